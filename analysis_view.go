@@ -0,0 +1,94 @@
+package domino
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// AnalysisView lists the human moves of the last hand, each graded against
+// the best move.
+type AnalysisView struct {
+	*tview.Table
+	analysis engine.Analysis
+	names    []string
+}
+
+func NewAnalysisView() *AnalysisView {
+	view := &AnalysisView{Table: tview.NewTable().SetFixed(1, 0)}
+	view.SetBorder(true).SetTitle("Analysis [t export text, w export HTML, Esc close]")
+	return view
+}
+
+var verdictColors = map[engine.Verdict]tcell.Color{
+	engine.Best:       tcell.ColorGreen,
+	engine.Inaccuracy: tcell.ColorYellow,
+	engine.Blunder:    tcell.ColorRed,
+}
+
+// Show fills the table with the analysis, players named by names.
+func (v *AnalysisView) Show(a engine.Analysis, names []string) {
+	v.analysis, v.names = a, names
+	v.Clear()
+	for col, h := range []string{"Move", "Player", "Played", "Best", "Verdict", "Loss", "Judged by"} {
+		v.SetCell(0, col, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	if len(a.Reviews) == 0 {
+		v.SetCell(1, 1, tview.NewTableCell("No move to review, every human move was forced"))
+		return
+	}
+
+	for i, rev := range a.Reviews {
+		judge := fmt.Sprintf("%d rollouts", engine.Rollouts)
+		if rev.Solved {
+			judge = "solver"
+		}
+
+		cells := []string{
+			fmt.Sprint(rev.Turn),
+			tview.Escape(names[rev.Player]),
+			tview.Escape(rev.Played.String()),
+			tview.Escape(rev.Best.String()),
+			string(rev.Verdict),
+			fmt.Sprintf("%.1f", rev.Loss),
+			judge,
+		}
+		for col, text := range cells {
+			v.SetCell(i+1, col, tview.NewTableCell(text).SetTextColor(verdictColors[rev.Verdict]))
+		}
+	}
+
+	summary := fmt.Sprintf("%d best, %d inaccuracies, %d blunders", a.Count(engine.Best), a.Count(engine.Inaccuracy), a.Count(engine.Blunder))
+	v.SetCell(len(a.Reviews)+2, 1, tview.NewTableCell(summary))
+}
+
+// Fail shows why the hand could not be analysed instead of any review.
+func (v *AnalysisView) Fail(err error) {
+	v.analysis, v.names = engine.Analysis{}, nil
+	v.Clear()
+	v.SetCell(0, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("The hand could not be analysed: %v", err))).SetTextColor(tcell.ColorRed))
+}
+
+// Export writes the analysis into the directory and returns the file name.
+// Format is either "txt" or "html".
+func (v *AnalysisView) Export(dir, format string) (string, error) {
+	path := filepath.Join(dir, "analysis."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if format == "html" {
+		err = v.analysis.WriteHTML(f, v.names)
+	} else {
+		err = v.analysis.WriteText(f, v.names)
+	}
+
+	return path, err
+}
@@ -0,0 +1,73 @@
+package domino
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// BettingView shows the chips of a wagering hand: every stack and bet, the
+// pots and, for the player on turn, what calling costs and the raise they
+// are about to make.
+type BettingView struct {
+	*tview.TextView
+}
+
+func NewBettingView() *BettingView {
+	view := &BettingView{TextView: tview.NewTextView().SetDynamicColors(true)}
+	view.SetBorder(true).SetTitle("Chips")
+	return view
+}
+
+// Refresh redraws the chips. names holds the seat names and raise the
+// amount the player on turn would raise by.
+func (v *BettingView) Refresh(b *engine.Betting, names []string, raise int) {
+	v.Clear()
+	for i, name := range names {
+		state := ""
+		switch {
+		case b.Folded[i]:
+			state = " [gray]folded[white]"
+		case b.AllIn(i):
+			state = " [red]all in[white]"
+		case i == b.Turn:
+			state = " [yellow]on turn[white]"
+		}
+
+		fmt.Fprintf(v, "%s: %d behind, %d in%s\n", tview.Escape(name), b.Stacks[i], b.Committed[i], state)
+	}
+
+	for i, pot := range b.Pots() {
+		eligible := []string{}
+		for _, p := range pot.Eligible {
+			eligible = append(eligible, names[p])
+		}
+
+		label := "Pot"
+		if i > 0 {
+			label = fmt.Sprintf("Side pot %d", i)
+		}
+		fmt.Fprintf(v, "[::b]%s %d[::-] for %s\n", label, pot.Amount, tview.Escape(strings.Join(eligible, ", ")))
+	}
+
+	if b.Turn < 0 {
+		return
+	}
+
+	keys := []string{}
+	for _, k := range b.Legal() {
+		switch k {
+		case engine.BetCheck:
+			keys = append(keys, "c check")
+		case engine.BetCall:
+			keys = append(keys, fmt.Sprintf("c call %d", b.ToCall(b.Turn)))
+		case engine.BetRaise:
+			keys = append(keys, fmt.Sprintf("r raise %d (+/- to change)", raise))
+		case engine.BetFold:
+			keys = append(keys, "f fold")
+		}
+	}
+	fmt.Fprintf(v, "[green]%s[white]\n", strings.Join(keys, ", "))
+}
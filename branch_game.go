@@ -0,0 +1,357 @@
+package domino
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// placement puts a tile on a target of a layout: a train, a node of a
+// chicken foot or an end of an all fives line.
+type placement struct {
+	tile   engine.Tile
+	target int
+}
+
+// layout adapts a round in which tiles go onto one of several targets to
+// BranchGame. Over, Draw, Pass and Result come from the engine round.
+type layout interface {
+	Over() bool
+	Draw() (engine.Tile, error)
+	Pass() error
+	Result() engine.Result
+
+	turn() int
+	hand(player int) []engine.Tile
+	legal(player int) []placement
+	play(p placement) error
+	// targets lists what the selection cycles through
+	targets() []int
+	targetName(target int) string
+	// after describes what a move left the other players to do
+	after(p placement) string
+	hint() (placement, string, bool)
+	// chooser snapshots the position for a strategy thinking outside the
+	// UI thread
+	chooser(s engine.Strategy) func() (placement, bool)
+	board() tview.Primitive
+	refresh(selected int)
+	describe() string
+}
+
+// BranchGame is the terminal screen of games played on several lines at
+// once, Mexican Train, Chicken Foot and the spinner games. Like Game it is
+// only a view, the rules live in the engine rounds.
+type BranchGame struct {
+	*tableGame
+
+	round layout
+	top   *tview.Flex
+	hand  *tview.Table
+	// tile and target are the selection of the human player on turn
+	tile   int
+	target int
+}
+
+// NewBranchGame returns the screen for a Mexican Train, Chicken Foot or
+// All Fives ruleset.
+func NewBranchGame(rules engine.Ruleset) *BranchGame {
+	game := &BranchGame{
+		tableGame: newTableGame(rules),
+		top:       tview.NewFlex(),
+		hand:      tview.NewTable(),
+	}
+	game.variant = game
+
+	game.hand.SetBorder(true).SetTitle("Your hand")
+
+	game.AddItem(game.top, 0, 1, false)
+	game.AddItem(game.hand, 3, 0, false)
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	game.Log(fmt.Sprintf("Waiting for players of %s...", rules.Name))
+	return game
+}
+
+func (g *BranchGame) seated(seat int) string {
+	return ""
+}
+
+func (g *BranchGame) wentOut(seat int) bool {
+	return len(g.round.hand(seat)) == 0
+}
+
+// deal starts the next hand of the match.
+func (g *BranchGame) deal() {
+	g.finish = false
+	hand := len(g.match.Hands)
+	switch g.rules.Variant {
+	case engine.VariantChickenFoot:
+		g.round = newFootLayout(engine.DealFoot(g.rng, g.rules, len(g.seats), hand), g.names())
+	case engine.VariantAllFives:
+		g.round = newSpinnerLayout(engine.DealSpinner(g.rng, g.rules, len(g.seats), g.match.LastWinner()), g.names())
+	default:
+		g.round = newTrainLayout(engine.DealTrains(g.rng, g.rules, len(g.seats), hand), g.names())
+	}
+
+	g.top.Clear()
+	g.top.AddItem(g.round.board(), 0, 2, false)
+	g.top.AddItem(g.log, 0, 1, false)
+	g.Log(fmt.Sprintf("Hand %d %s, %s starts", hand+1, g.round.describe(), g.current().name))
+	g.Log("Keys: Left/Right tile, Up/Down target, Enter play, d draw, p pass, h hint")
+	g.startTurn()
+}
+
+func (g *BranchGame) current() *tableSeat {
+	return g.seats[g.round.turn()]
+}
+
+func (g *BranchGame) names() []string {
+	names := make([]string, len(g.seats))
+	for i, s := range g.seats {
+		names[i] = s.name
+	}
+
+	return names
+}
+
+// startTurn refreshes the screen for the player on turn and lets a CPU
+// player think outside the UI thread.
+func (g *BranchGame) startTurn() {
+	if g.round.Over() {
+		g.end()
+		return
+	}
+
+	seat := g.current()
+	g.tile, g.target = 0, -1
+	if legal := g.round.legal(g.round.turn()); len(legal) > 0 && seat.strategy == nil {
+		g.tile = g.handIndex(legal[0].tile)
+		g.target = legal[0].target
+	}
+	g.refresh()
+
+	if seat.strategy == nil {
+		return
+	}
+
+	choose := g.round.chooser(seat.strategy)
+	go func() {
+		start := time.Now()
+		move, ok := choose()
+		time.Sleep(700*time.Millisecond - time.Since(start))
+		g.App.QueueUpdateDraw(func() {
+			if g.current() != seat || g.finish {
+				return
+			}
+
+			if !ok {
+				g.drawOrPass()
+				return
+			}
+
+			g.play(move)
+		})
+	}()
+}
+
+func (g *BranchGame) refresh() {
+	seat := g.current()
+	g.round.refresh(g.target)
+	g.hand.Clear()
+	if seat.strategy != nil {
+		g.hand.SetTitle(fmt.Sprintf("%s is thinking", seat.name))
+	} else {
+		g.hand.SetTitle(fmt.Sprintf("%s's hand", seat.name))
+		legal := g.round.legal(g.round.turn())
+		for i, t := range g.round.hand(g.round.turn()) {
+			color := tcell.ColorDarkGray
+			for _, m := range legal {
+				if m.tile.Same(t) {
+					color = tcell.ColorGreen
+				}
+			}
+
+			cell := tview.NewTableCell(tview.Escape(t.String())).SetTextColor(color)
+			if i == g.tile {
+				cell.SetAttributes(tcell.AttrReverse)
+			}
+			g.hand.SetCell(0, i, cell)
+		}
+	}
+
+	progress := fmt.Sprintf("[HAND:%d/%d]", len(g.match.Hands)+1, g.rules.Hands)
+	if g.rules.Hands <= 0 {
+		progress = fmt.Sprintf("[HAND:%d] [TARGET:%d]", len(g.match.Hands)+1, g.rules.TargetScore)
+	}
+
+	g.status.SetText(fmt.Sprintf("[black::b]%s %s [ON TURN:%s]", g.rules.Name, progress, seat.name))
+}
+
+func (g *BranchGame) handIndex(t engine.Tile) int {
+	for i, h := range g.round.hand(g.round.turn()) {
+		if h.Same(t) {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// moveTarget steps the target selection through the layout's targets.
+func (g *BranchGame) moveTarget(step int) {
+	targets := g.round.targets()
+	if len(targets) == 0 {
+		return
+	}
+
+	at := -1
+	for i, t := range targets {
+		if t == g.target {
+			at = i
+		}
+	}
+
+	if at < 0 {
+		g.target = targets[0]
+		return
+	}
+
+	g.target = targets[(at+step+len(targets))%len(targets)]
+}
+
+func (g *BranchGame) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.round == nil {
+		return event
+	}
+
+	if g.finish {
+		switch {
+		case g.match.Over() && g.OnEnd != nil && event.Key() == tcell.KeyEnter:
+			g.App.Stop()
+		case event.Rune() == 'n':
+			if g.match.Over() {
+				g.match = engine.NewMatch(g.rules, len(g.seats))
+			}
+			g.deal()
+		}
+		return nil
+	}
+
+	if g.current().strategy != nil {
+		return event
+	}
+
+	hand := g.round.hand(g.round.turn())
+	switch event.Key() {
+	case tcell.KeyLeft:
+		g.tile = (g.tile + len(hand) - 1) % len(hand)
+	case tcell.KeyRight:
+		g.tile = (g.tile + 1) % len(hand)
+	case tcell.KeyUp:
+		g.moveTarget(-1)
+	case tcell.KeyDown:
+		g.moveTarget(1)
+	case tcell.KeyEnter:
+		if g.target < 0 {
+			g.Log("Select where to play with Up/Down first")
+			return nil
+		}
+		g.play(placement{tile: hand[g.tile], target: g.target})
+		return nil
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'd', 'p':
+			g.drawOrPass()
+			return nil
+		case 'h':
+			g.showHint()
+		}
+	}
+
+	g.refresh()
+	return nil
+}
+
+// play makes the move for the player on turn and logs it.
+func (g *BranchGame) play(p placement) {
+	seat := g.current()
+	name := g.round.targetName(p.target)
+	if err := g.round.play(p); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s played %s on %s", seat.name, tview.Escape(p.tile.String()), name))
+	if msg := g.round.after(p); msg != "" {
+		g.Log(msg)
+	}
+	g.startTurn()
+}
+
+// drawOrPass draws for the player on turn when allowed, otherwise passes.
+func (g *BranchGame) drawOrPass() {
+	seat := g.current()
+	if t, err := g.round.Draw(); err == nil {
+		if seat.strategy == nil {
+			g.Log(fmt.Sprintf("%s drew %s", seat.name, tview.Escape(t.String())))
+		} else {
+			g.Log(fmt.Sprintf("%s drew a tile", seat.name))
+		}
+		g.startTurn()
+		return
+	}
+
+	if err := g.round.Pass(); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s passes", seat.name))
+	g.startTurn()
+}
+
+// showHint selects the move the CPU evaluation would pick and explains it.
+func (g *BranchGame) showHint() {
+	best, reason, ok := g.round.hint()
+	if !ok {
+		g.Log("No playable tile, press d to draw or pass")
+		return
+	}
+
+	g.tile, g.target = g.handIndex(best.tile), best.target
+	g.Log(fmt.Sprintf("Hint: play %s on %s, %s", tview.Escape(best.tile.String()), g.round.targetName(best.target), reason))
+}
+
+func (g *BranchGame) end() {
+	result := g.round.Result()
+	g.match.Add(result)
+	g.finish = true
+	g.target = -1
+	g.refresh()
+	g.Log(fmt.Sprintf("[::b]HAND FINISHED: %s", result.Reason))
+	for i, s := range g.seats {
+		g.Log(fmt.Sprintf("%s: +%d, total %d", s.name, result.Points[i], g.match.Scores[i]))
+	}
+
+	g.recordResult(result)
+	if !g.match.Over() {
+		g.Log("Press n to deal the next hand")
+		return
+	}
+
+	for _, w := range g.match.Winners() {
+		g.Log(fmt.Sprintf("[::b]MATCH WON by %s with %d points", g.seats[w].name, g.match.Scores[w]))
+	}
+
+	if g.OnEnd != nil {
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	} else {
+		g.Log("Press n to start a new match")
+	}
+}
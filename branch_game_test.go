@@ -0,0 +1,38 @@
+package domino
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestBranchGame(t *testing.T) {
+	for _, name := range []string{"mexican-train", "chicken-foot", "all-fives"} {
+		rules, _ := engine.LookupRuleset(name)
+		game := NewBranchGame(rules)
+		for i := 0; i < rules.Players; i++ {
+			game.Join(fmt.Sprintf("p%d", i+1), false)
+		}
+
+		if !game.Started() || len(game.round.targets()) == 0 {
+			t.Fatalf("%s: expecting a dealt hand with somewhere to play", name)
+		}
+
+		// a row per player's train and one for the Mexican train
+		if trains, ok := game.round.(*trainLayout); ok && trains.view.GetRowCount() != rules.Players+1 {
+			t.Fatalf("%s: expecting %d trains on the board but got %d rows", name, rules.Players+1, trains.view.GetRowCount())
+		}
+
+		turn, tiles := game.round.turn(), len(game.round.hand(game.round.turn()))
+		if legal := game.round.legal(turn); len(legal) == 0 {
+			game.drawOrPass()
+		} else {
+			game.play(legal[0])
+		}
+
+		if game.round.turn() == turn && len(game.round.hand(turn)) == tiles {
+			t.Fatalf("%s: expecting player %d to have moved, drawn or passed", name, turn+1)
+		}
+	}
+}
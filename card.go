@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
 	"github.com/rivo/tview"
 )
 
@@ -17,6 +18,13 @@ type Card struct {
 	higlighted        bool
 	next, prev        *Card
 	hideNotPlayedCard bool
+	// marked is set while the card's owner is on turn, playable tells
+	// whether it fits the line of play at that moment
+	marked   bool
+	playable bool
+	// chinese paints the pips the way the Chinese set does, see
+	// engine.RedPips
+	chinese bool
 }
 
 // NewCard returns a new radio button primitive.
@@ -33,19 +41,61 @@ func NewCard(x int, y int) *Card {
 	return card
 }
 
+// NewChineseCard returns a card of the Chinese set.
+func NewChineseCard(x int, y int) *Card {
+	card := NewCard(x, y)
+	card.chinese = true
+	return card
+}
+
+// Tile returns the domino shown by this card.
+func (card *Card) Tile() engine.Tile {
+	return engine.Tile{A: card.X, B: card.Y}
+}
+
 func (card *Card) Highlight() {
 	card.SetBorderColor(tcell.ColorYellow)
 	card.higlighted = true
 }
 
 func (card *Card) ClearHighlight() {
-	if card.Played {
-		card.SetBorderColor(tcell.ColorRed)
-	} else {
-		card.SetBorderColor(tcell.ColorBlue)
-	}
-	card.SetTitle(fmt.Sprintf("[%d,%d]", card.X, card.Y))
 	card.higlighted = false
+	card.SetBorderColor(card.borderColor())
+	card.SetTitle(fmt.Sprintf("[%d,%d]", card.X, card.Y))
+}
+
+// MarkPlayable shows whether the card can be played this turn: playable
+// cards get a green border, the others are dimmed.
+func (card *Card) MarkPlayable(playable bool) {
+	card.marked = true
+	card.playable = playable
+	card.SetBorderColor(card.borderColor())
+}
+
+// ClearMark removes the playable/dimmed marking.
+func (card *Card) ClearMark() {
+	card.marked = false
+	card.playable = false
+	card.SetBorderColor(card.borderColor())
+}
+
+func (card *Card) dimmed() bool {
+	return card.marked && !card.playable && !card.Played
+}
+
+func (card *Card) borderColor() tcell.Color {
+	switch {
+	case card.higlighted:
+		return tcell.ColorYellow
+	case card.Played:
+		return tcell.ColorRed
+	case card.marked && card.playable:
+		return tcell.ColorGreen
+	case card.dimmed():
+		return tcell.ColorDarkGray
+	}
+
+	return tcell.ColorBlue
 }
 
 func (card *Card) Flip() {
@@ -69,6 +119,10 @@ func (r *Card) Draw(screen tcell.Screen) {
 	x, y, width, height := r.GetInnerRect()
 	curY, curX, check, i := y, x, rune('\u25c9'), 0
 	offsetX, offsetY := ((width/2)/2)+x, 0
+	style := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+	if r.dimmed() {
+		style = tcell.StyleDefault.Foreground(tcell.ColorDarkGray)
+	}
 
 	if !r.Played && r.hideNotPlayedCard {
 		r.SetTitle("[?,?]")
@@ -91,21 +145,31 @@ func (r *Card) Draw(screen tcell.Screen) {
 	for i < r.X {
 		curY = i / 2
 		curX = i % 2
-		screen.SetContent(curX*(width/2)+offsetX, offsetY+y+curY, check, nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
+		screen.SetContent(curX*(width/2)+offsetX, offsetY+y+curY, check, nil, r.pipStyle(style, i, r.X))
 		i++
 	}
 
 	// Draw a horizontal line across the middle of the box.
 	offsetY = height / 2
 	for cx := x + 1; cx < x+width-1; cx++ {
-		screen.SetContent(cx, offsetY+y-1, tview.BoxDrawingsHeavyHorizontal, nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
+		screen.SetContent(cx, offsetY+y-1, tview.BoxDrawingsHeavyHorizontal, nil, style)
 	}
 
 	i = 0
 	for i < r.Y {
 		curY = i / 2
 		curX = i % 2
-		screen.SetContent(curX*(width/2)+offsetX, offsetY+y+curY, check, nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
+		screen.SetContent(curX*(width/2)+offsetX, offsetY+y+curY, check, nil, r.pipStyle(style, i, r.Y))
 		i++
 	}
 }
+
+// pipStyle paints the i-th pip of a half showing n red when the Chinese set
+// does.
+func (r *Card) pipStyle(style tcell.Style, i, n int) tcell.Style {
+	if r.chinese && !r.dimmed() && i < engine.RedPips(r.Tile(), n) {
+		return style.Foreground(tcell.ColorRed)
+	}
+
+	return style
+}
@@ -0,0 +1,191 @@
+// Command simulate plays CPU strategies against each other without a screen
+// and reports how they compare.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+// seatStats accumulates the results of one configured strategy.
+type seatStats struct {
+	name    string
+	wins    float64
+	pips    float64
+	pipsSq  float64
+	games   int
+	dominos int
+}
+
+type outcome struct {
+	order  []int
+	result engine.Result
+	err    error
+}
+
+func main() {
+	games := flag.Int("n", 1000, "number of games to play")
+	names := flag.String("strategies", "balanced,heavy,random", "comma separated CPU strategies, one per seat ("+strings.Join(engine.Strategies(), ", ")+" or external:<command>)")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of games played in parallel")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed, the same seed replays the same games")
+	rules := flag.String("rules", engine.DefaultRuleset.Name, "ruleset name ("+strings.Join(engine.Rulesets(), ", ")+") or path to a ruleset file")
+	handSize := flag.Int("hand", 0, "tiles dealt to every player, 0 keeps the ruleset's hand size")
+	flag.Parse()
+
+	seats := strings.Split(*names, ",")
+	ruleset, err := engine.FindRuleset("", *rules)
+	if err == nil {
+		ruleset.Players = len(seats)
+		if *handSize > 0 {
+			ruleset.HandSize = *handSize
+		}
+		err = ruleset.Validate()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, name := range seats {
+		if err := ruleset.CheckStrategy(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		s, err := engine.NewStrategy(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+
+	jobs := make(chan int)
+	results := make(chan outcome)
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- play(*seed+int64(i), i, ruleset, seats)
+			}
+		}()
+	}
+
+	go func() {
+		for i := 0; i < *games; i++ {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	stats := make([]*seatStats, len(seats))
+	for i, name := range seats {
+		stats[i] = &seatStats{name: fmt.Sprintf("%d:%s", i+1, name)}
+	}
+
+	played, aborted, turns, turnsSq, blocked := 0, 0, 0.0, 0.0, 0
+	for o := range results {
+		if o.err != nil {
+			fmt.Fprintln(os.Stderr, o.err)
+			os.Exit(1)
+		}
+
+		if o.order == nil {
+			aborted++
+			continue
+		}
+
+		played++
+		turns += float64(o.result.Turns)
+		turnsSq += float64(o.result.Turns * o.result.Turns)
+		if o.result.Blocked {
+			blocked++
+		}
+
+		for seat, idx := range o.order {
+			s := stats[idx]
+			pips := float64(o.result.Pips[seat])
+			s.games++
+			s.pips += pips
+			s.pipsSq += pips * pips
+			if o.result.IsWinner(seat) {
+				// a shared win counts as a fraction of a win
+				s.wins += 1 / float64(len(o.result.Winners))
+				if !o.result.Blocked {
+					s.dominos++
+				}
+			}
+		}
+	}
+
+	fmt.Printf("%s games: %d played, %d could not open, %d blocked (seed %d)\n", ruleset.Name, played, aborted, blocked, *seed)
+	if played == 0 {
+		return
+	}
+
+	mean, ci := meanCI(turns, turnsSq, played)
+	fmt.Printf("game length: %.1f ± %.2f turns\n\n", mean, ci)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "strategy\twin rate\t95% CI\tavg pips left\t95% CI\tdominoes\t")
+	for _, s := range stats {
+		rate := s.wins / float64(s.games)
+		rateCI := 1.96 * math.Sqrt(rate*(1-rate)/float64(s.games))
+		pips, pipsCI := meanCI(s.pips, s.pipsSq, s.games)
+		fmt.Fprintf(w, "%s\t%.1f%%\t±%.1f%%\t%.2f\t±%.2f\t%d\t\n", s.name, rate*100, rateCI*100, pips, pipsCI, s.dominos)
+	}
+	w.Flush()
+}
+
+// play deals and plays one game. Seats are rotated with the game number so
+// every strategy sits in every position equally often; order maps seats to
+// the configured strategies and is nil when the deal could not be opened.
+func play(seed int64, game int, rules engine.Ruleset, seats []string) outcome {
+	order := make([]int, len(seats))
+	names := make([]string, len(seats))
+	for seat := range seats {
+		order[seat] = (seat + game) % len(seats)
+		names[seat] = seats[order[seat]]
+	}
+
+	result, ok, err := engine.PlayRound(seed, rules, names)
+	if !ok {
+		order = nil
+	}
+
+	return outcome{order: order, result: result, err: err}
+}
+
+// meanCI returns the mean of n samples and the half width of its 95%
+// confidence interval from their sum and sum of squares.
+func meanCI(sum, sumSq float64, n int) (float64, float64) {
+	mean := sum / float64(n)
+	if n < 2 {
+		return mean, 0
+	}
+
+	variance := (sumSq - sum*mean) / float64(n-1)
+	if variance < 0 {
+		variance = 0
+	}
+
+	return mean, 1.96 * math.Sqrt(variance/float64(n))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gusti-andika/domino"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+)
+
+func main() {
+	kinds := []string{}
+	for _, k := range engine.SolitaireKinds() {
+		kinds = append(kinds, string(k))
+	}
+
+	game := flag.String("game", string(engine.Luzon), "solitaire to play: "+strings.Join(kinds, ", "))
+	seed := flag.Int64("seed", 0, "deal to play, the same seed always gives the same deal; 0 deals at random")
+	daily := flag.Bool("daily", false, "play the daily challenge, the same deal for everybody today")
+	name := flag.String("name", "Player 1", "profile the results are recorded under")
+	flag.Parse()
+
+	store, err := profile.Load(profile.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profiles disabled: %v\n", err)
+		store = nil
+	}
+
+	s := domino.NewSolitaireGame(engine.SolitaireKind(*game))
+	s.Profiles, s.Player = store, *name
+	switch {
+	case *daily:
+		err = s.DealDaily(time.Now())
+	case *seed != 0:
+		err = s.Deal(*seed)
+	default:
+		err = s.Deal(time.Now().UnixNano() % 1000000)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	s.Run()
+}
@@ -1,12 +1,136 @@
 package main
 
-import "github.com/gusti-andika/domino"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gusti-andika/domino"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+)
+
+// table is the screen of any variant: line, branch, QiuQiu, Pai Gow, Tien
+// Gow or Texas 42.
+type table interface {
+	Join(playerName string, isCpu bool)
+	JoinCpu(playerName string, strategy string) error
+	Run()
+}
 
 func main() {
+	rulesDir := flag.String("rules-dir", filepath.Join(filepath.Dir(profile.DefaultPath()), "rules"), "directory of house ruleset files (.json, .yaml, .toml)")
+	rules := flag.String("rules", engine.DefaultRuleset.Name, "ruleset name, or path to a ruleset file")
+	players := flag.String("players", "",
+		"comma separated seats: human, cpu:<strategy> ("+strings.Join(engine.Strategies(), ", ")+") or external:<command>; defaults to one human against CPUs")
+	opening := flag.String("opening", "", "override the ruleset's opening: highest-double, winner, boneyard, double-blank or double-six")
+	tie := flag.String("tie", "", "override the ruleset's tie rule: lowest-tile, shared or none")
+	scoring := flag.String("scoring", "", "override the ruleset's scoring: opponents, net, penalty, gaple, fives (all-fives only) or bergen")
+	puzzle := flag.String("puzzle", "", "solve a puzzle instead of playing: a built-in puzzle ("+strings.Join(engine.Puzzles(), ", ")+") or a puzzle file")
+	flag.Parse()
+
+	if *puzzle != "" {
+		p, err := engine.FindPuzzle(*puzzle)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		game := domino.NewGame()
+		if err := game.LoadPuzzle(p, "Player 1"); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		game.Run()
+		return
+	}
+
+	ruleset, err := engine.FindRuleset(*rulesDir, *rules)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *opening != "" {
+		ruleset.Opening = engine.Opening(*opening)
+	}
+	if *tie != "" {
+		ruleset.Tie = engine.TieRule(*tie)
+	}
+	if *scoring != "" {
+		ruleset.Scoring = engine.Scoring(*scoring)
+	}
+
+	seats := []string{"human"}
+	for len(seats) < ruleset.Players {
+		seats = append(seats, "cpu:"+engine.DefaultStrategy)
+	}
+	if *players != "" {
+		seats = strings.Split(*players, ",")
+		ruleset.Players = len(seats)
+	}
+
+	if err := ruleset.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	store, err := profile.Load(profile.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profiles disabled: %v\n", err)
+		store = nil
+	}
+
+	var game table
+	switch ruleset.Variant {
+	case engine.VariantMexicanTrain, engine.VariantChickenFoot, engine.VariantAllFives:
+		branch := domino.NewBranchGame(ruleset)
+		branch.Profiles = store
+		game = branch
+	case engine.VariantQiuQiu:
+		qiu := domino.NewQiuGame(ruleset)
+		qiu.Profiles = store
+		game = qiu
+	case engine.VariantPaiGow:
+		pg := domino.NewPaiGowGame(ruleset)
+		pg.Profiles = store
+		game = pg
+	case engine.VariantTienGow:
+		tg := domino.NewTienGowGame(ruleset)
+		tg.Profiles = store
+		game = tg
+	case engine.VariantTexas42:
+		tx := domino.NewTexas42Game(ruleset)
+		tx.Profiles = store
+		game = tx
+	default:
+		line := domino.NewGame()
+		line.SetRules(ruleset)
+		line.Profiles = store
+		game = line
+	}
+
+	for i, seat := range seats {
+		name := fmt.Sprintf("Player %d", i+1)
+		switch {
+		case seat == "human":
+			game.Join(name, false)
+		case strings.HasPrefix(seat, "cpu:"):
+			err = game.JoinCpu(name, strings.TrimPrefix(seat, "cpu:"))
+		case strings.HasPrefix(seat, engine.ExternalPrefix):
+			err = game.JoinCpu(name, seat)
+		default:
+			err = fmt.Errorf("unknown seat %q", seat)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
-	game := domino.NewGame()
-	game.Join("Player 1", false)
-	game.Join("Player 2", true)
-	game.Join("Player 3", true)
 	game.Run()
 }
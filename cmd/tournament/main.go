@@ -0,0 +1,206 @@
+// Command tournament runs a round-robin, Swiss or single-elimination
+// tournament among humans and CPU strategies. CPU-only tables are played
+// without a screen, tables with a human open the terminal game.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gusti-andika/domino"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/tournament"
+)
+
+func main() {
+	format := flag.String("format", "roundrobin", "roundrobin, swiss or elimination")
+	players := flag.String("players", "Player 1,cpu:balanced,cpu:heavy,cpu:random", "comma separated entrants, cpu:<strategy> for CPU players")
+	table := flag.Int("table", 3, "players per table")
+	rounds := flag.Int("rounds", 0, "number of Swiss rounds, 0 picks one from the field size")
+	state := flag.String("state", "tournament.json", "file the tournament is saved to and resumed from")
+	rules := flag.String("rules", engine.DefaultRuleset.Name, "ruleset name ("+strings.Join(engine.Rulesets(), ", ")+") or path to a ruleset file")
+	flag.Parse()
+
+	ruleset, err := engine.FindRuleset("", *rules)
+	if err == nil {
+		ruleset.Players = *table
+		err = ruleset.Validate()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	t, err := tournament.Load(*state)
+	if err == nil {
+		fmt.Printf("Resuming %s tournament from %s\n", t.Format, *state)
+	} else if os.IsNotExist(err) {
+		entrants := []tournament.Entrant{}
+		for _, name := range strings.Split(*players, ",") {
+			entrants = append(entrants, tournament.ParseEntrant(name))
+		}
+
+		t, err = tournament.New(tournament.Format(*format), *table, *rounds, entrants)
+		if err == nil {
+			err = t.SaveTo(*state)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, e := range t.Entrants {
+		if err := ruleset.CheckStrategy(e.Strategy); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	store, err := profile.Load(profile.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profiles disabled: %v\n", err)
+	}
+
+	for games := t.Pending(); games != nil; games = t.Pending() {
+		for _, g := range games {
+			result, ok := playTable(t, g, ruleset, store)
+			if !ok {
+				fmt.Printf("Tournament interrupted, run again to resume from %s\n", *state)
+				return
+			}
+
+			if err := t.Record(g, result); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			if result.Winner < 0 {
+				fmt.Printf("round %d: no winner\n", g.Round)
+			} else {
+				fmt.Printf("round %d: %s wins\n", g.Round, t.Entrants[g.Seats[result.Winner]].Name)
+			}
+		}
+
+		fmt.Println()
+		fmt.Print(t.Report())
+		fmt.Println()
+	}
+
+	fmt.Println("Final standings")
+	fmt.Print(t.Report())
+}
+
+// playTable plays one match of the tournament by the ruleset. It reports
+// false when a human quit before the match ended.
+func playTable(t *tournament.Tournament, g *tournament.Game, rules engine.Ruleset, store *profile.Store) (tournament.Result, bool) {
+	strategies := []string{}
+	for _, e := range g.Seats {
+		if !t.Entrants[e].IsCpu() {
+			return playWithHumans(t, g, rules, store)
+		}
+		strategies = append(strategies, t.Entrants[e].Strategy)
+	}
+
+	for seed := time.Now().UnixNano(); ; seed++ {
+		m, err := engine.PlayMatch(seed, rules, strategies)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		if res := tableResult(m); res.Single() || t.Format != tournament.SingleElimination {
+			return res, true
+		}
+	}
+}
+
+// table is the screen of any variant: line, branch, QiuQiu, Pai Gow, Tien
+// Gow or Texas 42.
+type table interface {
+	Join(playerName string, isCpu bool)
+	JoinCpu(playerName string, strategy string) error
+	Started() bool
+	Run()
+}
+
+func playWithHumans(t *tournament.Tournament, g *tournament.Game, rules engine.Ruleset, store *profile.Store) (tournament.Result, bool) {
+	for {
+		var result *tournament.Result
+		onEnd := func(m *engine.Match) {
+			r := tableResult(m)
+			result = &r
+		}
+
+		var game table
+		switch rules.Variant {
+		case engine.VariantMexicanTrain, engine.VariantChickenFoot, engine.VariantAllFives:
+			branch := domino.NewBranchGame(rules)
+			branch.Profiles, branch.OnEnd = store, onEnd
+			game = branch
+		case engine.VariantQiuQiu:
+			qiu := domino.NewQiuGame(rules)
+			qiu.Profiles, qiu.OnEnd = store, onEnd
+			game = qiu
+		case engine.VariantPaiGow:
+			pg := domino.NewPaiGowGame(rules)
+			pg.Profiles, pg.OnEnd = store, onEnd
+			game = pg
+		case engine.VariantTienGow:
+			tg := domino.NewTienGowGame(rules)
+			tg.Profiles, tg.OnEnd = store, onEnd
+			game = tg
+		case engine.VariantTexas42:
+			tx := domino.NewTexas42Game(rules)
+			tx.Profiles, tx.OnEnd = store, onEnd
+			game = tx
+		default:
+			line := domino.NewGame()
+			line.SetRules(rules)
+			line.Profiles, line.OnEnd = store, onEnd
+			game = line
+		}
+
+		for _, e := range g.Seats {
+			entrant := t.Entrants[e]
+			if !entrant.IsCpu() {
+				game.Join(entrant.Name, false)
+			} else if err := game.JoinCpu(entrant.Name, entrant.Strategy); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		if !game.Started() {
+			continue
+		}
+
+		game.Run()
+		if result == nil {
+			return tournament.Result{}, false
+		}
+
+		if !result.Single() && t.Format == tournament.SingleElimination {
+			fmt.Println("No single winner, the table is replayed")
+			continue
+		}
+
+		return *result, true
+	}
+}
+
+func tableResult(m *engine.Match) tournament.Result {
+	last := m.Hands[len(m.Hands)-1]
+	res := tournament.Result{Winner: -1, Winners: m.Winners(), Pips: m.Pips, Blocked: last.Blocked}
+	if len(res.Winners) > 0 {
+		res.Winner = res.Winners[0]
+	}
+
+	return res
+}
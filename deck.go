@@ -4,35 +4,56 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
+
+	"github.com/gusti-andika/domino/engine"
 )
 
 type Deck struct {
 	offset int
-	cards  [21]*Card
+	cards  []*Card
 	last   int
 	game   *Game
+	rules  engine.Ruleset
 }
 
 func NewDeck(game *Game) *Deck {
-	return &Deck{game: game}
+	return &Deck{game: game, rules: engine.DefaultRuleset}
+}
+
+// NewRulesDeck returns a deck of the ruleset's set for screens without a
+// Game, e.g. the 28 tiles of a double-six set.
+func NewRulesDeck(rules engine.Ruleset) *Deck {
+	return &Deck{rules: rules}
 }
 
+// Shuffle fills the deck with the set of the game's ruleset and shuffles it.
 func (d *Deck) Shuffle() {
+	d.ShuffleSeed(time.Now().UnixNano())
+}
+
+// ShuffleSeed is Shuffle with a given seed, the same seed always giving
+// the same deck, see engine.SolitaireTiles.
+func (d *Deck) ShuffleSeed(seed int64) {
+	rules := d.rules
+	if d.game != nil && d.game.rules.Name != "" {
+		rules = d.game.rules
+	}
+
+	set := rules.Set()
+	d.cards = make([]*Card, len(set))
 	index := 0
-	for i := 1; i <= 6; i++ {
-		for j := i; j <= 6; j++ {
-			card := NewCard(i, j)
-			card.SetBorder(true).
-				SetTitle(fmt.Sprintf("[%d,%d]", i, j)).
-				SetRect(0, 0, 10, 10)
-
-			d.cards[index] = card
-			index++
-		}
+	for _, t := range set {
+		card := NewCard(t.A, t.B)
+		card.chinese = rules.Tiles == engine.SetChinese
+		card.SetBorder(true).
+			SetTitle(fmt.Sprintf("[%d,%d]", t.A, t.B)).
+			SetRect(0, 0, 10, 10)
+
+		d.cards[index] = card
+		index++
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(d.cards), func(i, j int) {
+	rand.New(rand.NewSource(seed)).Shuffle(len(d.cards), func(i, j int) {
 		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
 	})
 
@@ -62,6 +83,16 @@ func (d *Deck) PopCards(num int) []*Card {
 	return newcards
 }
 
+// Tiles returns the tiles left in the deck, last one on top.
+func (d *Deck) Tiles() []engine.Tile {
+	tiles := make([]engine.Tile, 0, d.last)
+	for _, c := range d.cards[:d.last] {
+		tiles = append(tiles, c.Tile())
+	}
+
+	return tiles
+}
+
 // return number of cards in deck
 func (d *Deck) GetNum() int {
 	return d.last
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math/rand"
+)
+
+// Verdict grades a move against the best one.
+type Verdict string
+
+const (
+	Best       Verdict = "best"
+	Inaccuracy Verdict = "inaccuracy"
+	Blunder    Verdict = "blunder"
+)
+
+// InaccuracyLoss and BlunderLoss are the expected points a move has to
+// give away to be an inaccuracy or a blunder.
+const (
+	InaccuracyLoss = 1
+	BlunderLoss    = 10
+)
+
+// Rollouts is how many deals of the unseen tiles a move is played out on
+// when the solver can not see every tile.
+const Rollouts = 40
+
+// Review is the verdict on a single move.
+type Review struct {
+	// Turn counts the moves of the round from 1
+	Turn   int
+	Player int
+	Played Move
+	Best   Move
+	// Loss is what the played move is expected to cost against the best
+	// one: the payoff of the player, less the opponent's with two players
+	Loss    float64
+	Verdict Verdict
+	// Solved tells the solver saw every tile, otherwise the moves were
+	// played out by the balanced strategy on deals of the unseen tiles
+	Solved bool
+}
+
+// Analysis reviews the moves of a finished round.
+type Analysis struct {
+	Reviews []Review
+}
+
+// Analyze replays the events played from the start position and reviews
+// the moves of the seats. Block games are judged by the solver, the rest
+// by rollouts of every move.
+func Analyze(start *Round, events []Event, seats []int) (Analysis, error) {
+	a := Analysis{}
+	reviewed := map[int]bool{}
+	for _, s := range seats {
+		reviewed[s] = true
+	}
+
+	solver := NewSolver()
+	r, turn := start.Clone(), 0
+	for _, e := range events {
+		if e.Pass || e.Draw {
+			// passes and draws follow from the moves
+			continue
+		}
+
+		turn++
+		if r.Turn != e.Player {
+			return a, fmt.Errorf("move %d: player %d played out of turn", turn, e.Player+1)
+		}
+
+		if reviewed[e.Player] && len(r.Position(r.Turn).LegalMoves()) > 1 {
+			rev := review(solver, r, e.Move)
+			rev.Turn = turn
+			a.Reviews = append(a.Reviews, rev)
+		}
+
+		if _, err := r.Play(e.Move); err != nil {
+			return a, fmt.Errorf("move %d: %v", turn, err)
+		}
+	}
+
+	return a, nil
+}
+
+// review weighs the played move against every legal move.
+func review(solver *Solver, r *Round, played Move) Review {
+	rev := Review{Player: r.Turn, Played: played}
+	if r.Started() && r.Head() == r.Tail() {
+		// either end is the same move, LegalMoves only lists the head
+		played.End = Head
+	}
+
+	values, err := solver.Evaluate(r)
+	if err == nil {
+		rev.Solved, rev.Best = true, values[0].Move
+		for _, v := range values {
+			if v.Move == played {
+				rev.Loss = float64(solver.value(r, values[0].Payoffs) - solver.value(r, v.Payoffs))
+			}
+		}
+	} else {
+		best := 0.0
+		for i, m := range r.Position(r.Turn).LegalMoves() {
+			v := rollout(solver, r, m)
+			if i == 0 || v > best {
+				rev.Best, best = m, v
+			}
+			if m == played {
+				rev.Loss = -v
+			}
+		}
+		rev.Loss += best
+	}
+
+	switch {
+	case rev.Loss < InaccuracyLoss:
+		rev.Verdict = Best
+	case rev.Loss < BlunderLoss:
+		rev.Verdict = Inaccuracy
+	default:
+		rev.Verdict = Blunder
+	}
+
+	return rev
+}
+
+// rollout returns the value of the move to the player on turn averaged
+// over deals of the tiles they can not see, every seat then playing
+// balanced. Every move is played out on the same deals.
+func rollout(solver *Solver, r *Round, m Move) float64 {
+	players := make([]Strategy, len(r.Hands))
+	for i := range players {
+		players[i] = balancedStrategy{}
+	}
+
+	total := 0
+	for k := 0; k < Rollouts; k++ {
+		c := r.Clone()
+		unseen := append([]Tile(nil), c.Boneyard...)
+		for i, h := range c.Hands {
+			if i != c.Turn {
+				unseen = append(unseen, h...)
+			}
+		}
+
+		rand.New(rand.NewSource(int64(k))).Shuffle(len(unseen), func(i, j int) {
+			unseen[i], unseen[j] = unseen[j], unseen[i]
+		})
+		for i, h := range c.Hands {
+			if i != c.Turn {
+				c.Hands[i], unseen = unseen[:len(h):len(h)], unseen[len(h):]
+			}
+		}
+		c.Boneyard = unseen
+
+		c.Play(m)
+		c.Run(players)
+		total += solver.value(r, Payoffs(c))
+	}
+
+	return float64(total) / Rollouts
+}
+
+// Count returns how many moves got the verdict.
+func (a Analysis) Count(v Verdict) int {
+	n := 0
+	for _, rev := range a.Reviews {
+		if rev.Verdict == v {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Loss returns the expected points the player's moves gave away.
+func (a Analysis) Loss(player int) float64 {
+	loss := 0.0
+	for _, rev := range a.Reviews {
+		if rev.Player == player {
+			loss += rev.Loss
+		}
+	}
+
+	return loss
+}
+
+// WriteText exports the analysis as plain text, players named by names.
+func (a Analysis) WriteText(w io.Writer, names []string) error {
+	fmt.Fprintf(w, "%-5s %-12s %-16s %-16s %-10s %s\n", "Move", "Player", "Played", "Best", "Verdict", "Loss")
+	for _, rev := range a.Reviews {
+		fmt.Fprintf(w, "%-5d %-12s %-16s %-16s %-10s %.1f\n", rev.Turn, names[rev.Player], rev.Played, rev.Best, rev.Verdict, rev.Loss)
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d best, %d inaccuracies, %d blunders\n", a.Count(Best), a.Count(Inaccuracy), a.Count(Blunder))
+	return err
+}
+
+// WriteHTML exports the analysis as an HTML page, players named by names.
+func (a Analysis) WriteHTML(w io.Writer, names []string) error {
+	fmt.Fprint(w, "<!DOCTYPE html>\n<html>\n<head><title>Game analysis</title></head>\n<body>\n<h1>Game analysis</h1>\n<table>\n")
+	fmt.Fprint(w, "<tr><th>Move</th><th>Player</th><th>Played</th><th>Best</th><th>Verdict</th><th>Loss</th></tr>\n")
+	for _, rev := range a.Reviews {
+		fmt.Fprintf(w, "<tr class=%q><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%.1f</td></tr>\n",
+			rev.Verdict, rev.Turn, html.EscapeString(names[rev.Player]), rev.Played, rev.Best, rev.Verdict, rev.Loss)
+	}
+
+	_, err := fmt.Fprintf(w, "</table>\n<p>%d best, %d inaccuracies, %d blunders</p>\n</body>\n</html>\n", a.Count(Best), a.Count(Inaccuracy), a.Count(Blunder))
+	return err
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeSolved(t *testing.T) {
+	p, _ := FindPuzzle("two-handed")
+	start, _ := p.Round()
+	r := start.Clone()
+	for _, m := range r.Position(r.Turn).LegalMoves() {
+		if m != p.Solution()[0] {
+			r.Play(m)
+			break
+		}
+	}
+	r.Run([]Strategy{balancedStrategy{}, balancedStrategy{}})
+
+	a, err := Analyze(start, r.History, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := a.Reviews[0]
+	if !first.Solved || first.Turn != 1 || first.Best != p.Solution()[0] || first.Loss < InaccuracyLoss || first.Verdict == Best {
+		t.Fatalf("Expecting the first move flagged against the solution but got %+v", first)
+	}
+
+	for _, rev := range a.Reviews {
+		if rev.Player != 0 {
+			t.Fatalf("Expecting only player 1 reviewed but got %+v", rev)
+		}
+	}
+
+	var buf bytes.Buffer
+	a.WriteText(&buf, []string{"ana", "cpu"})
+	if !strings.Contains(buf.String(), string(first.Verdict)) || !strings.Contains(buf.String(), "ana") {
+		t.Fatalf("Unexpected text export:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	a.WriteHTML(&buf, []string{"<ana>", "cpu"})
+	if !strings.Contains(buf.String(), "&lt;ana&gt;") || !strings.Contains(buf.String(), "<table>") {
+		t.Fatalf("Unexpected HTML export:\n%s", buf.String())
+	}
+}
+
+func TestAnalyzeRollouts(t *testing.T) {
+	rules, _ := LookupRuleset("draw")
+	r := rules.Deal(rand.New(rand.NewSource(3)), 2)
+	r.Open(OpeningWinner, -1)
+	start := r.Clone()
+	r.Run([]Strategy{&randomStrategy{rng: rand.New(rand.NewSource(1))}, balancedStrategy{}})
+
+	a, err := Analyze(start, r.History[len(start.History):], []int{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Reviews) == 0 {
+		t.Fatal("Expecting moves to review")
+	}
+
+	// the solver takes over once the boneyard is empty
+	if a.Reviews[0].Solved {
+		t.Fatalf("Expecting the first moves graded by rollouts but got %+v", a.Reviews[0])
+	}
+
+	for _, rev := range a.Reviews {
+		if rev.Loss < 0 || rev.Verdict == "" {
+			t.Fatalf("Expecting every move graded but got %+v", rev)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package engine
+
+const (
+	// DoubleHeads is scored for making both ends of the line show the same
+	// number.
+	DoubleHeads = 2
+	// TripleHeads replaces DoubleHeads when one of the ends is a double.
+	TripleHeads = 3
+	// BergenHand is scored by the winner of a hand.
+	BergenHand = 1
+)
+
+// heads returns what the last play scores in Bergen: double or triple
+// heads when both ends show the same number, nothing otherwise or for the
+// first tile.
+func (r *Round) heads() int {
+	if r.Scoring != ScoreBergen || len(r.Line) < 2 || r.Head() != r.Tail() {
+		return 0
+	}
+
+	if r.Line[0].IsDouble() || r.Line[len(r.Line)-1].IsDouble() {
+		return TripleHeads
+	}
+
+	return DoubleHeads
+}
+
+// scoreBergen replaces the pips of a result with the heads every player
+// made and the point for winning the hand.
+func (r *Round) scoreBergen(res *Result) {
+	for i := range res.Points {
+		res.Points[i] = 0
+	}
+
+	for _, e := range r.History {
+		res.Points[e.Player] += e.Score
+	}
+
+	for _, w := range res.Winners {
+		res.Points[w] += BergenHand
+	}
+}
@@ -0,0 +1,42 @@
+package engine
+
+import "testing"
+
+func TestBergenHeads(t *testing.T) {
+	rules, _ := LookupRuleset("bergen")
+	r := rules.NewRound()
+	r.AddHand([]Tile{{3, 5}, {5, 5}, {0, 1}})
+	r.AddHand([]Tile{{2, 5}, {6, 6}})
+	r.Place(Tile{2, 3}, Head)
+	r.Turn = 0
+
+	r.Play(Move{Tile: Tile{3, 5}, End: Tail})
+	r.Play(Move{Tile: Tile{2, 5}, End: Head})
+	if e := r.History[1]; e.Score != DoubleHeads {
+		t.Fatalf("Expecting double heads on 5 and 5 but got %+v", e)
+	}
+
+	r.Play(Move{Tile: Tile{5, 5}, End: Head})
+	if e := r.History[2]; e.Score != TripleHeads {
+		t.Fatalf("Expecting triple heads with [5,5] against a 5 but got %+v", e)
+	}
+
+	res := r.Result()
+	if !res.Blocked || res.Points[0] != TripleHeads+BergenHand || res.Points[1] != DoubleHeads {
+		t.Fatalf("Expecting the heads scored and a point to the lowest hand but got %+v", res)
+	}
+}
+
+func TestBergenMatch(t *testing.T) {
+	for _, name := range []string{"bergen", "matador"} {
+		rules, _ := LookupRuleset(name)
+		m, err := PlayMatch(11, rules, []string{"balanced", "random"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !m.Over() || m.Scores[m.Winners()[0]] < rules.TargetScore {
+			t.Fatalf("Expecting a %s match played to %d but got %v", name, rules.TargetScore, m.Scores)
+		}
+	}
+}
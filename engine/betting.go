@@ -0,0 +1,339 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BetKind is what a player does in a betting round.
+type BetKind int
+
+const (
+	BetCheck BetKind = iota
+	BetCall
+	BetRaise
+	BetFold
+)
+
+func (k BetKind) String() string {
+	switch k {
+	case BetCall:
+		return "call"
+	case BetRaise:
+		return "raise"
+	case BetFold:
+		return "fold"
+	}
+
+	return "check"
+}
+
+// Bet is a betting action. Amount is what a raise puts on top of calling.
+type Bet struct {
+	Kind   BetKind
+	Amount int
+}
+
+func (b Bet) String() string {
+	if b.Kind == BetRaise {
+		return fmt.Sprintf("raise %d", b.Amount)
+	}
+
+	return b.Kind.String()
+}
+
+// Pot is a share of the chips bet. A side pot is formed whenever a player
+// is all in for less than others bet, only the players who put in as much
+// are eligible to win it.
+type Pot struct {
+	Amount   int
+	Eligible []int
+}
+
+// Betting keeps the chips of a hand: what every player has behind, what
+// they have bet and whose turn it is in the current betting round.
+type Betting struct {
+	Stacks []int
+	// Committed holds everything every player put in the pot this hand
+	Committed []int
+	Folded    []bool
+	// Bets holds what every player bet in the current round, Current is
+	// the highest of them
+	Bets     []int
+	Current  int
+	MinRaise int
+	// Turn is the player to act, -1 once the round is over
+	Turn  int
+	acted []bool
+}
+
+// NewBetting takes the ante from every stack, all of it from a stack that
+// can not cover it. Players without chips sit the hand out. Raises have to
+// be at least minBet.
+func NewBetting(stacks []int, ante, minBet int) *Betting {
+	n := len(stacks)
+	b := &Betting{
+		Stacks:    append([]int(nil), stacks...),
+		Committed: make([]int, n),
+		Folded:    make([]bool, n),
+		Bets:      make([]int, n),
+		MinRaise:  minBet,
+		Turn:      -1,
+		acted:     make([]bool, n),
+	}
+
+	for i, s := range b.Stacks {
+		if s <= 0 {
+			b.Folded[i] = true
+			continue
+		}
+
+		b.put(i, ante)
+	}
+
+	return b
+}
+
+func (b *Betting) put(player, amount int) {
+	amount = min(amount, b.Stacks[player])
+	b.Stacks[player] -= amount
+	b.Committed[player] += amount
+	b.Bets[player] += amount
+}
+
+// Active returns the players who have not folded.
+func (b *Betting) Active() []int {
+	active := []int{}
+	for i, f := range b.Folded {
+		if !f {
+			active = append(active, i)
+		}
+	}
+
+	return active
+}
+
+// AllIn reports whether the player is still in the hand without chips
+// behind.
+func (b *Betting) AllIn(player int) bool {
+	return !b.Folded[player] && b.Stacks[player] == 0
+}
+
+// StartRound opens a betting round, the first player to act being the
+// first one from first on who still can.
+func (b *Betting) StartRound(first int) {
+	for i := range b.Bets {
+		b.Bets[i] = 0
+		b.acted[i] = false
+	}
+
+	b.Current = 0
+	b.Turn = b.nextToAct(first - 1)
+}
+
+// nextToAct returns the first player after from who still has to act, or
+// -1 when the round is over.
+func (b *Betting) nextToAct(from int) int {
+	if len(b.Active()) < 2 {
+		return -1
+	}
+
+	n := len(b.Stacks)
+	open := []int{}
+	for i := 1; i <= n; i++ {
+		p := ((from+i)%n + n) % n
+		if !b.Folded[p] && b.Stacks[p] > 0 {
+			open = append(open, p)
+		}
+	}
+
+	// the last player with chips has nobody to bet against
+	if len(open) == 1 && b.Bets[open[0]] >= b.Current {
+		return -1
+	}
+
+	for _, p := range open {
+		if !b.acted[p] || b.Bets[p] < b.Current {
+			return p
+		}
+	}
+
+	return -1
+}
+
+// RoundOver reports whether every player still in has acted and matched
+// the highest bet or is all in.
+func (b *Betting) RoundOver() bool {
+	return b.Turn < 0
+}
+
+// ToCall returns what the player has to put in to stay, at most their
+// stack.
+func (b *Betting) ToCall(player int) int {
+	return min(b.Current-b.Bets[player], b.Stacks[player])
+}
+
+// Legal lists the actions open to the player on turn.
+func (b *Betting) Legal() []BetKind {
+	if b.Turn < 0 {
+		return nil
+	}
+
+	call := b.ToCall(b.Turn)
+	legal := []BetKind{BetCheck}
+	if call > 0 {
+		legal = []BetKind{BetCall, BetFold}
+	}
+
+	if b.Stacks[b.Turn] > call {
+		legal = append(legal, BetRaise)
+	}
+
+	return legal
+}
+
+// Act makes the action for the player on turn. A raise below the minimum
+// is only allowed when it puts the player all in.
+func (b *Betting) Act(bet Bet) error {
+	p := b.Turn
+	if p < 0 {
+		return fmt.Errorf("the betting round is over")
+	}
+
+	legal := false
+	for _, k := range b.Legal() {
+		legal = legal || k == bet.Kind
+	}
+
+	if !legal {
+		return fmt.Errorf("player %d can not %s", p+1, bet.Kind)
+	}
+
+	switch bet.Kind {
+	case BetCall:
+		b.put(p, b.ToCall(p))
+	case BetFold:
+		b.Folded[p] = true
+	case BetRaise:
+		call := b.ToCall(p)
+		if bet.Amount < b.MinRaise && call+bet.Amount < b.Stacks[p] {
+			return fmt.Errorf("a raise has to be at least %d", b.MinRaise)
+		}
+
+		b.put(p, call+bet.Amount)
+		if raised := b.Bets[p] - b.Current; raised > 0 {
+			b.MinRaise = max(b.MinRaise, raised)
+			b.Current = b.Bets[p]
+			for i := range b.acted {
+				b.acted[i] = false
+			}
+		}
+	}
+
+	b.acted[p] = true
+	b.Turn = b.nextToAct(p)
+	return nil
+}
+
+// Total returns all chips in the pots.
+func (b *Betting) Total() int {
+	total := 0
+	for _, c := range b.Committed {
+		total += c
+	}
+
+	return total
+}
+
+// Pots splits the chips into the main pot and the side pots, the main pot
+// first. Chips of folded players go to the pots they reached.
+func (b *Betting) Pots() []Pot {
+	levels := []int{}
+	for _, p := range b.Active() {
+		levels = append(levels, b.Committed[p])
+	}
+	sort.Ints(levels)
+
+	pots := []Pot{}
+	prev := 0
+	for _, level := range levels {
+		if level == prev {
+			continue
+		}
+
+		pot := Pot{}
+		for i, c := range b.Committed {
+			pot.Amount += min(c, level) - min(c, prev)
+			if !b.Folded[i] && c >= level {
+				pot.Eligible = append(pot.Eligible, i)
+			}
+		}
+
+		pots = append(pots, pot)
+		prev = level
+	}
+
+	// folded players may have bet more than anybody still in
+	for _, c := range b.Committed {
+		if c > prev && len(pots) > 0 {
+			pots[len(pots)-1].Amount += c - prev
+		}
+	}
+
+	return pots
+}
+
+// Payout shares every pot among its best eligible players, compare telling
+// whether a player's hand beats another's like QiuHand.Compare. Odd chips
+// go to the first winner. It returns the chips every player won and the
+// winners of the main pot.
+func (b *Betting) Payout(compare func(a, b int) int) ([]int, []int) {
+	won := make([]int, len(b.Stacks))
+	var main []int
+	for _, pot := range b.Pots() {
+		winners := []int{pot.Eligible[0]}
+		for _, p := range pot.Eligible[1:] {
+			switch c := compare(p, winners[0]); {
+			case c > 0:
+				winners = []int{p}
+			case c == 0:
+				winners = append(winners, p)
+			}
+		}
+
+		for _, w := range winners {
+			won[w] += pot.Amount / len(winners)
+		}
+		won[winners[0]] += pot.Amount % len(winners)
+
+		if main == nil {
+			main = winners
+		}
+	}
+
+	return won, main
+}
+
+// AntePayout settles a hand played for antes only, like money-style Gaple:
+// everybody antes and the winners of the result share the pot, which goes
+// back to the players when nobody won. It returns what every stack gains
+// or loses.
+func AntePayout(stacks []int, ante int, res Result) []int {
+	b := NewBetting(stacks, ante, 0)
+	won, _ := b.Payout(func(x, y int) int {
+		switch {
+		case res.IsWinner(x) == res.IsWinner(y):
+			return 0
+		case res.IsWinner(x):
+			return 1
+		}
+
+		return -1
+	})
+
+	for i := range won {
+		won[i] -= b.Committed[i]
+	}
+
+	return won
+}
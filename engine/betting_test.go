@@ -0,0 +1,53 @@
+package engine
+
+import "testing"
+
+func TestSidePots(t *testing.T) {
+	b := NewBetting([]int{15, 100, 100}, 10, 10)
+	b.StartRound(0)
+	if err := b.Act(Bet{Kind: BetRaise, Amount: 5}); err != nil {
+		t.Fatalf("Expecting a short raise that puts the player all in but got %v", err)
+	}
+
+	b = NewBetting([]int{30, 100, 100}, 10, 10)
+	b.StartRound(0)
+	b.Act(Bet{Kind: BetRaise, Amount: 20})
+	if err := b.Act(Bet{Kind: BetRaise, Amount: 5}); err == nil {
+		t.Fatal("Expecting a raise below the minimum to fail")
+	}
+
+	b.Act(Bet{Kind: BetRaise, Amount: 50})
+	b.Act(Bet{Kind: BetCall})
+	if !b.RoundOver() || !b.AllIn(0) {
+		t.Fatalf("Expecting the round over with player 1 all in but got turn %d", b.Turn)
+	}
+
+	pots := b.Pots()
+	if len(pots) != 2 || pots[0].Amount != 90 || len(pots[0].Eligible) != 3 || pots[1].Amount != 100 || len(pots[1].Eligible) != 2 {
+		t.Fatalf("Expecting a main pot of 90 for all and a side pot of 100 for two but got %+v", pots)
+	}
+
+	// player 1 has the best hand, then player 3
+	rank := []int{3, 1, 2}
+	won, winners := b.Payout(func(x, y int) int { return rank[x] - rank[y] })
+	if won[0] != 90 || won[1] != 0 || won[2] != 100 || len(winners) != 1 || winners[0] != 0 {
+		t.Fatalf("Expecting the main pot to player 1 and the side pot to player 3 but got %v", won)
+	}
+
+	won, _ = b.Payout(func(x, y int) int { return 0 })
+	if won[0] != 30 || won[1] != 80 || won[2] != 80 {
+		t.Fatalf("Expecting ties to share every pot but got %v", won)
+	}
+}
+
+func TestAntePayout(t *testing.T) {
+	delta := AntePayout([]int{100, 100, 5}, 10, Result{Winner: 1, Winners: []int{1}})
+	if delta[0] != -10 || delta[1] != 15 || delta[2] != -5 {
+		t.Fatalf("Expecting the winner to take 25 but got %v", delta)
+	}
+
+	delta = AntePayout([]int{100, 100, 100}, 10, Result{Winner: -1})
+	if delta[0] != 0 || delta[1] != 0 || delta[2] != 0 {
+		t.Fatalf("Expecting the antes back without a winner but got %v", delta)
+	}
+}
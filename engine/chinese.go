@@ -0,0 +1,105 @@
+package engine
+
+// TileSet is the family of tiles a ruleset plays with.
+type TileSet string
+
+const (
+	// SetDoubleN is the western set of every pair of pips from MinPip to
+	// MaxPip, the zero value stands for it
+	SetDoubleN TileSet = "double-n"
+	// SetChinese is the 32 tiles of the Chinese set: the eleven civil tiles
+	// twice and the ten military tiles once, without blanks
+	SetChinese TileSet = "chinese"
+)
+
+// Suit is the half of the Chinese set a tile belongs to.
+type Suit int
+
+const (
+	// Civil tiles come in identical pairs
+	Civil Suit = iota
+	// Military tiles pair with the other tile of the same pips
+	Military
+)
+
+func (s Suit) String() string {
+	if s == Military {
+		return "military"
+	}
+
+	return "civil"
+}
+
+// ChineseTile describes a tile of the Chinese set. Rank orders the tiles
+// from 0, the highest, the two tiles of a military pair sharing theirs.
+type ChineseTile struct {
+	Tile Tile
+	Name string
+	Suit Suit
+	Rank int
+}
+
+// chineseTiles lists the Chinese set in the order of Pai Gow, the Gee Joon
+// tiles first.
+var chineseTiles = []ChineseTile{
+	{Tile{1, 2}, "Gee Joon", Military, 0},
+	{Tile{2, 4}, "Gee Joon", Military, 0},
+	{Tile{6, 6}, "Heaven", Civil, 1},
+	{Tile{1, 1}, "Earth", Civil, 2},
+	{Tile{4, 4}, "Man", Civil, 3},
+	{Tile{1, 3}, "Goose", Civil, 4},
+	{Tile{5, 5}, "Flower", Civil, 5},
+	{Tile{3, 3}, "Long", Civil, 6},
+	{Tile{2, 2}, "Bench", Civil, 7},
+	{Tile{5, 6}, "Axe", Civil, 8},
+	{Tile{4, 6}, "Red Head Ten", Civil, 9},
+	{Tile{1, 6}, "Long Leg Seven", Civil, 10},
+	{Tile{1, 5}, "Red Mallet Six", Civil, 11},
+	{Tile{4, 5}, "Nine", Military, 12},
+	{Tile{3, 6}, "Nine", Military, 12},
+	{Tile{3, 5}, "Eight", Military, 13},
+	{Tile{2, 6}, "Eight", Military, 13},
+	{Tile{3, 4}, "Seven", Military, 14},
+	{Tile{2, 5}, "Seven", Military, 14},
+	{Tile{1, 4}, "Five", Military, 15},
+	{Tile{2, 3}, "Five", Military, 15},
+}
+
+// ChineseSet returns the 32 tiles of the Chinese set.
+func ChineseSet() []Tile {
+	tiles := []Tile{}
+	for _, c := range chineseTiles {
+		tiles = append(tiles, c.Tile)
+		if c.Suit == Civil {
+			tiles = append(tiles, c.Tile)
+		}
+	}
+
+	return tiles
+}
+
+// Chinese describes the tile, ok is false for tiles not in the Chinese
+// set.
+func Chinese(t Tile) (ChineseTile, bool) {
+	for _, c := range chineseTiles {
+		if c.Tile.Same(t) {
+			return c, true
+		}
+	}
+
+	return ChineseTile{}, false
+}
+
+// RedPips returns how many pips of the half showing n are painted red on a
+// Chinese tile: every pip of a one or a four, and half of each six of
+// Heaven.
+func RedPips(t Tile, n int) int {
+	switch {
+	case n == 1 || n == 4:
+		return n
+	case n == 6 && t.A == 6 && t.B == 6:
+		return 3
+	}
+
+	return 0
+}
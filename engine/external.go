@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExternalPrefix marks a strategy name as a command line to launch, as in
+// "external:python3 bot.py".
+const ExternalPrefix = "external:"
+
+// DefaultMoveTimeout is how long an external bot may think about a move.
+var DefaultMoveTimeout = 5 * time.Second
+
+// ExternalStrategy drives a seat with an external program speaking a line
+// based protocol on its stdin and stdout. Tiles are written as "a-b".
+//
+// On start the game sends
+//
+//	domino 2
+//
+// and the bot answers "ready" optionally followed by its name. Every turn
+// the game sends
+//
+//	position <head> <tail>      or "position none" before the first tile
+//	played <tile>...            line of play from head to tail
+//	hand <tile>...
+//	opponents <count>...        tiles left in every opponent's hand
+//	legal <tile>:<end>...       end is head or tail
+//	go <milliseconds> <turn>
+//
+// and the bot answers "move <tile> <end> <turn>" within the time limit,
+// turn counting the requests from 1. When the game is over it sends
+// "quit". A bot that answers late or with an illegal move forfeits the
+// choice and the first legal move is played for it; its late answer is
+// told apart by the turn and dropped.
+type ExternalStrategy struct {
+	Timeout time.Duration
+	// Errors keeps every protocol violation of the bot
+	Errors []error
+
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	closed bool
+	// turn counts the moves asked for
+	turn int
+}
+
+// NewExternalStrategy launches the command and performs the handshake.
+func NewExternalStrategy(command []string, timeout time.Duration) (*ExternalStrategy, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("external strategy needs a command")
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &ExternalStrategy{
+		Timeout: timeout,
+		name:    "external:" + filepath.Base(command[len(command)-1]),
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 16),
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			s.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(s.lines)
+	}()
+
+	s.send("domino 2")
+	reply, err := s.receive()
+	if err == nil && !strings.HasPrefix(reply, "ready") {
+		err = fmt.Errorf("expected ready but got %q", reply)
+	}
+
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("%s: %v", s.name, err)
+	}
+
+	if fields := strings.Fields(reply); len(fields) > 1 {
+		s.name = "external:" + strings.Join(fields[1:], " ")
+	}
+
+	return s, nil
+}
+
+func (s *ExternalStrategy) Name() string { return s.name }
+
+func (s *ExternalStrategy) Choose(pos Position) (Move, bool) {
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	if pos.Started {
+		s.send(fmt.Sprintf("position %d %d", pos.Head, pos.Tail))
+	} else {
+		s.send("position none")
+	}
+
+	s.send("played" + tileList(pos.Played))
+	s.send("hand" + tileList(pos.Hand))
+	counts := "opponents"
+	for _, c := range pos.OpponentCards {
+		counts += " " + strconv.Itoa(c)
+	}
+	s.send(counts)
+
+	legal := "legal"
+	for _, m := range moves {
+		legal += fmt.Sprintf(" %d-%d:%s", m.Tile.A, m.Tile.B, m.End)
+	}
+	s.send(legal)
+	s.turn++
+	s.send(fmt.Sprintf("go %d %d", s.Timeout.Milliseconds(), s.turn))
+
+	m, err := s.receiveMove()
+	if err == nil {
+		for _, legal := range moves {
+			if legal.Tile.Same(m.Tile) && (legal.End == m.End || pos.Head == pos.Tail) {
+				return legal, true
+			}
+		}
+		err = fmt.Errorf("illegal move %s %s", m.Tile, m.End)
+	}
+
+	s.Errors = append(s.Errors, err)
+	return moves[0], true
+}
+
+// receiveMove waits for the answer to the current turn, dropping the
+// answers to earlier turns that came in after they timed out.
+func (s *ExternalStrategy) receiveMove() (Move, error) {
+	deadline := time.After(s.Timeout)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				return Move{}, fmt.Errorf("bot exited")
+			}
+
+			m, turn, err := parseMove(line)
+			if err == nil && turn < s.turn {
+				continue
+			}
+
+			if err == nil && turn != s.turn {
+				err = fmt.Errorf("answer for turn %d during turn %d", turn, s.turn)
+			}
+			return m, err
+		case <-deadline:
+			return Move{}, fmt.Errorf("no answer within %s", s.Timeout)
+		}
+	}
+}
+
+// Close tells the bot to quit and waits briefly for it to exit.
+func (s *ExternalStrategy) Close() error {
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	s.send("quit")
+	s.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- s.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		s.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (s *ExternalStrategy) send(line string) {
+	fmt.Fprintln(s.stdin, line)
+}
+
+func (s *ExternalStrategy) receive() (string, error) {
+	select {
+	case line, ok := <-s.lines:
+		if !ok {
+			return "", fmt.Errorf("bot exited")
+		}
+		return line, nil
+	case <-time.After(s.Timeout):
+		return "", fmt.Errorf("no answer within %s", s.Timeout)
+	}
+}
+
+func tileList(tiles []Tile) string {
+	list := ""
+	for _, t := range tiles {
+		list += fmt.Sprintf(" %d-%d", t.A, t.B)
+	}
+
+	return list
+}
+
+// parseMove reads "move <a-b> <end> <turn>".
+func parseMove(line string) (Move, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "move" {
+		return Move{}, 0, fmt.Errorf("expected \"move <tile> <end> <turn>\" but got %q", line)
+	}
+
+	var t Tile
+	if _, err := fmt.Sscanf(fields[1], "%d-%d", &t.A, &t.B); err != nil {
+		return Move{}, 0, fmt.Errorf("bad tile %q", fields[1])
+	}
+
+	turn, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return Move{}, 0, fmt.Errorf("bad turn %q", fields[3])
+	}
+
+	switch fields[2] {
+	case "head":
+		return Move{Tile: t, End: Head}, turn, nil
+	case "tail":
+		return Move{Tile: t, End: Tail}, turn, nil
+	}
+
+	return Move{}, 0, fmt.Errorf("bad end %q", fields[2])
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as an external bot when asked to.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("DOMINO_TEST_BOT"); mode != "" {
+		runTestBot(mode)
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runTestBot answers with the last legal move, or too late for the first
+// turn in "slow" mode.
+func runTestBot(mode string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	legal := []string{}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch fields[0] {
+		case "domino":
+			fmt.Println("ready testbot")
+		case "legal":
+			legal = fields[1:]
+		case "go":
+			if mode == "slow" && fields[2] == "1" {
+				time.Sleep(200 * time.Millisecond)
+			}
+			fmt.Println("move " + strings.Replace(legal[len(legal)-1], ":", " ", 1) + " " + fields[2])
+		case "quit":
+			return
+		}
+	}
+}
+
+func startTestBot(t *testing.T, mode string) *ExternalStrategy {
+	os.Setenv("DOMINO_TEST_BOT", mode)
+	defer os.Unsetenv("DOMINO_TEST_BOT")
+
+	s, err := NewExternalStrategy([]string{os.Args[0]}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestExternalStrategy(t *testing.T) {
+	s := startTestBot(t, "fast")
+	defer s.Close()
+
+	if s.Name() != "external:testbot" {
+		t.Fatalf("Expecting the bot to introduce itself but got %q", s.Name())
+	}
+
+	pos := Position{Started: true, Head: 6, Tail: 2, Hand: []Tile{{6, 1}, {2, 5}, {3, 3}}}
+	m, ok := s.Choose(pos)
+	if !ok || !m.Tile.Same(Tile{2, 5}) || m.End != Tail {
+		t.Fatalf("Expecting the bot's move [2,5] on tail but got %s", m)
+	}
+
+	if len(s.Errors) != 0 {
+		t.Fatalf("Unexpected protocol errors %v", s.Errors)
+	}
+}
+
+func TestExternalLineGamesOnly(t *testing.T) {
+	rules, _ := LookupRuleset("qiuqiu")
+	if _, err := PlayMatch(1, rules, []string{"balanced", ExternalPrefix + os.Args[0]}); err == nil {
+		t.Fatal("Expecting an external bot refused a seat at QiuQiu")
+	}
+
+	for _, name := range []string{"block", "bergen", "matador"} {
+		rules, _ := LookupRuleset(name)
+		if err := rules.CheckStrategy(ExternalPrefix + os.Args[0]); err != nil {
+			t.Fatalf("Expecting an external bot to play %s but got %v", name, err)
+		}
+	}
+}
+
+func TestExternalStrategyTimeout(t *testing.T) {
+	s := startTestBot(t, "slow")
+	defer s.Close()
+
+	pos := Position{Started: true, Head: 6, Tail: 2, Hand: []Tile{{6, 1}, {2, 5}}}
+	m, ok := s.Choose(pos)
+	if !ok || !m.Tile.Same(Tile{6, 1}) {
+		t.Fatalf("Expecting the first legal move after a timeout but got %s", m)
+	}
+
+	if len(s.Errors) != 1 {
+		t.Fatalf("Expecting the timeout to be recorded but got %v", s.Errors)
+	}
+
+	// the late answer to the first turn comes in during the second
+	time.Sleep(150 * time.Millisecond)
+	pos.Hand = []Tile{{6, 1}, {2, 4}}
+	m, _ = s.Choose(pos)
+	if !m.Tile.Same(Tile{2, 4}) || len(s.Errors) != 1 {
+		t.Fatalf("Expecting the late answer dropped and [2,4] played but got %s, %v", m, s.Errors)
+	}
+}
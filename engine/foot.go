@@ -0,0 +1,397 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// CentreArms is how many tiles the centre double of a chicken foot hand
+	// takes before play goes anywhere else.
+	CentreArms = 6
+	// FootToes is how many tiles every later double takes.
+	FootToes = 3
+	// DoubleBlankPenalty is what the double blank costs when it is left in
+	// hand at the end of a chicken foot hand.
+	DoubleBlankPenalty = 50
+)
+
+// FootNode is a tile of a chicken foot layout. Tiles are oriented so that
+// A touches the parent tile and B is the open side.
+type FootNode struct {
+	Tile Tile
+	// Parent is the node the tile was played on, -1 for the centre double
+	Parent   int
+	Children []int
+}
+
+// FootMove puts a tile on a node of the layout.
+type FootMove struct {
+	Tile Tile
+	Node int
+}
+
+// FootEvent is one action of a chicken foot round: a move, a tile drawn
+// from the boneyard (kept in Move.Tile) or a pass.
+type FootEvent struct {
+	Player int
+	Move   FootMove
+	Draw   bool
+	Pass   bool
+}
+
+// FootRound is a hand of Chicken Foot. Play starts on a centre double that
+// has to be surrounded by CentreArms tiles. Every later double is a chicken
+// foot that needs FootToes tiles before play goes on anywhere else. A
+// player who can not play draws once and then passes.
+type FootRound struct {
+	Hands    [][]Tile
+	Boneyard []Tile
+	// Nodes is the layout, Nodes[0] is the centre double
+	Nodes   []FootNode
+	Turn    int
+	History []FootEvent
+	drew    bool
+	passes  int
+}
+
+// DealFoot deals a hand by the ruleset around the centre double for the
+// given hand number; the player after the dealer starts.
+func DealFoot(rng *rand.Rand, rules Ruleset, players, hand int) *FootRound {
+	centre := rules.Station(hand)
+	r := &FootRound{Nodes: []FootNode{{Tile: centre, Parent: -1}}, Turn: hand % players}
+	tiles := []Tile{}
+	for _, t := range rules.Set() {
+		if !t.Same(centre) {
+			tiles = append(tiles, t)
+		}
+	}
+
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-rules.HandSize:]...))
+		tiles = tiles[:len(tiles)-rules.HandSize]
+	}
+
+	r.Boneyard = tiles
+	return r
+}
+
+// Capacity is how many tiles may be played on the node.
+func (r *FootRound) Capacity(node int) int {
+	switch {
+	case node == 0:
+		return CentreArms
+	case r.Nodes[node].Tile.IsDouble():
+		return FootToes
+	}
+
+	return 1
+}
+
+// Pending returns the earliest double still short of tiles, which every
+// player has to play on, or -1 when play is free.
+func (r *FootRound) Pending() int {
+	for i, n := range r.Nodes {
+		if n.Tile.IsDouble() && len(n.Children) < r.Capacity(i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Ends returns the nodes that take a tile now.
+func (r *FootRound) Ends() []int {
+	if p := r.Pending(); p >= 0 {
+		return []int{p}
+	}
+
+	ends := []int{}
+	for i, n := range r.Nodes {
+		if len(n.Children) < r.Capacity(i) {
+			ends = append(ends, i)
+		}
+	}
+
+	return ends
+}
+
+// LegalMoves lists every tile of the player's hand that fits an end.
+func (r *FootRound) LegalMoves(player int) []FootMove {
+	moves := []FootMove{}
+	ends := r.Ends()
+	for _, tile := range r.Hands[player] {
+		for _, e := range ends {
+			if tile.Matches(r.Nodes[e].Tile.B) {
+				moves = append(moves, FootMove{Tile: tile, Node: e})
+			}
+		}
+	}
+
+	return moves
+}
+
+// Play makes the move for the player on turn and passes the turn on.
+func (r *FootRound) Play(m FootMove) error {
+	hand := r.Hands[r.Turn]
+	idx := -1
+	for i, t := range hand {
+		if t.Same(m.Tile) {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("%s is not in hand", m.Tile)
+	}
+
+	legal := false
+	for _, l := range r.LegalMoves(r.Turn) {
+		if l.Node == m.Node && l.Tile.Same(m.Tile) {
+			legal = true
+		}
+	}
+
+	if !legal {
+		return fmt.Errorf("%s can not be played there", m.Tile)
+	}
+
+	tile := m.Tile
+	if tile.A != r.Nodes[m.Node].Tile.B {
+		tile = Tile{tile.B, tile.A}
+	}
+
+	r.Nodes = append(r.Nodes, FootNode{Tile: tile, Parent: m.Node})
+	r.Nodes[m.Node].Children = append(r.Nodes[m.Node].Children, len(r.Nodes)-1)
+	r.Hands[r.Turn] = append(hand[:idx:idx], hand[idx+1:]...)
+	r.History = append(r.History, FootEvent{Player: r.Turn, Move: m})
+	r.passes = 0
+	r.next()
+	return nil
+}
+
+// Draw takes a tile from the boneyard for the player on turn, which is
+// only allowed once a turn and when they have no move.
+func (r *FootRound) Draw() (Tile, error) {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return Tile{}, fmt.Errorf("player %d has a move", r.Turn+1)
+	case r.drew:
+		return Tile{}, fmt.Errorf("player %d already drew this turn", r.Turn+1)
+	case len(r.Boneyard) == 0:
+		return Tile{}, fmt.Errorf("the boneyard is empty")
+	}
+
+	t := r.Boneyard[len(r.Boneyard)-1]
+	r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+	r.Hands[r.Turn] = append(r.Hands[r.Turn], t)
+	r.History = append(r.History, FootEvent{Player: r.Turn, Move: FootMove{Tile: t, Node: -1}, Draw: true})
+	r.drew = true
+	return t, nil
+}
+
+// Pass ends the turn of a player who can not play and has drawn, or can
+// not draw.
+func (r *FootRound) Pass() error {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return fmt.Errorf("player %d has a move", r.Turn+1)
+	case !r.drew && len(r.Boneyard) > 0:
+		return fmt.Errorf("player %d has to draw first", r.Turn+1)
+	}
+
+	r.History = append(r.History, FootEvent{Player: r.Turn, Pass: true})
+	r.passes++
+	r.next()
+	return nil
+}
+
+func (r *FootRound) next() {
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+	r.drew = false
+}
+
+// Over reports whether a player has gone out, or every player passed in a
+// row with the boneyard empty.
+func (r *FootRound) Over() bool {
+	for _, h := range r.Hands {
+		if len(h) == 0 {
+			return true
+		}
+	}
+
+	return len(r.Boneyard) == 0 && r.passes >= len(r.Hands)
+}
+
+// FootPenalty is what a tile left in hand costs: its pips, or
+// DoubleBlankPenalty for the double blank.
+func FootPenalty(t Tile) int {
+	if t == (Tile{0, 0}) {
+		return DoubleBlankPenalty
+	}
+
+	return t.Pips()
+}
+
+// Result scores the hand by penalty with FootPenalty. Pips holds the
+// penalty of every hand.
+func (r *FootRound) Result() Result {
+	return penaltyResult(r.Hands, FootPenalty, len(r.History))
+}
+
+// FootPosition is what a player sees of a chicken foot round.
+type FootPosition struct {
+	Player int
+	Hand   []Tile
+	Nodes  []FootNode
+	// Pending is the double everybody has to play on, or -1
+	Pending   int
+	HandSizes []int
+	Boneyard  int
+	Legal     []FootMove
+}
+
+// Position returns what the player sees.
+func (r *FootRound) Position(player int) FootPosition {
+	pos := FootPosition{
+		Player:   player,
+		Hand:     append([]Tile(nil), r.Hands[player]...),
+		Pending:  r.Pending(),
+		Boneyard: len(r.Boneyard),
+		Legal:    r.LegalMoves(player),
+	}
+
+	for _, n := range r.Nodes {
+		pos.Nodes = append(pos.Nodes, FootNode{Tile: n.Tile, Parent: n.Parent, Children: append([]int(nil), n.Children...)})
+	}
+
+	for _, h := range r.Hands {
+		pos.HandSizes = append(pos.HandSizes, len(h))
+	}
+
+	return pos
+}
+
+// Run plays the hand to the end with one strategy per seat. Strategies that
+// do not implement FootStrategy play the balanced chicken foot moves.
+func (r *FootRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		pos := r.Position(r.Turn)
+		if len(pos.Legal) == 0 {
+			if _, err := r.Draw(); err != nil {
+				r.Pass()
+			}
+			continue
+		}
+
+		m, ok := ChooseFoot(strategies[r.Turn], pos)
+		if !ok {
+			return Result{}, fmt.Errorf("%s found no move for player %d", strategies[r.Turn].Name(), r.Turn)
+		}
+
+		if err := r.Play(m); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// FootStrategy is implemented by strategies that know how to play chicken
+// foot.
+type FootStrategy interface {
+	ChooseFoot(pos FootPosition) (FootMove, bool)
+}
+
+// ChooseFoot asks the strategy for a chicken foot move, falling back to the
+// top move of EvaluateFoot.
+func ChooseFoot(s Strategy, pos FootPosition) (FootMove, bool) {
+	if fs, ok := s.(FootStrategy); ok {
+		return fs.ChooseFoot(pos)
+	}
+
+	return balancedStrategy{}.ChooseFoot(pos)
+}
+
+// ScoredFootMove is a legal chicken foot move with its score and
+// explanation.
+type ScoredFootMove struct {
+	FootMove
+	Score  float64
+	Reason string
+}
+
+// EvaluateFoot scores every legal move, best first. It sheds the tiles
+// that would cost most at the end, above all the double blank, and avoids
+// doubles it can not help to fill.
+func EvaluateFoot(pos FootPosition) []ScoredFootMove {
+	scored := make([]ScoredFootMove, 0, len(pos.Legal))
+	for _, m := range pos.Legal {
+		cost := FootPenalty(m.Tile)
+		s := ScoredFootMove{FootMove: m, Score: float64(cost)}
+		s.Reason = fmt.Sprintf("gets rid of %d penalty points", cost)
+		if m.Tile.IsDouble() {
+			toes := 0
+			for _, t := range pos.Hand {
+				if !t.Same(m.Tile) && t.Matches(m.Tile.A) {
+					toes++
+				}
+			}
+
+			// a foot the opponents must fill stalls them, one we can fill
+			// ourselves is a free run
+			if toes > 0 {
+				s.Score += 4
+				s.Reason = fmt.Sprintf("starts a chicken foot on %s you can help fill", m.Tile)
+			} else {
+				s.Score -= 2
+			}
+		}
+
+		scored = append(scored, s)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	return scored
+}
+
+func (s *randomStrategy) ChooseFoot(pos FootPosition) (FootMove, bool) {
+	if len(pos.Legal) == 0 {
+		return FootMove{}, false
+	}
+
+	return pos.Legal[s.rng.Intn(len(pos.Legal))], true
+}
+
+func (heavyStrategy) ChooseFoot(pos FootPosition) (FootMove, bool) {
+	if len(pos.Legal) == 0 {
+		return FootMove{}, false
+	}
+
+	best := pos.Legal[0]
+	for _, m := range pos.Legal[1:] {
+		if m.Tile.Pips() > best.Tile.Pips() {
+			best = m
+		}
+	}
+
+	return best, true
+}
+
+func (balancedStrategy) ChooseFoot(pos FootPosition) (FootMove, bool) {
+	scored := EvaluateFoot(pos)
+	if len(scored) == 0 {
+		return FootMove{}, false
+	}
+
+	return scored[0].FootMove, true
+}
@@ -0,0 +1,51 @@
+package engine
+
+import "testing"
+
+func TestFootPlay(t *testing.T) {
+	r := &FootRound{Nodes: []FootNode{{Tile: Tile{9, 9}, Parent: -1}}}
+	r.Hands = [][]Tile{{{9, 1}, {9, 2}, {9, 3}, {1, 4}}, {{9, 4}, {9, 5}, {9, 6}, {0, 0}}}
+
+	// the centre takes six arms before anything else
+	for _, tile := range []Tile{{9, 1}, {9, 4}, {9, 2}, {9, 5}} {
+		if err := r.Play(FootMove{Tile: tile, Node: 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if moves := r.LegalMoves(0); len(moves) != 1 || moves[0].Node != 0 {
+		t.Fatalf("Expecting only [9,3] on the centre but got %v", moves)
+	}
+
+	r.Play(FootMove{Tile: Tile{9, 3}, Node: 0})
+	r.Play(FootMove{Tile: Tile{9, 6}, Node: 0})
+	if r.Pending() >= 0 || len(r.Ends()) != 6 {
+		t.Fatalf("Expecting six open arms but got pending %d, ends %v", r.Pending(), r.Ends())
+	}
+
+	// [1,4] goes on the arm ending in 1
+	if err := r.Play(FootMove{Tile: Tile{1, 4}, Node: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Nodes[len(r.Nodes)-1].Tile != (Tile{1, 4}) {
+		t.Fatalf("Expecting [1,4] oriented away from the arm but got %s", r.Nodes[len(r.Nodes)-1].Tile)
+	}
+
+	r.Pass()
+	if res := r.Result(); res.Winner != 0 || res.Points[1] != DoubleBlankPenalty {
+		t.Fatalf("Expecting player 1 out and the double blank to cost %d but got %+v", DoubleBlankPenalty, res)
+	}
+}
+
+func TestFootMatch(t *testing.T) {
+	rules, _ := LookupRuleset("chicken-foot")
+	m, err := PlayMatch(9, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Hands) != rules.Hands || len(m.Winners()) == 0 {
+		t.Fatalf("Expecting %d hands and a winner but got %d hands", rules.Hands, len(m.Hands))
+	}
+}
@@ -0,0 +1,56 @@
+package engine
+
+const (
+	// GapleBonus is scored on top by a player who blocks the hand ("gaple")
+	// and wins it.
+	GapleBonus = 20
+	// GaplePenalty is charged to a player who blocks the hand and loses it.
+	GaplePenalty = 20
+	// DoublePenalty is charged for every double ("balak") a losing player is
+	// left holding.
+	DoublePenalty = 5
+	// EndDoublePenalty replaces DoublePenalty for the double blank and the
+	// double six, the doubles the hands are opened with.
+	EndDoublePenalty = 10
+	// OutOnDoubleBonus is scored by a player who goes out with a double.
+	OutOnDoubleBonus = 10
+)
+
+// scoreGaple adds the Gaple bonuses and penalties to a result scored like
+// ScoreOpponents.
+func (r *Round) scoreGaple(res *Result) {
+	last := -1
+	for i := len(r.History) - 1; i >= 0; i-- {
+		if e := r.History[i]; !e.Pass && !e.Draw {
+			last = i
+			break
+		}
+	}
+
+	if last >= 0 {
+		e := r.History[last]
+		switch {
+		case res.Blocked && res.IsWinner(e.Player):
+			res.Points[e.Player] += GapleBonus
+		case res.Blocked:
+			res.Points[e.Player] -= GaplePenalty
+		case e.Move.Tile.IsDouble():
+			res.Points[e.Player] += OutOnDoubleBonus
+		}
+	}
+
+	for i, h := range r.Hands {
+		if res.IsWinner(i) {
+			continue
+		}
+
+		for _, t := range h {
+			switch {
+			case t == Tile{0, 0} || t == Tile{6, 6}:
+				res.Points[i] -= EndDoublePenalty
+			case t.IsDouble():
+				res.Points[i] -= DoublePenalty
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package engine
+
+// Inference is what a player can tell about the tiles hidden from them. A
+// player who passes, or draws in a draw game, shows they hold no tile
+// fitting either end; a draw hands them an unknown tile, so what they had
+// shown before no longer holds.
+type Inference struct {
+	Player int
+	// Lacks holds for every seat the numbers it provably holds none of
+	Lacks [][]int
+	// Unseen holds the tiles of the set neither on the line nor in the
+	// player's hand, wherever they are
+	Unseen []Tile
+}
+
+// Infer returns what the player can tell from the round played with the
+// set.
+func (r *Round) Infer(player int, set []Tile) Inference {
+	in := Inference{Player: player, Lacks: make([][]int, len(r.Hands))}
+	masks := r.lackMasks()
+	for i := range in.Lacks {
+		in.Lacks[i] = []int{}
+		if masks != nil {
+			in.Lacks[i] = maskNumbers(masks[i])
+		}
+	}
+
+	in.Unseen = []Tile{}
+	for _, t := range set {
+		if !containsTile(r.Line, t) && (player < 0 || player >= len(r.Hands) || !containsTile(r.Hands[player], t)) {
+			in.Unseen = append(in.Unseen, t)
+		}
+	}
+
+	return in
+}
+
+func containsTile(tiles []Tile, t Tile) bool {
+	for _, o := range tiles {
+		if o.Same(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lacking reports whether the seat provably holds no tile showing n.
+func (in Inference) Lacking(seat, n int) bool {
+	for _, l := range in.Lacks[seat] {
+		if l == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MayHold reports whether the unseen tile could be in the seat's hand.
+func (in Inference) MayHold(seat int, t Tile) bool {
+	return seat != in.Player && containsTile(in.Unseen, t) && !in.Lacking(seat, t.A) && !in.Lacking(seat, t.B)
+}
+
+// Possible returns the unseen tiles the seat could hold.
+func (in Inference) Possible(seat int) []Tile {
+	tiles := []Tile{}
+	for _, t := range in.Unseen {
+		if in.MayHold(seat, t) {
+			tiles = append(tiles, t)
+		}
+	}
+
+	return tiles
+}
+
+// lackMasks returns for every seat the numbers it has shown to lack as a
+// bit mask, or nil when nobody has passed or drawn.
+func (r *Round) lackMasks() []uint64 {
+	shown := false
+	for _, e := range r.History {
+		if e.Pass || e.Draw {
+			shown = true
+			break
+		}
+	}
+
+	if !shown {
+		return nil
+	}
+
+	// walking the history back takes the moves off the line, giving the
+	// open ends every pass and draw faced
+	type ends struct{ head, tail int }
+	faced := make([]ends, len(r.History))
+	lo, hi := 0, len(r.Line)
+	for i := len(r.History) - 1; i >= 0; i-- {
+		e := r.History[i]
+		switch {
+		case e.Pass || e.Draw:
+			faced[i] = ends{-1, -1}
+			if lo < hi {
+				faced[i] = ends{r.Line[lo].A, r.Line[hi-1].B}
+			}
+		case e.Move.End == Tail && lo < hi:
+			hi--
+		case lo < hi:
+			lo++
+		}
+	}
+
+	masks := make([]uint64, len(r.Hands))
+	for i, e := range r.History {
+		if !e.Pass && !e.Draw {
+			continue
+		}
+
+		if e.Draw {
+			masks[e.Player] = 0
+		}
+
+		for _, end := range []int{faced[i].head, faced[i].tail} {
+			if n, ok := r.Matching.needs(end); ok {
+				masks[e.Player] |= 1 << uint(n)
+			}
+		}
+	}
+
+	return masks
+}
+
+// needs returns the number a tile has to show to fit the open end, apart
+// from the wild matadors. An end of -1 stands for an empty line.
+func (m Matching) needs(end int) (int, bool) {
+	switch {
+	case end < 0 || end > 63:
+		return 0, false
+	case m != MatchSeven:
+		return end, true
+	case end > 0 && end < 7:
+		return 7 - end, true
+	}
+
+	return 0, false
+}
+
+func maskNumbers(mask uint64) []int {
+	numbers := []int{}
+	for n := 0; n < 64; n++ {
+		if mask&(1<<uint(n)) != 0 {
+			numbers = append(numbers, n)
+		}
+	}
+
+	return numbers
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferPass(t *testing.T) {
+	r := NewRound()
+	r.AddHand([]Tile{{3, 4}, {4, 4}})
+	r.AddHand([]Tile{{1, 1}, {2, 5}})
+	r.AddHand([]Tile{{4, 6}})
+	r.Line, r.Turn = []Tile{{3, 3}}, 0
+
+	// player 2 holds no 4 nor 3 and passes
+	r.Play(Move{Tile{3, 4}, Head})
+	if r.Turn != 2 {
+		t.Fatalf("Expecting player 2 to pass but player %d is on turn", r.Turn+1)
+	}
+
+	in := r.Infer(0, Set(0, 6))
+	if !reflect.DeepEqual(in.Lacks, [][]int{{}, {3, 4}, {}}) {
+		t.Fatalf("Expecting player 2 to lack 3 and 4 but got %v", in.Lacks)
+	}
+
+	if len(in.Unseen) != 25 || containsTile(in.Unseen, Tile{4, 4}) || containsTile(in.Unseen, Tile{3, 3}) {
+		t.Fatalf("Expecting the line and the own hand seen but got %v", in.Unseen)
+	}
+
+	if in.MayHold(1, Tile{0, 3}) || !in.MayHold(1, Tile{1, 1}) || !in.MayHold(2, Tile{0, 3}) || in.MayHold(0, Tile{1, 1}) {
+		t.Fatal("Expecting tiles showing 3 or 4 ruled out for player 2 only")
+	}
+
+	if n := len(in.Possible(1)); n != 15 {
+		t.Fatalf("Expecting 15 tiles without 3 or 4 left for player 2 but got %d", n)
+	}
+
+	pos := r.Position(2)
+	if !reflect.DeepEqual(pos.OpponentLacks, [][]int{{}, {3, 4}}) {
+		t.Fatalf("Expecting the position to carry what the opponents lack but got %v", pos.OpponentLacks)
+	}
+}
+
+func TestInferDraw(t *testing.T) {
+	r := NewRound()
+	r.Draw = true
+	r.AddHand([]Tile{{3, 4}, {0, 0}})
+	r.AddHand([]Tile{{1, 1}, {2, 2}})
+	r.Boneyard = []Tile{{4, 5}, {5, 6}}
+	r.Line, r.Turn = []Tile{{3, 3}}, 0
+
+	// player 2 draws [5,6], then [4,5] fits and is played
+	r.Play(Move{Tile{3, 4}, Head})
+	r.Play(Move{Tile{4, 5}, Head})
+	in := r.Infer(0, Set(0, 6))
+	if !reflect.DeepEqual(in.Lacks[1], []int{3, 4}) || !in.MayHold(1, Tile{5, 6}) {
+		t.Fatalf("Expecting player 2 to lack the ends they drew on but got %v", in.Lacks)
+	}
+
+	if n, _ := MatchSeven.needs(3); n != 4 {
+		t.Fatalf("Expecting a 4 to go on a 3 in Matador but got %d", n)
+	}
+}
+
+func TestEvaluateLacks(t *testing.T) {
+	// [2,5] leaves a 5 open, which the opponent has shown to lack
+	pos := Position{Started: true, Head: 2, Tail: 2, Hand: []Tile{{2, 5}, {2, 6}},
+		OpponentCards: []int{3}, OpponentLacks: [][]int{{5}}}
+	if best := Evaluate(pos)[0]; best.Tile != (Tile{2, 5}) {
+		t.Fatalf("Expecting the opponent blocked with [2,5] but got %v", best)
+	}
+}
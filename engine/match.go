@@ -0,0 +1,151 @@
+package engine
+
+import "math/rand"
+
+// Match is a series of hands played until a player reaches the target score
+// of its ruleset or the ruleset's number of hands is played. A ruleset with
+// neither makes a single hand match.
+type Match struct {
+	Rules Ruleset
+	Hands []Result
+	// Scores and Pips are the points won and the pips left summed over the
+	// hands, by seat
+	Scores []int
+	Pips   []int
+}
+
+func NewMatch(rules Ruleset, players int) *Match {
+	return &Match{Rules: rules, Scores: make([]int, players), Pips: make([]int, players)}
+}
+
+// Add records a finished hand.
+func (m *Match) Add(res Result) {
+	m.Hands = append(m.Hands, res)
+	for i := range m.Scores {
+		m.Scores[i] += res.Points[i]
+		m.Pips[i] += res.Pips[i]
+	}
+}
+
+// Over reports whether the match is decided.
+func (m *Match) Over() bool {
+	if len(m.Hands) == 0 {
+		return false
+	}
+
+	if m.Rules.Hands > 0 && len(m.Hands) >= m.Rules.Hands {
+		return true
+	}
+
+	if m.Rules.TargetScore <= 0 {
+		return m.Rules.Hands <= 0
+	}
+
+	for _, s := range m.Scores {
+		if s >= m.Rules.TargetScore {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LastWinner is the winner of the latest hand, or -1, as the opening rule
+// wants it.
+func (m *Match) LastWinner() int {
+	if len(m.Hands) == 0 {
+		return -1
+	}
+
+	return m.Hands[len(m.Hands)-1].Winner
+}
+
+// Winners returns the players with the best score, the lowest one under
+// penalty scoring, or the winners of the only hand of a single hand match.
+func (m *Match) Winners() []int {
+	if !m.Over() {
+		return nil
+	}
+
+	if m.Rules.TargetScore <= 0 && m.Rules.Hands <= 0 {
+		return m.Hands[0].Winners
+	}
+
+	sign := 1
+	if m.Rules.Scoring == ScorePenalty {
+		sign = -1
+	}
+
+	best, winners := 0, []int{}
+	for i, s := range m.Scores {
+		switch {
+		case len(winners) == 0 || sign*s > sign*best:
+			best, winners = s, []int{i}
+		case s == best:
+			winners = append(winners, i)
+		}
+	}
+
+	return winners
+}
+
+// PlayMatch plays hands dealt from the seed by the ruleset until the match
+// is over. Deals that can not be opened are dealt again.
+func PlayMatch(seed int64, rules Ruleset, strategies []string) (*Match, error) {
+	rng := rand.New(rand.NewSource(seed))
+	players, err := seatStrategies(rng, rules, strategies)
+	defer closeStrategies(players)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewMatch(rules, len(strategies))
+	for !m.Over() {
+		res, ok, err := playHand(rng, rules, players, len(m.Hands), m.LastWinner())
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			m.Add(res)
+		}
+	}
+
+	return m, nil
+}
+
+// playHand deals and plays one hand of the match. It reports false when the
+// deal could not be opened.
+func playHand(rng *rand.Rand, rules Ruleset, players []Strategy, hand, lastWinner int) (Result, bool, error) {
+	switch rules.Variant {
+	case VariantMexicanTrain:
+		res, err := DealTrains(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantChickenFoot:
+		res, err := DealFoot(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantQiuQiu:
+		res, err := DealQiu(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantPaiGow:
+		res, err := DealPaiGow(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantTienGow:
+		res, err := DealTienGow(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantTexas42:
+		res, err := DealTexas(rng, rules, len(players), hand).Run(players)
+		return res, err == nil, err
+	case VariantAllFives:
+		res, err := DealSpinner(rng, rules, len(players), lastWinner).Run(players)
+		return res, err == nil, err
+	}
+
+	r := rules.Deal(rng, len(players))
+	if !r.Open(rules.Opening, lastWinner) {
+		return Result{}, false, nil
+	}
+
+	res, err := r.Run(players)
+	return res, err == nil, err
+}
@@ -0,0 +1,55 @@
+package engine
+
+// Matching decides which tiles may be put against an open end of a line of
+// play. The ruleset's variant picks it, see Ruleset.Matching.
+type Matching string
+
+const (
+	// MatchEqual joins halves showing the same number, the zero value
+	// stands for it
+	MatchEqual Matching = "equal"
+	// MatchSeven joins halves adding up to seven, as in Matador. Matadors
+	// are wild and go anywhere, and only a matador goes on a blank.
+	MatchSeven Matching = "seven"
+)
+
+// IsMatador reports whether the tile is wild in Matador: the double blank
+// and the tiles of seven pips.
+func IsMatador(t Tile) bool {
+	return t.Pips() == 7 || t == Tile{0, 0}
+}
+
+// Join returns the tile oriented to lie against the open end, its A half
+// touching it, and whether it fits there at all. A matador that only fits
+// as a wild tile turns its higher half out.
+func (m Matching) Join(t Tile, end int) (Tile, bool) {
+	flipped := Tile{t.B, t.A}
+	if m != MatchSeven {
+		switch end {
+		case t.A:
+			return t, true
+		case t.B:
+			return flipped, true
+		}
+		return t, false
+	}
+
+	switch {
+	case t.A+end == 7:
+		return t, true
+	case t.B+end == 7:
+		return flipped, true
+	case IsMatador(t) && t.A > t.B:
+		return flipped, true
+	case IsMatador(t):
+		return t, true
+	}
+
+	return t, false
+}
+
+// Fits reports whether the tile may go against the open end.
+func (m Matching) Fits(t Tile, end int) bool {
+	_, ok := m.Join(t, end)
+	return ok
+}
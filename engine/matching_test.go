@@ -0,0 +1,34 @@
+package engine
+
+import "testing"
+
+func TestMatadorMatching(t *testing.T) {
+	r := NewRound()
+	r.Matching = MatchSeven
+	r.AddHand([]Tile{{1, 3}, {2, 5}, {4, 4}})
+	r.AddHand([]Tile{{0, 6}, {3, 4}})
+	r.Place(Tile{6, 6}, Head)
+	r.Turn = 0
+
+	if _, err := r.Play(Move{Tile: Tile{4, 4}, End: Tail}); err == nil {
+		t.Fatal("Expecting [4,4] to not add up to seven with 6")
+	}
+
+	placed, err := r.Play(Move{Tile: Tile{1, 3}, End: Head})
+	if err != nil || placed != (Tile{3, 1}) || r.Head() != 3 {
+		t.Fatalf("Expecting the 1 of [1,3] against the 6 but got %s, %v", placed, err)
+	}
+
+	r.Turn = 0
+	if placed, _ := r.Play(Move{Tile: Tile{2, 5}, End: Tail}); placed != (Tile{2, 5}) || r.Tail() != 5 {
+		t.Fatalf("Expecting the matador [2,5] wild on a 6 with its 5 out but got %s", placed)
+	}
+
+	if MatchSeven.Fits(Tile{0, 6}, 0) || !MatchSeven.Fits(Tile{3, 4}, 0) || !MatchSeven.Fits(Tile{0, 0}, 4) {
+		t.Fatal("Expecting only matadors on a blank and matadors to go anywhere")
+	}
+
+	if !MatchEqual.Fits(Tile{0, 6}, 6) || MatchEqual.Fits(Tile{1, 6}, 0) {
+		t.Fatal("Expecting equal matching to join the same numbers")
+	}
+}
@@ -0,0 +1,354 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// PaiGowTiles is how many tiles a Pai Gow hand holds.
+const PaiGowTiles = 4
+
+// Wong and Gong are the values of Heaven or Earth with a nine and with an
+// eight, above every count of points.
+const (
+	Gong = 10
+	Wong = 11
+)
+
+// PaiGowPair is two tiles of a Pai Gow hand played together.
+type PaiGowPair struct {
+	Tiles [2]Tile
+	// Pair is set for matching tiles, PairRank then orders them like the
+	// ranks of the Chinese set
+	Pair     bool
+	PairRank int
+	// Value is the points of the pair, their pips modulo 10, or Gong or
+	// Wong
+	Value int
+}
+
+// NewPaiGowPair values two tiles played together. A Gee Joon tile counts
+// three or six pips, whichever is better.
+func NewPaiGowPair(a, b Tile) PaiGowPair {
+	p := PaiGowPair{Tiles: [2]Tile{a, b}}
+	ca, _ := Chinese(a)
+	cb, _ := Chinese(b)
+	if ca.Rank == cb.Rank && (ca.Suit == Military || a.Same(b)) {
+		p.Pair, p.PairRank = true, ca.Rank
+		return p
+	}
+
+	for _, x := range geeJoonPips(a) {
+		for _, y := range geeJoonPips(b) {
+			p.Value = max(p.Value, (x+y)%10)
+		}
+	}
+
+	if ca.Rank == 1 || ca.Rank == 2 {
+		p.Value = max(p.Value, bonus(b.Pips()))
+	}
+
+	if cb.Rank == 1 || cb.Rank == 2 {
+		p.Value = max(p.Value, bonus(a.Pips()))
+	}
+
+	return p
+}
+
+// bonus is what a tile is worth next to Heaven or Earth.
+func bonus(pips int) int {
+	switch pips {
+	case 9:
+		return Wong
+	case 8:
+		return Gong
+	}
+
+	return 0
+}
+
+func geeJoonPips(t Tile) []int {
+	if c, _ := Chinese(t); c.Rank == 0 {
+		return []int{3, 6}
+	}
+
+	return []int{t.Pips()}
+}
+
+func (p PaiGowPair) String() string {
+	a, _ := Chinese(p.Tiles[0])
+	b, _ := Chinese(p.Tiles[1])
+	switch {
+	case p.Pair:
+		return fmt.Sprintf("pair of %s", a.Name)
+	case p.Value == Wong:
+		return "wong"
+	case p.Value == Gong:
+		return "gong"
+	}
+
+	return fmt.Sprintf("%d (%s, %s)", p.Value, a.Name, b.Name)
+}
+
+// top returns the rank of the better tile.
+func (p PaiGowPair) top() int {
+	a, _ := Chinese(p.Tiles[0])
+	b, _ := Chinese(p.Tiles[1])
+	return min(a.Rank, b.Rank)
+}
+
+// Compare returns a positive number when p beats o, a negative one when o
+// beats p and zero for a copy. Pairs beat everything else, then Wong, Gong
+// and the points; equal points go to the better tile, except zero which
+// is always a copy.
+func (p PaiGowPair) Compare(o PaiGowPair) int {
+	switch {
+	case p.Pair != o.Pair && p.Pair:
+		return 1
+	case p.Pair != o.Pair:
+		return -1
+	case p.Pair:
+		return o.PairRank - p.PairRank
+	case p.Value != o.Value:
+		return p.Value - o.Value
+	case p.Value == 0:
+		return 0
+	}
+
+	return o.top() - p.top()
+}
+
+// PaiGowHand is four tiles set into a high and a low pair.
+type PaiGowHand struct {
+	High, Low PaiGowPair
+}
+
+func (h PaiGowHand) String() string {
+	return fmt.Sprintf("%s / %s", h.High, h.Low)
+}
+
+// SetPaiGow pairs tiles[0] with tiles[partner] and the other two together,
+// the better pair going high.
+func SetPaiGow(tiles []Tile, partner int) PaiGowHand {
+	rest := []Tile{}
+	for i, t := range tiles[1:] {
+		if i+1 != partner {
+			rest = append(rest, t)
+		}
+	}
+
+	h := PaiGowHand{High: NewPaiGowPair(tiles[0], tiles[partner]), Low: NewPaiGowPair(rest[0], rest[1])}
+	if h.Low.Compare(h.High) > 0 {
+		h.High, h.Low = h.Low, h.High
+	}
+
+	return h
+}
+
+// HouseWay sets the tiles the way the house does: it keeps a pair
+// together, and otherwise plays the strongest low pair, the stronger high
+// one breaking ties. It returns the partner of the first tile.
+func HouseWay(tiles []Tile) int {
+	best := 1
+	for partner := 2; partner < PaiGowTiles; partner++ {
+		if houseBetter(SetPaiGow(tiles, partner), SetPaiGow(tiles, best)) {
+			best = partner
+		}
+	}
+
+	return best
+}
+
+func houseBetter(h, o PaiGowHand) bool {
+	switch {
+	case h.High.Pair != o.High.Pair:
+		return h.High.Pair
+	case h.High.Pair:
+		return h.High.Compare(o.High) > 0
+	case h.Low.Compare(o.Low) != 0:
+		return h.Low.Compare(o.Low) > 0
+	}
+
+	return h.High.Compare(o.High) > 0
+}
+
+// PaiGowRound is a hand of Pai Gow: every seat gets four tiles from the
+// Chinese set and sets them into a high and a low pair. Each player then
+// settles with the dealer, winning the bet with both pairs better, losing
+// it with both worse and pushing otherwise. Copies go to the dealer.
+type PaiGowRound struct {
+	Hands  [][]Tile
+	Dealer int
+	// Partners holds the tile every seat pairs with their first one, zero
+	// while not set
+	Partners []int
+	// Turn is the seat setting their hand, the dealer sets last
+	Turn int
+	Bet  int
+	set  int
+}
+
+// DealPaiGow deals a hand by the ruleset, the dealer moving on every hand
+// and every player betting the ante.
+func DealPaiGow(rng *rand.Rand, rules Ruleset, players, hand int) *PaiGowRound {
+	tiles := rules.Set()
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	r := &PaiGowRound{Dealer: hand % players, Partners: make([]int, players), Bet: rules.Ante}
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-PaiGowTiles:]...))
+		tiles = tiles[:len(tiles)-PaiGowTiles]
+	}
+
+	r.Turn = (r.Dealer + 1) % players
+	return r
+}
+
+// Set sets the hand of the seat on turn, pairing its first tile with the
+// tile at partner, 1 to 3.
+func (r *PaiGowRound) Set(partner int) error {
+	switch {
+	case r.Over():
+		return fmt.Errorf("every hand is set")
+	case partner < 1 || partner >= PaiGowTiles:
+		return fmt.Errorf("no tile %d to pair with", partner)
+	}
+
+	r.Partners[r.Turn] = partner
+	r.set++
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+	return nil
+}
+
+// Over reports whether every seat has set their hand.
+func (r *PaiGowRound) Over() bool {
+	return r.set == len(r.Hands)
+}
+
+// Hand returns the seat's hand as set, or set the house way while it is
+// not.
+func (r *PaiGowRound) Hand(player int) PaiGowHand {
+	partner := r.Partners[player]
+	if partner == 0 {
+		partner = HouseWay(r.Hands[player])
+	}
+
+	return SetPaiGow(r.Hands[player], partner)
+}
+
+// Settle returns +1 when the player beats the dealer, -1 when the dealer
+// wins and 0 for a push.
+func (r *PaiGowRound) Settle(player int) int {
+	h, d := r.Hand(player), r.Hand(r.Dealer)
+	high, low := h.High.Compare(d.High) > 0, h.Low.Compare(d.Low) > 0
+	switch {
+	case high && low:
+		return 1
+	case !high && !low:
+		return -1
+	}
+
+	return 0
+}
+
+// Result settles every player with the dealer. Points holds the chips won
+// or lost, the dealer's being what the players lost less what they won.
+// The winners are the players who beat the dealer, or the dealer when
+// nobody did and they won chips.
+func (r *PaiGowRound) Result() Result {
+	res := Result{Winner: -1, Turns: r.set, Points: make([]int, len(r.Hands))}
+	beat := 0
+	for i, h := range r.Hands {
+		pips := 0
+		for _, t := range h {
+			pips += t.Pips()
+		}
+		res.Pips = append(res.Pips, pips)
+
+		if i == r.Dealer {
+			continue
+		}
+
+		s := r.Settle(i)
+		res.Points[i] = s * r.Bet
+		res.Points[r.Dealer] -= s * r.Bet
+		if s > 0 {
+			res.Winners = append(res.Winners, i)
+			beat++
+		}
+	}
+
+	if beat == 0 && res.Points[r.Dealer] > 0 {
+		res.Winners = []int{r.Dealer}
+	}
+
+	if len(res.Winners) > 0 {
+		res.Winner = res.Winners[0]
+	}
+
+	res.Reason = fmt.Sprintf("player %d deals %s, %d players beat it", r.Dealer+1, r.Hand(r.Dealer), beat)
+	return res
+}
+
+// PaiGowPosition is what a player sees of a Pai Gow round when setting
+// their hand.
+type PaiGowPosition struct {
+	Player int
+	Dealer int
+	Hand   []Tile
+}
+
+// Position returns what the player sees.
+func (r *PaiGowRound) Position(player int) PaiGowPosition {
+	return PaiGowPosition{Player: player, Dealer: r.Dealer, Hand: append([]Tile(nil), r.Hands[player]...)}
+}
+
+// Run sets every hand with one strategy per seat and settles the round.
+// Strategies that do not implement PaiGowStrategy set the house way.
+func (r *PaiGowRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		if err := r.Set(ChoosePaiGow(strategies[r.Turn], r.Position(r.Turn))); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// PaiGowStrategy is implemented by strategies that know how to set a Pai
+// Gow hand. ChoosePaiGow returns the partner of the first tile.
+type PaiGowStrategy interface {
+	ChoosePaiGow(pos PaiGowPosition) int
+}
+
+// ChoosePaiGow asks the strategy how to set the hand, falling back to the
+// balanced one.
+func ChoosePaiGow(s Strategy, pos PaiGowPosition) int {
+	if ps, ok := s.(PaiGowStrategy); ok {
+		return ps.ChoosePaiGow(pos)
+	}
+
+	return balancedStrategy{}.ChoosePaiGow(pos)
+}
+
+func (s *randomStrategy) ChoosePaiGow(pos PaiGowPosition) int {
+	return 1 + s.rng.Intn(PaiGowTiles-1)
+}
+
+// heavy puts its best pair high whatever is left for the low one
+func (heavyStrategy) ChoosePaiGow(pos PaiGowPosition) int {
+	best := 1
+	for partner := 2; partner < PaiGowTiles; partner++ {
+		if SetPaiGow(pos.Hand, partner).High.Compare(SetPaiGow(pos.Hand, best).High) > 0 {
+			best = partner
+		}
+	}
+
+	return best
+}
+
+func (balancedStrategy) ChoosePaiGow(pos PaiGowPosition) int {
+	return HouseWay(pos.Hand)
+}
@@ -0,0 +1,104 @@
+package engine
+
+import "testing"
+
+func TestChineseSet(t *testing.T) {
+	set := ChineseSet()
+	civil := 0
+	for _, tile := range set {
+		c, ok := Chinese(tile)
+		if !ok || tile.A == 0 || tile.B == 0 {
+			t.Fatalf("Expecting %s in the Chinese set", tile)
+		}
+
+		if c.Suit == Civil {
+			civil++
+		}
+	}
+
+	if len(set) != 32 || civil != 22 {
+		t.Fatalf("Expecting 32 tiles, 22 of them civil, but got %d with %d civil", len(set), civil)
+	}
+
+	if c, _ := Chinese(Tile{6, 6}); c.Name != "Heaven" || RedPips(Tile{6, 6}, 6) != 3 || RedPips(Tile{5, 6}, 6) != 0 || RedPips(Tile{4, 4}, 4) != 4 {
+		t.Fatalf("Expecting Heaven with half its pips red but got %+v", c)
+	}
+}
+
+func TestPaiGowPairs(t *testing.T) {
+	wong := NewPaiGowPair(Tile{6, 6}, Tile{4, 5})
+	gong := NewPaiGowPair(Tile{1, 1}, Tile{2, 6})
+	geeJoon := NewPaiGowPair(Tile{2, 4}, Tile{1, 2})
+	nine := NewPaiGowPair(Tile{2, 4}, Tile{1, 3})
+	if wong.Value != Wong || gong.Value != Gong || !geeJoon.Pair || geeJoon.PairRank != 0 {
+		t.Fatalf("Expecting wong, gong and the Gee Joon pair but got %s, %s and %s", wong, gong, geeJoon)
+	}
+
+	// the Gee Joon [2,4] counts three next to the four pips of [1,3]
+	if nine.Value != 7 {
+		t.Fatalf("Expecting [2,4] with [1,3] worth 7 but got %d", nine.Value)
+	}
+
+	order := []PaiGowPair{geeJoon, NewPaiGowPair(Tile{1, 1}, Tile{1, 1}), NewPaiGowPair(Tile{4, 5}, Tile{3, 6}), wong, gong, nine}
+	for i := 1; i < len(order); i++ {
+		if order[i-1].Compare(order[i]) <= 0 {
+			t.Errorf("Expecting %s to beat %s", order[i-1], order[i])
+		}
+	}
+
+	// equal points go to the better tile, zero is a copy
+	if NewPaiGowPair(Tile{5, 5}, Tile{4, 6}).Compare(NewPaiGowPair(Tile{2, 2}, Tile{1, 5})) != 0 {
+		t.Error("Expecting two zeros to be a copy")
+	}
+
+	if NewPaiGowPair(Tile{1, 3}, Tile{1, 2}).Compare(NewPaiGowPair(Tile{3, 4}, Tile{2, 5})) >= 0 {
+		t.Error("Expecting the pair of sevens to beat a seven")
+	}
+}
+
+func TestPaiGowRound(t *testing.T) {
+	// the house keeps the pair of Longs together
+	if p := HouseWay([]Tile{{3, 3}, {1, 6}, {3, 3}, {2, 5}}); p != 2 {
+		t.Fatalf("Expecting the house to pair the Longs but got partner %d", p)
+	}
+
+	// [6,6] [4,5] for wong / [1,2] [2,3] for 8 beats [6,6] [2,3] for 7 / 2
+	if p := HouseWay([]Tile{{6, 6}, {2, 3}, {4, 5}, {1, 2}}); p != 2 {
+		t.Fatalf("Expecting wong and eight but got %s", SetPaiGow([]Tile{{6, 6}, {2, 3}, {4, 5}, {1, 2}}, p))
+	}
+
+	r := &PaiGowRound{
+		Hands:    [][]Tile{{{5, 5}, {2, 2}, {1, 5}, {3, 4}}, {{6, 6}, {3, 6}, {1, 1}, {1, 6}}, {{4, 6}, {5, 5}, {2, 3}, {1, 4}}},
+		Partners: make([]int, 3),
+		Turn:     1,
+		Bet:      10,
+	}
+
+	for !r.Over() {
+		r.Set(HouseWay(r.Hands[r.Turn]))
+	}
+
+	// the dealer's 4 / 3 loses both pairs to wong / 9, and beats the 0 under
+	// the pair of fives for a push
+	res := r.Result()
+	if res.Points[0] != -10 || res.Points[1] != 10 || res.Points[2] != 0 || res.Winner != 1 {
+		t.Fatalf("Expecting player 2 to win and player 3 to push but got %+v, dealer %s", res, r.Hand(0))
+	}
+}
+
+func TestPaiGowMatch(t *testing.T) {
+	rules, _ := LookupRuleset("pai-gow")
+	m, err := PlayMatch(3, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, s := range m.Scores {
+		total += s
+	}
+
+	if len(m.Hands) != rules.Hands || total != 0 {
+		t.Fatalf("Expecting %d hands where the dealer pays what the players win but got %v", rules.Hands, m.Scores)
+	}
+}
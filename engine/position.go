@@ -0,0 +1,108 @@
+package engine
+
+import "fmt"
+
+// End identifies an open end of the line of play.
+type End int
+
+const (
+	Head End = iota
+	Tail
+)
+
+func (e End) String() string {
+	if e == Tail {
+		return "tail"
+	}
+
+	return "head"
+}
+
+// Move is a tile placed on one end of the line of play.
+type Move struct {
+	Tile Tile
+	End  End
+}
+
+func (m Move) String() string {
+	return fmt.Sprintf("%s on %s", m.Tile, m.End)
+}
+
+// Position is everything the player to move is allowed to see.
+type Position struct {
+	Started bool
+	Head    int
+	Tail    int
+	Hand    []Tile
+	Played  []Tile
+	// OpponentCards holds the number of tiles left in every opponent's hand
+	OpponentCards []int
+	// OpponentLacks holds, in the same order, the numbers every opponent
+	// has shown to lack by passing or drawing, see Round.Infer
+	OpponentLacks [][]int
+	// Matching decides which tiles fit an open end
+	Matching Matching
+}
+
+// CanPlay reports whether the tile fits on the given end.
+func (p Position) CanPlay(t Tile, e End) bool {
+	if !p.Started {
+		return true
+	}
+
+	if e == Tail {
+		return p.Matching.Fits(t, p.Tail)
+	}
+
+	return p.Matching.Fits(t, p.Head)
+}
+
+// EndFor returns the end a tile goes to when the player does not choose,
+// preferring the head like the original table rules.
+func (p Position) EndFor(t Tile) (End, bool) {
+	switch {
+	case p.CanPlay(t, Head):
+		return Head, true
+	case p.CanPlay(t, Tail):
+		return Tail, true
+	}
+
+	return Head, false
+}
+
+// LegalMoves lists every playable tile and end. When both ends show the same
+// number only the head is returned since the two moves are equivalent.
+func (p Position) LegalMoves() []Move {
+	moves := []Move{}
+	for _, t := range p.Hand {
+		if !p.Started {
+			moves = append(moves, Move{Tile: t, End: Head})
+			continue
+		}
+
+		if p.CanPlay(t, Head) {
+			moves = append(moves, Move{Tile: t, End: Head})
+		}
+
+		if p.CanPlay(t, Tail) && !(p.CanPlay(t, Head) && p.Head == p.Tail) {
+			moves = append(moves, Move{Tile: t, End: Tail})
+		}
+	}
+
+	return moves
+}
+
+// After returns the open ends once the move is made.
+func (p Position) After(m Move) (head int, tail int) {
+	if !p.Started {
+		return m.Tile.A, m.Tile.B
+	}
+
+	if m.End == Tail {
+		t, _ := p.Matching.Join(m.Tile, p.Tail)
+		return p.Head, t.B
+	}
+
+	t, _ := p.Matching.Join(m.Tile, p.Head)
+	return t.B, p.Tail
+}
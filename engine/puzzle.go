@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Puzzle is a position of a block game to solve: every hand and the line
+// of play are known and the player on turn has to find the moves that win
+// the hand whatever the others do.
+type Puzzle struct {
+	Name        string
+	Description string
+	// Hands holds the tiles of every seat, Line the tiles played from head
+	// to tail and Turn the seat of the hero, who moves first
+	Hands [][]Tile
+	Line  []Tile
+	Turn  int
+	Tie   TieRule
+}
+
+// ParsePuzzle reads a puzzle written as "key: value" lines, '#' starting a
+// comment. Tiles are written "a-b"; there is a hand line per seat, in seat
+// order, and turn counts seats from 1:
+//
+//	name: two-handed
+//	description: Go out before player 2 does
+//	line: 4-4 4-3 3-6
+//	hand: 1-6 1-3 1-4 4-5
+//	hand: 0-6 0-2 3-5 0-3
+//	turn: 1
+func ParsePuzzle(text string) (Puzzle, error) {
+	p := Puzzle{Tie: TieLowestTile}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+
+		if text == "" {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 2)
+		if len(parts) != 2 {
+			return p, fmt.Errorf("line %d: expected key: value", line)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		var err error
+		switch key {
+		case "name":
+			p.Name = value
+		case "description":
+			p.Description = value
+		case "line":
+			p.Line, err = parseTiles(value)
+		case "hand":
+			var hand []Tile
+			hand, err = parseTiles(value)
+			p.Hands = append(p.Hands, hand)
+		case "turn":
+			p.Turn, err = strconv.Atoi(value)
+			p.Turn--
+		case "tie":
+			p.Tie = TieRule(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+
+		if err != nil {
+			return p, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+
+	return p, p.Validate()
+}
+
+// Validate checks the puzzle is a position that could happen in a game and
+// that the hero has a winning move in it.
+func (p Puzzle) Validate() error {
+	r, err := p.Round()
+	if err != nil {
+		return err
+	}
+
+	if len(WinningMoves(r, p.Turn)) == 0 {
+		return fmt.Errorf("player %d has no winning move", p.Turn+1)
+	}
+
+	return nil
+}
+
+func parseTiles(value string) ([]Tile, error) {
+	tiles := []Tile{}
+	for _, f := range strings.Fields(value) {
+		var t Tile
+		if _, err := fmt.Sscanf(f, "%d-%d", &t.A, &t.B); err != nil || t.A < 0 || t.B < 0 {
+			return nil, fmt.Errorf("bad tile %q", f)
+		}
+		tiles = append(tiles, t)
+	}
+
+	return tiles, nil
+}
+
+// LoadPuzzle reads a puzzle file, named after the file when it has no
+// name.
+func LoadPuzzle(path string) (Puzzle, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Puzzle{}, err
+	}
+
+	p, err := ParsePuzzle(string(data))
+	if err != nil {
+		return p, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if p.Name == "" {
+		p.Name = path
+	}
+
+	return p, nil
+}
+
+// Ruleset returns the rules the puzzle is played by: a block game of its
+// seats, nobody drawing.
+func (p Puzzle) Ruleset() Ruleset {
+	hand := 0
+	for _, h := range p.Hands {
+		hand = max(hand, len(h))
+	}
+
+	return Ruleset{
+		Name: "puzzle", MinPip: 0, MaxPip: 6, HandSize: hand, Players: len(p.Hands),
+		Opening: OpeningWinner, Tie: p.Tie, Scoring: ScoreOpponents,
+	}
+}
+
+// Round sets the puzzle's position up, checking it could happen in a game.
+func (p Puzzle) Round() (*Round, error) {
+	switch {
+	case len(p.Hands) < 2:
+		return nil, fmt.Errorf("puzzle needs at least 2 hands")
+	case p.Turn < 0 || p.Turn >= len(p.Hands):
+		return nil, fmt.Errorf("turn %d is not a seat", p.Turn+1)
+	}
+
+	switch p.Tie {
+	case TieLowestTile, TieShared, TieNone:
+	default:
+		return nil, fmt.Errorf("unknown tie rule %q", p.Tie)
+	}
+
+	seen, rules := []Tile{}, p.Ruleset()
+	tiles := append([]Tile(nil), p.Line...)
+	for _, h := range p.Hands {
+		if len(h) == 0 {
+			return nil, fmt.Errorf("every hand needs a tile")
+		}
+		tiles = append(tiles, h...)
+	}
+
+	for _, t := range tiles {
+		if t.A > rules.MaxPip || t.B > rules.MaxPip {
+			return nil, fmt.Errorf("%s is not in a double-%d set", t, rules.MaxPip)
+		}
+
+		for _, s := range seen {
+			if s.Same(t) {
+				return nil, fmt.Errorf("%s is there twice", t)
+			}
+		}
+		seen = append(seen, t)
+	}
+
+	for i := 1; i < len(p.Line); i++ {
+		if p.Line[i-1].B != p.Line[i].A {
+			return nil, fmt.Errorf("%s does not join %s on the line", p.Line[i], p.Line[i-1])
+		}
+	}
+
+	r := rules.NewRound()
+	for _, h := range p.Hands {
+		r.AddHand(h)
+	}
+
+	r.Line, r.Turn = append([]Tile(nil), p.Line...), p.Turn
+	if !r.HasMove(p.Turn) {
+		return nil, fmt.Errorf("player %d on turn can not move", p.Turn+1)
+	}
+
+	return r, nil
+}
+
+// Solution returns the first moves that win the puzzle.
+func (p Puzzle) Solution() []Move {
+	r, err := p.Round()
+	if err != nil {
+		return nil
+	}
+
+	return WinningMoves(r, p.Turn)
+}
+
+// puzzles is the built-in collection, each one checked by the solver.
+var puzzles = map[string]string{
+	"two-handed": `
+name: two-handed
+description: Player 2 holds the blanks. Go out before they block you.
+line: 4-4 4-3 3-6
+hand: 1-6 1-3 1-4 4-5
+hand: 0-6 0-2 3-5 0-3
+turn: 1
+`,
+	"three-handed": `
+name: three-handed
+description: Three players, three tiles each. Win with the lightest hand.
+line: 3-6 6-6 6-0
+hand: 0-2 1-4 0-3
+hand: 2-4 3-3 0-4
+hand: 5-6 1-2 3-5
+turn: 1
+`,
+	"four-handed": `
+name: four-handed
+description: Four players for themselves. Only one of your moves keeps the win.
+line: 2-6 6-5 5-4
+hand: 4-4 0-1 2-5 2-4
+hand: 1-4 6-6 1-6 3-3
+hand: 1-5 1-1 2-3 5-5
+hand: 2-2 0-3 1-2 0-0
+turn: 1
+`,
+}
+
+// Puzzles returns the names of the built-in puzzles, sorted.
+func Puzzles() []string {
+	names := make([]string, 0, len(puzzles))
+	for name := range puzzles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// FindPuzzle returns the built-in puzzle called name, or reads it from the
+// file when name has a file extension.
+func FindPuzzle(name string) (Puzzle, error) {
+	if strings.Contains(name, ".") {
+		return LoadPuzzle(name)
+	}
+
+	text, ok := puzzles[name]
+	if !ok {
+		return Puzzle{}, fmt.Errorf("unknown puzzle %q, built-in puzzles: %s", name, strings.Join(Puzzles(), ", "))
+	}
+
+	return ParsePuzzle(text)
+}
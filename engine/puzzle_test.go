@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPuzzles(t *testing.T) {
+	for _, name := range Puzzles() {
+		p, err := FindPuzzle(name)
+		if err != nil {
+			t.Fatalf("Expecting puzzle %s to load but got %v", name, err)
+		}
+
+		r, _ := p.Round()
+		legal, win := r.Position(p.Turn).LegalMoves(), p.Solution()
+		if len(win) == 0 || len(win) == len(legal) {
+			t.Fatalf("Expecting puzzle %s to be won by some of its %d moves but %d win", name, len(legal), len(win))
+		}
+
+		// the defender holds on to the win after any losing move
+		for _, m := range legal {
+			next := r.Clone()
+			next.Play(m)
+			if Wins(next, p.Turn) != containsMove(win, m) {
+				t.Fatalf("Expecting WinningMoves and Wins of puzzle %s to agree on %v", name, m)
+			}
+		}
+	}
+}
+
+func containsMove(moves []Move, m Move) bool {
+	for _, w := range moves {
+		if w == m {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestParsePuzzle(t *testing.T) {
+	p, err := ParsePuzzle(`
+# a two move puzzle
+name: short
+line: 1-2
+hand: 2-3 1-6
+hand: 5-5 4-4   # player 2
+turn: 1
+tie: shared
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "short" || len(p.Hands) != 2 || p.Turn != 0 || p.Tie != TieShared || p.Line[0] != (Tile{1, 2}) {
+		t.Fatalf("Expecting the puzzle read as written but got %+v", p)
+	}
+
+	// player 2 never moves, so either tile wins
+	if win := p.Solution(); len(win) != 2 {
+		t.Fatalf("Expecting both moves to win but got %v", win)
+	}
+
+	for _, bad := range []struct{ text, err string }{
+		{"line: 1-2\nhand: 1-2\nhand: 3-3", "twice"},
+		{"line: 1-2 3-4\nhand: 2-2\nhand: 5-5", "does not join"},
+		{"line: 1-2\nhand: 5-5\nhand: 2-2", "can not move"},
+		{"line: 1-x\nhand: 2-2\nhand: 5-5", "bad tile"},
+		{"hand: 2-2\nhand: 5-5\nturn: 3", "not a seat"},
+		{"hand: 2-2", "at least 2"},
+		{"colour: red", "unknown key"},
+		{"line: 1-2\nhand: 2-7\nhand: 5-5", "double-6"},
+		// [2,3] leaves [5,5] against [4,4] and the blocked hand is lost
+		{"line: 1-2\nhand: 2-3 5-5\nhand: 1-6 4-4", "no winning move"},
+	} {
+		if _, err := ParsePuzzle(bad.text); err == nil || !strings.Contains(err.Error(), bad.err) {
+			t.Fatalf("Expecting %q to fail with %q but got %v", bad.text, bad.err, err)
+		}
+	}
+}
+
+func TestDefender(t *testing.T) {
+	p, _ := FindPuzzle("two-handed")
+	r, _ := p.Round()
+	for _, m := range r.Position(p.Turn).LegalMoves() {
+		if containsMove(p.Solution(), m) {
+			continue
+		}
+
+		// once the hero slips the defender keeps them from winning
+		next := r.Clone()
+		next.Play(m)
+		defender := &Defender{Round: next, Hero: p.Turn}
+		for !next.Over() {
+			move := next.Position(next.Turn).LegalMoves()[0]
+			if next.Turn != p.Turn {
+				move, _ = defender.Choose(next.Position(next.Turn))
+			}
+			next.Play(move)
+		}
+
+		if next.Result().IsWinner(p.Turn) {
+			t.Fatalf("Expecting the defender to beat %v", m)
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// QiuSpecial is a special hand of QiuQiu. Special hands rank above every
+// normal hand, higher values above lower ones.
+type QiuSpecial int
+
+const (
+	QiuNormal QiuSpecial = iota
+	// QiuPureBig is four tiles with 39 pips or more
+	QiuPureBig
+	// QiuPureSmall is four tiles with 9 pips or fewer
+	QiuPureSmall
+	// QiuTwin is four doubles (balak)
+	QiuTwin
+	// QiuSixDevil is four tiles of six pips each
+	QiuSixDevil
+)
+
+func (s QiuSpecial) String() string {
+	switch s {
+	case QiuPureBig:
+		return "pure big"
+	case QiuPureSmall:
+		return "pure small"
+	case QiuTwin:
+		return "twin"
+	case QiuSixDevil:
+		return "six devil"
+	}
+
+	return "normal"
+}
+
+// QiuTiles is how many tiles a QiuQiu hand holds, QiuFirstTiles of them are
+// dealt before the first betting round.
+const (
+	QiuTiles      = 4
+	QiuFirstTiles = 3
+)
+
+// QiuHand is a QiuQiu hand split into two pairs, Tiles[0:2] holding the
+// higher one.
+type QiuHand struct {
+	Tiles   [QiuTiles]Tile
+	Special QiuSpecial
+	// High and Low are the values of the pairs, their pips modulo 10
+	High, Low int
+}
+
+func (h QiuHand) String() string {
+	if h.Special != QiuNormal {
+		return h.Special.String()
+	}
+
+	if h.High == 9 && h.Low == 9 {
+		return "qiu qiu"
+	}
+
+	return fmt.Sprintf("%d/%d", h.High, h.Low)
+}
+
+// Pips returns the pips of all four tiles.
+func (h QiuHand) Pips() int {
+	total := 0
+	for _, t := range h.Tiles {
+		total += t.Pips()
+	}
+
+	return total
+}
+
+// PairValue is what two tiles are worth as a pair.
+func PairValue(a, b Tile) int {
+	return (a.Pips() + b.Pips()) % 10
+}
+
+// ArrangeQiu splits four tiles into a pair of tiles[0] with tiles[partner]
+// and a pair of the other two.
+func ArrangeQiu(tiles []Tile, partner int) QiuHand {
+	rest := []Tile{}
+	for i, t := range tiles[1:] {
+		if i+1 != partner {
+			rest = append(rest, t)
+		}
+	}
+
+	h := QiuHand{Tiles: [QiuTiles]Tile{tiles[0], tiles[partner], rest[0], rest[1]}}
+	h.High, h.Low = PairValue(h.Tiles[0], h.Tiles[1]), PairValue(h.Tiles[2], h.Tiles[3])
+	if h.Low > h.High {
+		h.Tiles = [QiuTiles]Tile{h.Tiles[2], h.Tiles[3], h.Tiles[0], h.Tiles[1]}
+		h.High, h.Low = h.Low, h.High
+	}
+
+	h.Special = qiuSpecial(tiles)
+	return h
+}
+
+func qiuSpecial(tiles []Tile) QiuSpecial {
+	doubles, devils, pips := 0, 0, 0
+	for _, t := range tiles {
+		pips += t.Pips()
+		if t.IsDouble() {
+			doubles++
+		}
+		if t.Pips() == 6 {
+			devils++
+		}
+	}
+
+	switch {
+	case devils == QiuTiles:
+		return QiuSixDevil
+	case doubles == QiuTiles:
+		return QiuTwin
+	case pips <= 9:
+		return QiuPureSmall
+	case pips >= 39:
+		return QiuPureBig
+	}
+
+	return QiuNormal
+}
+
+// BestQiu returns the strongest way to split the four tiles.
+func BestQiu(tiles []Tile) QiuHand {
+	best := ArrangeQiu(tiles, 1)
+	for partner := 2; partner < QiuTiles; partner++ {
+		if h := ArrangeQiu(tiles, partner); h.Compare(best) > 0 {
+			best = h
+		}
+	}
+
+	return best
+}
+
+// Compare returns a positive number when h beats o, a negative one when o
+// beats h and zero for a tie. Special hands beat normal ones, pure small
+// hands are compared by the fewest pips and pure big ones by the most.
+// Normal hands compare the higher pair, then the lower one. What is still
+// tied goes to the hand holding the best tile by the opening order.
+func (h QiuHand) Compare(o QiuHand) int {
+	switch {
+	case h.Special != o.Special:
+		return int(h.Special) - int(o.Special)
+	case h.Special == QiuPureSmall && h.Pips() != o.Pips():
+		return o.Pips() - h.Pips()
+	case h.Special == QiuPureBig && h.Pips() != o.Pips():
+		return h.Pips() - o.Pips()
+	case h.Special == QiuNormal && h.High != o.High:
+		return h.High - o.High
+	case h.Special == QiuNormal && h.Low != o.Low:
+		return h.Low - o.Low
+	}
+
+	a, b := h.top(), o.top()
+	switch {
+	case leads(a, b):
+		return 1
+	case leads(b, a):
+		return -1
+	}
+
+	return 0
+}
+
+func (h QiuHand) top() Tile {
+	top := h.Tiles[0]
+	for _, t := range h.Tiles[1:] {
+		if leads(t, top) {
+			top = t
+		}
+	}
+
+	return top
+}
+
+// QiuStreets is the number of betting rounds, one after three tiles and
+// one after the fourth.
+const QiuStreets = 2
+
+// QiuEvent is one betting action of a QiuQiu round, Chips what it put in
+// the pot.
+type QiuEvent struct {
+	Player int
+	Street int
+	Bet    Bet
+	Chips  int
+}
+
+// QiuRound is a hand of QiuQiu. Every player antes and gets three tiles and
+// bets, gets the fourth and bets again; the players still in the hand then
+// split their tiles into two pairs and the best hands take the pots.
+type QiuRound struct {
+	Hands    [][]Tile
+	Boneyard []Tile
+	// Betting holds the stacks, the pots and whose turn it is
+	*Betting
+	// Partners holds the tile every player pairs with their first one, zero
+	// for the best split
+	Partners []int
+	// Street counts the betting rounds: 0 after three tiles, 1 after the
+	// fourth and 2 at the showdown
+	Street  int
+	History []QiuEvent
+	first   int
+}
+
+// DealQiu deals a hand by the ruleset to players sitting down with the
+// ruleset's chips.
+func DealQiu(rng *rand.Rand, rules Ruleset, players, hand int) *QiuRound {
+	stacks := make([]int, players)
+	for i := range stacks {
+		stacks[i] = rules.Chips
+	}
+
+	return DealQiuStacks(rng, rules, hand, stacks)
+}
+
+// DealQiuStacks deals a hand to players with the given stacks: everybody
+// antes and gets three tiles, the player after the dealer bets first.
+// Players without chips sit the hand out.
+func DealQiuStacks(rng *rand.Rand, rules Ruleset, hand int, stacks []int) *QiuRound {
+	tiles := rules.Set()
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	players := len(stacks)
+	r := &QiuRound{Betting: NewBetting(stacks, rules.Ante, rules.MinBet), Partners: make([]int, players)}
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-QiuFirstTiles:]...))
+		tiles = tiles[:len(tiles)-QiuFirstTiles]
+	}
+
+	r.Boneyard = tiles
+	r.first = (hand + 1) % players
+	r.StartRound(r.first)
+	r.advance()
+	return r
+}
+
+// Act makes the betting action for the player on turn.
+func (r *QiuRound) Act(bet Bet) error {
+	if r.Over() {
+		return fmt.Errorf("the hand is over")
+	}
+
+	p := r.Turn
+	before := r.Committed[p]
+	if err := r.Betting.Act(bet); err != nil {
+		return err
+	}
+
+	r.History = append(r.History, QiuEvent{Player: p, Street: r.Street, Bet: bet, Chips: r.Committed[p] - before})
+	r.advance()
+	return nil
+}
+
+// advance moves on while nobody has to act: the fourth tiles are dealt
+// after the first betting round and the hands are shown down after the
+// second, or as soon as all players but one folded.
+func (r *QiuRound) advance() {
+	for r.RoundOver() && !r.Over() {
+		if len(r.Active()) < 2 {
+			r.Street = QiuStreets
+			return
+		}
+
+		r.Street++
+		if r.Street == 1 {
+			for _, p := range r.Active() {
+				r.Hands[p] = append(r.Hands[p], r.Boneyard[len(r.Boneyard)-1])
+				r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+			}
+		}
+
+		if r.Street < QiuStreets {
+			r.StartRound(r.first)
+		}
+	}
+}
+
+// Arrange pairs the player's first tile with the tile at partner, 1 to 3,
+// or lets the best split be used with 0.
+func (r *QiuRound) Arrange(player, partner int) error {
+	switch {
+	case len(r.Hands[player]) < QiuTiles:
+		return fmt.Errorf("player %d does not hold %d tiles yet", player+1, QiuTiles)
+	case partner < 0 || partner >= QiuTiles:
+		return fmt.Errorf("no tile %d to pair with", partner)
+	}
+
+	r.Partners[player] = partner
+	return nil
+}
+
+// Hand returns the player's hand split the way they arranged it.
+func (r *QiuRound) Hand(player int) QiuHand {
+	if r.Partners[player] == 0 {
+		return BestQiu(r.Hands[player])
+	}
+
+	return ArrangeQiu(r.Hands[player], r.Partners[player])
+}
+
+// Over reports whether the hand is shown down or all players but one have
+// folded.
+func (r *QiuRound) Over() bool {
+	return r.Street >= QiuStreets
+}
+
+// Result shares every pot among the best hands eligible for it. Points
+// holds the chips every player won or lost, Pips the pips of the hands and
+// Winners the winners of the main pot. A hand checked through without an
+// ante has no pot and no winner.
+func (r *QiuRound) Result() Result {
+	res := Result{Winner: -1, Turns: len(r.History), Points: make([]int, len(r.Hands))}
+	for _, h := range r.Hands {
+		pips := 0
+		for _, t := range h {
+			pips += t.Pips()
+		}
+		res.Pips = append(res.Pips, pips)
+	}
+
+	won, winners := r.Payout(func(a, b int) int {
+		return r.Hand(a).Compare(r.Hand(b))
+	})
+
+	for i := range res.Points {
+		res.Points[i] = won[i] - r.Committed[i]
+	}
+
+	if len(winners) == 0 {
+		res.Reason = "nothing was bet, nobody wins"
+		return res
+	}
+
+	res.Winners = winners
+	res.Winner = winners[0]
+	switch {
+	case len(r.Active()) == 1:
+		res.Reason = fmt.Sprintf("everybody else folded to player %d", res.Winner+1)
+	case len(res.Winners) > 1:
+		res.Reason = fmt.Sprintf("%d players tie with %s and share the pot", len(res.Winners), r.Hand(res.Winner))
+	default:
+		res.Reason = fmt.Sprintf("player %d shows %s", res.Winner+1, r.Hand(res.Winner))
+	}
+
+	if pots := len(r.Pots()); pots > 1 {
+		res.Reason += fmt.Sprintf(", %d side pots", pots-1)
+	}
+
+	return res
+}
+
+// QiuPosition is what a player sees of a QiuQiu round.
+type QiuPosition struct {
+	Player int
+	Hand   []Tile
+	Street int
+	Stacks []int
+	// Committed holds what every player put in the pot
+	Committed []int
+	Folded    []bool
+	Pot       int
+	ToCall    int
+	MinRaise  int
+	// Legal lists the actions open to the player when on turn
+	Legal []BetKind
+}
+
+// Position returns what the player sees.
+func (r *QiuRound) Position(player int) QiuPosition {
+	pos := QiuPosition{
+		Player:    player,
+		Hand:      append([]Tile(nil), r.Hands[player]...),
+		Street:    r.Street,
+		Stacks:    append([]int(nil), r.Stacks...),
+		Committed: append([]int(nil), r.Committed...),
+		Folded:    append([]bool(nil), r.Folded...),
+		Pot:       r.Total(),
+		ToCall:    r.ToCall(player),
+		MinRaise:  r.MinRaise,
+	}
+
+	if player == r.Turn {
+		pos.Legal = r.Legal()
+	}
+
+	return pos
+}
+
+// Run plays the hand to the end with one strategy per seat, every hand
+// being split the best way. Strategies that do not implement QiuStrategy
+// bet like the balanced one.
+func (r *QiuRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		if err := r.Act(ChooseQiu(strategies[r.Turn], r.Position(r.Turn))); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// QiuStrategy is implemented by strategies that know how to bet in
+// QiuQiu.
+type QiuStrategy interface {
+	ChooseQiu(pos QiuPosition) Bet
+}
+
+// ChooseQiu asks the strategy for a betting action, falling back to the
+// balanced one.
+func ChooseQiu(s Strategy, pos QiuPosition) Bet {
+	if qs, ok := s.(QiuStrategy); ok {
+		return qs.ChooseQiu(pos)
+	}
+
+	return balancedStrategy{}.ChooseQiu(pos)
+}
+
+// EvaluateQiu rates the hand from 0 to 1 and explains the rating. With
+// three tiles it rates the best pair among them, with four the best split.
+func EvaluateQiu(hand []Tile) (float64, string) {
+	if len(hand) >= QiuTiles {
+		h := BestQiu(hand)
+		if h.Special != QiuNormal {
+			return 1, fmt.Sprintf("holds %s", h)
+		}
+
+		return float64(h.High*10+h.Low) / 99, fmt.Sprintf("splits into %s", h)
+	}
+
+	best := 0
+	for i := range hand {
+		for j := i + 1; j < len(hand); j++ {
+			best = max(best, PairValue(hand[i], hand[j]))
+		}
+	}
+
+	return float64(best) / 9, fmt.Sprintf("best pair so far is worth %d", best)
+}
+
+// stay checks when it is free and calls otherwise.
+func (pos QiuPosition) stay() Bet {
+	if pos.ToCall > 0 {
+		return Bet{Kind: BetCall}
+	}
+
+	return Bet{Kind: BetCheck}
+}
+
+func (s *randomStrategy) ChooseQiu(pos QiuPosition) Bet {
+	k := pos.Legal[s.rng.Intn(len(pos.Legal))]
+	return Bet{Kind: k, Amount: pos.MinRaise}
+}
+
+// heavy stays in every hand and opens the betting whenever nobody did
+func (heavyStrategy) ChooseQiu(pos QiuPosition) Bet {
+	if pos.ToCall == 0 && pos.Stacks[pos.Player] > 0 {
+		return Bet{Kind: BetRaise, Amount: pos.MinRaise}
+	}
+
+	return pos.stay()
+}
+
+// balanced raises a strong hand when the betting is open, and gives up a
+// weak one unless staying is free
+func (balancedStrategy) ChooseQiu(pos QiuPosition) Bet {
+	strength, _ := EvaluateQiu(pos.Hand)
+	switch {
+	case strength >= 0.8 && pos.ToCall == 0 && pos.Stacks[pos.Player] > 0:
+		return Bet{Kind: BetRaise, Amount: pos.MinRaise}
+	case strength < 0.4+0.1*float64(pos.Street) && pos.ToCall > 0:
+		return Bet{Kind: BetFold}
+	}
+
+	return pos.stay()
+}
@@ -0,0 +1,117 @@
+package engine
+
+import "testing"
+
+func TestQiuHands(t *testing.T) {
+	// [6,3] with [0,0] makes 9 and [4,5] with [2,3] makes 4, which beats
+	// pairing [6,3] with [4,5] for 8 and [0,0] with [2,3] for 5
+	h := BestQiu([]Tile{{6, 3}, {4, 5}, {0, 0}, {2, 3}})
+	if h.High != 9 || h.Low != 4 {
+		t.Fatalf("Expecting the best split to be 9/4 but got %s", h)
+	}
+
+	if a := ArrangeQiu([]Tile{{6, 3}, {4, 5}, {0, 0}, {2, 3}}, 1); a.High != 8 || a.Low != 5 || a.Compare(h) >= 0 {
+		t.Fatalf("Expecting 8/5 to lose to 9/4 but got %s", a)
+	}
+
+	specials := []struct {
+		tiles []Tile
+		want  QiuSpecial
+	}{
+		{[]Tile{{0, 6}, {1, 5}, {2, 4}, {3, 3}}, QiuSixDevil},
+		{[]Tile{{0, 0}, {1, 1}, {4, 4}, {6, 6}}, QiuTwin},
+		{[]Tile{{0, 1}, {0, 2}, {1, 2}, {0, 3}}, QiuPureSmall},
+		{[]Tile{{6, 6}, {5, 6}, {5, 5}, {4, 6}}, QiuPureBig},
+	}
+
+	for i, s := range specials {
+		got := BestQiu(s.tiles)
+		if got.Special != s.want {
+			t.Errorf("Expecting %v to be %s but got %s", s.tiles, s.want, got.Special)
+		}
+
+		if i > 0 && BestQiu(specials[i-1].tiles).Compare(got) <= 0 {
+			t.Errorf("Expecting %s to beat %s", specials[i-1].want, s.want)
+		}
+
+		if got.Compare(h) <= 0 {
+			t.Errorf("Expecting %s to beat a normal hand", s.want)
+		}
+	}
+}
+
+func TestQiuBetting(t *testing.T) {
+	r := &QiuRound{
+		Hands:    [][]Tile{{{6, 3}, {4, 5}, {0, 0}}, {{1, 1}, {1, 2}, {1, 3}}, {{2, 2}, {2, 4}, {2, 5}}},
+		Boneyard: []Tile{{2, 3}, {0, 1}, {0, 2}},
+		Betting:  NewBetting([]int{100, 100, 100}, 10, 10),
+		Partners: make([]int, 3),
+	}
+	r.StartRound(0)
+
+	r.Act(Bet{Kind: BetRaise, Amount: 10})
+	if err := r.Act(Bet{Kind: BetCheck}); err == nil {
+		t.Fatal("Expecting no check facing a raise")
+	}
+
+	r.Act(Bet{Kind: BetFold})
+	r.Act(Bet{Kind: BetCall})
+	if r.Street != 1 || len(r.Hands[0]) != QiuTiles || len(r.Hands[1]) != QiuFirstTiles {
+		t.Fatalf("Expecting the fourth tile for the players still in but got street %d, hands %v", r.Street, r.Hands)
+	}
+
+	if err := r.Arrange(0, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Act(Bet{Kind: BetCheck})
+	r.Act(Bet{Kind: BetCheck})
+	if !r.Over() || r.Total() != 20+10+20 {
+		t.Fatalf("Expecting a showdown for a pot of 50 but got %d", r.Total())
+	}
+
+	// player 1 splits [4,5] [0,0] for 9 and [6,3] [0,2] for 1, beating
+	// player 3's best split [2,2] [2,4] and [2,5] [0,1], 8/0
+	res := r.Result()
+	if res.Winner != 0 || res.Points[0] != 50-20 || res.Points[1] != -10 || res.Points[2] != -20 {
+		t.Fatalf("Expecting player 1 to take the pot but got %+v", res)
+	}
+}
+
+func TestQiuNoPot(t *testing.T) {
+	r := &QiuRound{
+		Hands:    [][]Tile{{{6, 3}, {4, 5}, {0, 0}}, {{1, 1}, {1, 2}, {1, 3}}},
+		Boneyard: []Tile{{2, 3}, {0, 1}},
+		Betting:  NewBetting([]int{100, 100}, 0, 10),
+		Partners: make([]int, 2),
+	}
+	r.StartRound(0)
+
+	for !r.Over() {
+		if err := r.Act(Bet{Kind: BetCheck}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res := r.Result()
+	if res.Winner != -1 || len(res.Winners) != 0 || res.Points[0] != 0 || res.Points[1] != 0 {
+		t.Fatalf("Expecting nobody to win a hand without a pot but got %+v", res)
+	}
+}
+
+func TestQiuMatch(t *testing.T) {
+	rules, _ := LookupRuleset("qiuqiu")
+	m, err := PlayMatch(7, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, s := range m.Scores {
+		total += s
+	}
+
+	if len(m.Hands) != rules.Hands || total != 0 {
+		t.Fatalf("Expecting %d hands where every chip won was lost by somebody but got %v", rules.Hands, m.Scores)
+	}
+}
@@ -0,0 +1,633 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// Set returns every tile with pips between min and max, doubles included.
+func Set(min, max int) []Tile {
+	tiles := []Tile{}
+	for i := min; i <= max; i++ {
+		for j := i; j <= max; j++ {
+			tiles = append(tiles, Tile{i, j})
+		}
+	}
+
+	return tiles
+}
+
+// Event is one turn of a round: a move, a pass or a tile drawn from the
+// boneyard, which is kept in Move.Tile.
+type Event struct {
+	Player int
+	Move   Move
+	Pass   bool
+	Draw   bool
+	// Score is what the move scored during play, the heads of Bergen
+	Score int
+}
+
+// Round is a single deal played out on a two ended line. It has no notion of
+// a screen and is shared by the terminal game and the simulator.
+type Round struct {
+	Hands    [][]Tile
+	Boneyard []Tile
+	// Line holds the played tiles from head to tail, oriented so that
+	// Line[0].A is the head and Line[len(Line)-1].B the tail
+	Line    []Tile
+	Turn    int
+	History []Event
+	// Draw makes a player who can not move draw from the boneyard instead
+	// of passing
+	Draw bool
+	// Tie and Scoring settle the result, the zero values stand for
+	// TieLowestTile and ScoreOpponents
+	Tie     TieRule
+	Scoring Scoring
+	// Matching decides which tiles fit an open end, the zero value joins
+	// equal numbers
+	Matching Matching
+}
+
+func NewRound() *Round {
+	return &Round{Turn: -1}
+}
+
+// Deal shuffles the set and deals handSize tiles to every player. The rest
+// of the set becomes the boneyard.
+func Deal(rng *rand.Rand, set []Tile, players, handSize int) *Round {
+	tiles := append([]Tile(nil), set...)
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	r := NewRound()
+	for i := 0; i < players; i++ {
+		r.AddHand(tiles[len(tiles)-handSize:])
+		tiles = tiles[:len(tiles)-handSize]
+	}
+
+	r.Boneyard = tiles
+	return r
+}
+
+// AddHand seats a new player holding the tiles and returns their index.
+func (r *Round) AddHand(tiles []Tile) int {
+	r.Hands = append(r.Hands, append([]Tile(nil), tiles...))
+	return len(r.Hands) - 1
+}
+
+func (r *Round) Started() bool {
+	return len(r.Line) > 0
+}
+
+func (r *Round) Head() int {
+	if !r.Started() {
+		return 0
+	}
+
+	return r.Line[0].A
+}
+
+func (r *Round) Tail() int {
+	if !r.Started() {
+		return 0
+	}
+
+	return r.Line[len(r.Line)-1].B
+}
+
+// Position returns what the player sees. A negative player only gets the
+// line of play.
+func (r *Round) Position(player int) Position {
+	pos := Position{
+		Started:  r.Started(),
+		Head:     r.Head(),
+		Tail:     r.Tail(),
+		Played:   append([]Tile(nil), r.Line...),
+		Matching: r.Matching,
+	}
+
+	if player < 0 || player >= len(r.Hands) {
+		return pos
+	}
+
+	pos.Hand = append([]Tile(nil), r.Hands[player]...)
+	masks := r.lackMasks()
+	for i, h := range r.Hands {
+		if i != player {
+			pos.OpponentCards = append(pos.OpponentCards, len(h))
+			if masks != nil {
+				pos.OpponentLacks = append(pos.OpponentLacks, maskNumbers(masks[i]))
+			}
+		}
+	}
+
+	return pos
+}
+
+// Place puts a tile on the line without taking it from any hand and returns
+// it oriented the way it lies on the table.
+func (r *Round) Place(t Tile, e End) Tile {
+	switch {
+	case !r.Started():
+		r.Line = append(r.Line, t)
+	case e == Tail:
+		t, _ = r.Matching.Join(t, r.Tail())
+		r.Line = append(r.Line, t)
+	default:
+		t, _ = r.Matching.Join(t, r.Head())
+		t = Tile{t.B, t.A}
+		r.Line = append([]Tile{t}, r.Line...)
+	}
+
+	return t
+}
+
+// HasMove reports whether the player holds a tile that fits the line.
+func (r *Round) HasMove(player int) bool {
+	pos := r.Position(-1)
+	for _, t := range r.Hands[player] {
+		if pos.CanPlay(t, Head) || pos.CanPlay(t, Tail) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Play makes the move for the player on turn, then passes the turn on.
+// It returns the tile as it lies on the table.
+func (r *Round) Play(m Move) (Tile, error) {
+	if r.Turn < 0 || r.Turn >= len(r.Hands) {
+		return m.Tile, fmt.Errorf("round has no player on turn")
+	}
+
+	hand := r.Hands[r.Turn]
+	idx := -1
+	for i, t := range hand {
+		if t.Same(m.Tile) {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return m.Tile, fmt.Errorf("%s is not in hand", m.Tile)
+	}
+
+	if !r.Position(-1).CanPlay(m.Tile, m.End) {
+		return m.Tile, fmt.Errorf("%s does not fit on the %s", m.Tile, m.End)
+	}
+
+	r.Hands[r.Turn] = append(hand[:idx:idx], hand[idx+1:]...)
+	placed := r.Place(m.Tile, m.End)
+	r.History = append(r.History, Event{Player: r.Turn, Move: m, Score: r.heads()})
+	if !r.Over() {
+		r.Next()
+	}
+
+	return placed, nil
+}
+
+// Next passes the turn to the next player able to move, recording a pass
+// for every player skipped on the way. In a draw game a player draws until
+// they can move and only passes once the boneyard is empty. It returns the
+// skipped players.
+func (r *Round) Next() []int {
+	skipped := []int{}
+	for range r.Hands {
+		r.Turn = (r.Turn + 1) % len(r.Hands)
+		if r.HasMove(r.Turn) || r.drawFor(r.Turn) {
+			break
+		}
+
+		r.History = append(r.History, Event{Player: r.Turn, Pass: true})
+		skipped = append(skipped, r.Turn)
+	}
+
+	return skipped
+}
+
+// drawFor draws tiles from the boneyard into the player's hand until one
+// fits and reports whether one did. It never draws in a block game.
+func (r *Round) drawFor(player int) bool {
+	for r.Draw && len(r.Boneyard) > 0 {
+		t := r.Boneyard[len(r.Boneyard)-1]
+		r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+		r.Hands[player] = append(r.Hands[player], t)
+		r.History = append(r.History, Event{Player: player, Move: Move{Tile: t}, Draw: true})
+		if r.HasMove(player) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Over reports whether a player has gone out or nobody can move, which in a
+// draw game needs the boneyard to be empty as well.
+func (r *Round) Over() bool {
+	for _, h := range r.Hands {
+		if len(h) == 0 {
+			return true
+		}
+	}
+
+	if r.Draw && len(r.Boneyard) > 0 {
+		return false
+	}
+
+	for i := range r.Hands {
+		if r.HasMove(i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Pips returns the pips left in the player's hand.
+func (r *Round) Pips(player int) int {
+	total := 0
+	for _, t := range r.Hands[player] {
+		total += t.Pips()
+	}
+
+	return total
+}
+
+// Opening is the rule deciding who plays the first tile of a round.
+type Opening string
+
+const (
+	// OpeningHighestDouble has the holder of the highest double, or of the
+	// heaviest tile when nobody holds a double, play it to open every round.
+	OpeningHighestDouble Opening = "highest-double"
+	// OpeningWinner lets the winner of the previous round open with any
+	// tile; the first round is opened by the highest double.
+	OpeningWinner Opening = "winner"
+	// OpeningBoneyard turns a tile from the boneyard that fits every hand,
+	// played for nobody, and lets the first player start.
+	OpeningBoneyard Opening = "boneyard"
+	// OpeningDoubleBlank has the holder of the lowest double, [0,0] in a
+	// fully dealt set, open the first round with it. Later rounds are opened
+	// by the previous winner with any tile, as in Gaple.
+	OpeningDoubleBlank Opening = "double-blank"
+	// OpeningDoubleSix is OpeningDoubleBlank opened with the highest double.
+	OpeningDoubleSix Opening = "double-six"
+)
+
+// DefaultOpening is the opening rule used when none is configured.
+const DefaultOpening = OpeningWinner
+
+// Open starts the round with the opening rule. lastWinner is the winner of
+// the previous round, or -1 for the first one. It reports false when the
+// round can not be opened.
+func (r *Round) Open(rule Opening, lastWinner int) bool {
+	switch {
+	case rule == OpeningBoneyard:
+		return r.OpenFromBoneyard()
+	case rule != OpeningHighestDouble && lastWinner >= 0 && lastWinner < len(r.Hands):
+		r.Turn = lastWinner
+		return true
+	}
+
+	player, tile, ok := r.Leader()
+	if !ok {
+		return false
+	}
+
+	// the leader holds the highest double whenever anybody holds one
+	if rule == OpeningDoubleBlank {
+		for i, h := range r.Hands {
+			for _, t := range h {
+				if t.IsDouble() && t.A < tile.A {
+					player, tile = i, t
+				}
+			}
+		}
+	}
+
+	r.Turn = player
+	_, err := r.Play(Move{Tile: tile, End: Head})
+	return err == nil
+}
+
+// Leader returns the player holding the highest double and that double,
+// or the heaviest tile when no hand holds a double.
+func (r *Round) Leader() (int, Tile, bool) {
+	player, best, found := -1, Tile{}, false
+	for i, h := range r.Hands {
+		for _, t := range h {
+			if !found || leads(t, best) {
+				player, best, found = i, t, true
+			}
+		}
+	}
+
+	return player, best, found
+}
+
+// leads reports whether a outranks b for the opening: doubles first, then
+// by pips, then by the higher half.
+func leads(a, b Tile) bool {
+	if a.IsDouble() != b.IsDouble() {
+		return a.IsDouble()
+	}
+
+	if a.Pips() != b.Pips() {
+		return a.Pips() > b.Pips()
+	}
+
+	return max(a.A, a.B) > max(b.A, b.B)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// OpenFromBoneyard draws tiles from the boneyard until one fits a tile in
+// every hand, places it for nobody and gives the turn to the first player.
+// It reports false when no such tile exists.
+func (r *Round) OpenFromBoneyard() bool {
+	for len(r.Boneyard) > 0 {
+		t := r.Boneyard[len(r.Boneyard)-1]
+		r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+
+		playable := 0
+		for _, h := range r.Hands {
+			for _, c := range h {
+				if r.Matching.Fits(c, t.A) || r.Matching.Fits(c, t.B) {
+					playable++
+					break
+				}
+			}
+		}
+
+		if playable == len(r.Hands) {
+			r.Place(t, Head)
+			r.Turn = -1
+			r.Next()
+			return true
+		}
+	}
+
+	return false
+}
+
+// TieRule decides a blocked round in which several players hold the same
+// lowest number of pips.
+type TieRule string
+
+const (
+	// TieLowestTile gives the round to the tied player holding the lowest
+	// single tile.
+	TieLowestTile TieRule = "lowest-tile"
+	// TieShared lets every tied player win and share the points.
+	TieShared TieRule = "shared"
+	// TieNone leaves a tied round without winner.
+	TieNone TieRule = "none"
+)
+
+// Scoring decides how many points the winners of a round get.
+type Scoring string
+
+const (
+	// ScoreOpponents gives the winners the pips left in the losers' hands.
+	ScoreOpponents Scoring = "opponents"
+	// ScoreNet is ScoreOpponents less the winner's own pips.
+	ScoreNet Scoring = "net"
+	// ScorePenalty charges every player the pips left in their own hand,
+	// the lowest total wins the match.
+	ScorePenalty Scoring = "penalty"
+	// ScoreGaple is ScoreOpponents with the Gaple bonuses for blocking and
+	// going out on a double, and penalties for blocking a lost hand and for
+	// doubles left in hand.
+	ScoreGaple Scoring = "gaple"
+	// ScoreFives scores every play that makes the open ends a multiple of
+	// five, and the opponents' pips rounded to five for going out.
+	ScoreFives Scoring = "fives"
+	// ScoreBergen scores the heads made during play and a point for winning
+	// the hand.
+	ScoreBergen Scoring = "bergen"
+)
+
+// Result is the outcome of a finished round.
+type Result struct {
+	// Winner is the first of the Winners, or -1 when nobody won
+	Winner  int
+	Winners []int
+	Blocked bool
+	Pips    []int
+	// Points holds the points every player scored in the round
+	Points []int
+	Turns  int
+	// Reason tells how the winner was decided
+	Reason string
+}
+
+// IsWinner reports whether the player is one of the winners.
+func (res Result) IsWinner(player int) bool {
+	for _, w := range res.Winners {
+		if w == player {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Result scores the round: a player who went out wins, otherwise the
+// players with the fewest pips left, ties being settled by the round's
+// TieRule. Winners share the points given by the round's Scoring.
+func (r *Round) Result() Result {
+	res := r.result()
+	switch r.Scoring {
+	case ScoreGaple:
+		r.scoreGaple(&res)
+	case ScoreBergen:
+		r.scoreBergen(&res)
+	}
+
+	return res
+}
+
+func (r *Round) result() Result {
+	res := Result{Winner: -1, Blocked: true, Turns: len(r.History), Points: make([]int, len(r.Hands))}
+	least := -1
+	for i := range r.Hands {
+		pips := r.Pips(i)
+		res.Pips = append(res.Pips, pips)
+		if len(r.Hands[i]) == 0 {
+			res.Blocked = false
+		}
+
+		if least < 0 || pips < least {
+			least = pips
+		}
+	}
+
+	// a player who went out wins alone even against a hand of [0,0]
+	tied := []int{}
+	for i, pips := range res.Pips {
+		if res.Blocked && pips == least || !res.Blocked && len(r.Hands[i]) == 0 {
+			tied = append(tied, i)
+		}
+	}
+
+	switch {
+	case !res.Blocked:
+		res.Winners = tied
+		res.Reason = fmt.Sprintf("player %d went out", tied[0]+1)
+	case len(tied) == 1:
+		res.Winners = tied
+		res.Reason = fmt.Sprintf("blocked, lowest hand with %d pips", least)
+	case r.Tie == TieShared:
+		res.Winners = tied
+		res.Reason = fmt.Sprintf("blocked, %d players tied on %d pips share the win", len(tied), least)
+	case r.Tie == TieNone:
+		res.Reason = fmt.Sprintf("blocked, %d players tied on %d pips, no winner", len(tied), least)
+	default:
+		best, lowest := tied[0], r.lowestTile(tied[0])
+		for _, p := range tied[1:] {
+			if t := r.lowestTile(p); lower(t, lowest) {
+				best, lowest = p, t
+			}
+		}
+		res.Winners = []int{best}
+		res.Reason = fmt.Sprintf("blocked, tie on %d pips settled by the lowest tile %s", least, lowest)
+	}
+
+	if r.Scoring == ScorePenalty {
+		copy(res.Points, res.Pips)
+	}
+
+	if len(res.Winners) == 0 {
+		return res
+	}
+
+	res.Winner = res.Winners[0]
+	if r.Scoring == ScorePenalty {
+		return res
+	}
+
+	losers := 0
+	for i, pips := range res.Pips {
+		if !res.IsWinner(i) {
+			losers += pips
+		}
+	}
+
+	for _, w := range res.Winners {
+		points := losers / len(res.Winners)
+		if r.Scoring == ScoreNet {
+			points -= res.Pips[w]
+		}
+
+		if points < 0 {
+			points = 0
+		}
+		res.Points[w] = points
+	}
+
+	return res
+}
+
+// lowestTile returns the player's tile with the fewest pips.
+func (r *Round) lowestTile(player int) Tile {
+	lowest := r.Hands[player][0]
+	for _, t := range r.Hands[player][1:] {
+		if lower(t, lowest) {
+			lowest = t
+		}
+	}
+
+	return lowest
+}
+
+// lower orders tiles by pips, then by their lower half.
+func lower(a, b Tile) bool {
+	if a.Pips() != b.Pips() {
+		return a.Pips() < b.Pips()
+	}
+
+	return min(a.A, a.B) < min(b.A, b.B)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Run plays the round to the end with one strategy per seat.
+func (r *Round) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		if p, ok := strategies[r.Turn].(Peeker); ok {
+			p.Peek(r)
+		}
+
+		m, ok := strategies[r.Turn].Choose(r.Position(r.Turn))
+		if !ok {
+			return Result{}, fmt.Errorf("%s found no move for player %d", strategies[r.Turn].Name(), r.Turn)
+		}
+
+		if _, err := r.Play(m); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// PlayRound deals a round from the seed and plays it out by the ruleset
+// with the named strategies, one per seat. It reports false when the deal
+// could not be opened.
+func PlayRound(seed int64, rules Ruleset, strategies []string) (Result, bool, error) {
+	rng := rand.New(rand.NewSource(seed))
+	players, err := seatStrategies(rng, rules, strategies)
+	defer closeStrategies(players)
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	return playHand(rng, rules, players, 0, -1)
+}
+
+// seatStrategies launches the named strategies, refusing them all before
+// any is launched when one can not play the ruleset.
+func seatStrategies(rng *rand.Rand, rules Ruleset, names []string) ([]Strategy, error) {
+	for _, name := range names {
+		if err := rules.CheckStrategy(name); err != nil {
+			return nil, err
+		}
+	}
+
+	players := make([]Strategy, 0, len(names))
+	for _, name := range names {
+		s, err := NewSeededStrategy(name, rng.Int63())
+		if err != nil {
+			return players, err
+		}
+		players = append(players, s)
+	}
+
+	return players, nil
+}
+
+func closeStrategies(players []Strategy) {
+	for _, s := range players {
+		if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRoundPlayAndSkip(t *testing.T) {
+	r := NewRound()
+	r.AddHand([]Tile{{6, 1}, {2, 2}})
+	r.AddHand([]Tile{{3, 3}})
+	r.AddHand([]Tile{{1, 4}, {5, 5}})
+	r.Place(Tile{6, 6}, Head)
+	r.Turn = 0
+
+	placed, err := r.Play(Move{Tile: Tile{6, 1}, End: Head})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if placed != (Tile{1, 6}) || r.Head() != 1 || r.Tail() != 6 {
+		t.Fatalf("Expecting [1,6] placed on head but got %s with ends %d/%d", placed, r.Head(), r.Tail())
+	}
+
+	// player 1 holds nothing matching 1 or 6 and is skipped
+	if r.Turn != 2 {
+		t.Fatalf("Expecting player 2 on turn but got %d", r.Turn)
+	}
+
+	if last := r.History[len(r.History)-1]; !last.Pass || last.Player != 1 {
+		t.Fatalf("Expecting a recorded pass for player 1 but got %+v", last)
+	}
+
+	if _, err := r.Play(Move{Tile: Tile{5, 5}, End: Tail}); err == nil {
+		t.Fatalf("Expecting [5,5] to be rejected on tail 6")
+	}
+
+	if _, err := r.Play(Move{Tile: Tile{1, 4}, End: Tail}); err == nil {
+		t.Fatalf("Expecting [1,4] to be rejected on tail 6")
+	}
+
+	if _, err := r.Play(Move{Tile: Tile{1, 4}, End: Head}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Over() {
+		t.Fatalf("Expecting round blocked when nobody matches 4 or 6")
+	}
+
+	res := r.Result()
+	if !res.Blocked || res.Winner != 0 || res.Pips[2] != 10 {
+		t.Fatalf("Unexpected result %+v", res)
+	}
+}
+
+func TestRunDealtRound(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 50; i++ {
+		r := Deal(rng, Set(1, 6), 3, 5)
+		if len(r.Boneyard) != 6 {
+			t.Fatalf("Expecting 6 tiles in the boneyard but got %d", len(r.Boneyard))
+		}
+
+		if !r.OpenFromBoneyard() {
+			continue
+		}
+
+		strategies := []Strategy{}
+		for _, name := range []string{"random", "heavy", "balanced"} {
+			s, _ := NewSeededStrategy(name, int64(i))
+			strategies = append(strategies, s)
+		}
+
+		res, err := r.Run(strategies)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Winner < 0 || res.Pips[res.Winner] > res.Pips[(res.Winner+1)%3] {
+			t.Fatalf("Unexpected result %+v", res)
+		}
+	}
+}
+
+func TestOpening(t *testing.T) {
+	deal := func() *Round {
+		r := NewRound()
+		r.AddHand([]Tile{{6, 5}, {2, 2}})
+		r.AddHand([]Tile{{4, 4}, {1, 3}})
+		r.AddHand([]Tile{{6, 1}, {3, 5}})
+		return r
+	}
+
+	r := deal()
+	if !r.Open(OpeningHighestDouble, 2) {
+		t.Fatalf("Expecting the round to open")
+	}
+
+	if r.Line[0] != (Tile{4, 4}) || r.History[0].Player != 1 || len(r.Hands[1]) != 1 {
+		t.Fatalf("Expecting player 1 to open with [4,4] but got %v by %+v", r.Line, r.History[0])
+	}
+
+	// the previous winner leads with any tile
+	r = deal()
+	if !r.Open(OpeningWinner, 2) || r.Started() || r.Turn != 2 {
+		t.Fatalf("Expecting player 2 to lead an empty line but got turn %d line %v", r.Turn, r.Line)
+	}
+
+	// without doubles the heaviest tile leads
+	r = NewRound()
+	r.AddHand([]Tile{{6, 1}, {2, 3}})
+	r.AddHand([]Tile{{6, 5}, {1, 3}})
+	if p, tile, _ := r.Leader(); p != 1 || tile != (Tile{6, 5}) {
+		t.Fatalf("Expecting player 1 to lead with [6,5] but got %d %s", p, tile)
+	}
+}
+
+func TestBlockedTies(t *testing.T) {
+	blocked := func(tie TieRule, scoring Scoring) Result {
+		r := NewRound()
+		r.AddHand([]Tile{{1, 4}})
+		r.AddHand([]Tile{{2, 3}})
+		r.AddHand([]Tile{{3, 3}, {2, 2}})
+		r.Place(Tile{6, 6}, Head)
+		r.Tie, r.Scoring = tie, scoring
+		return r.Result()
+	}
+
+	res := blocked(TieLowestTile, ScoreOpponents)
+	if !res.Blocked || res.Winner != 0 || len(res.Winners) != 1 {
+		t.Fatalf("Expecting [1,4] to beat [2,3] on the lowest tile but got %+v", res)
+	}
+
+	if res.Points[0] != 15 || res.Points[1] != 0 {
+		t.Fatalf("Expecting the winner to score the losers' 15 pips but got %v", res.Points)
+	}
+
+	res = blocked(TieShared, ScoreOpponents)
+	if len(res.Winners) != 2 || !res.IsWinner(1) || res.Points[0] != 5 || res.Points[1] != 5 {
+		t.Fatalf("Expecting a shared win of 10 pips but got %+v", res)
+	}
+
+	res = blocked(TieNone, ScoreOpponents)
+	if res.Winner != -1 || len(res.Winners) != 0 || res.Points[0] != 0 {
+		t.Fatalf("Expecting no winner but got %+v", res)
+	}
+
+	res = blocked(TieLowestTile, ScoreNet)
+	if res.Points[0] != 10 {
+		t.Fatalf("Expecting net score 15-5 but got %v", res.Points)
+	}
+}
+
+func TestOutBeatsDoubleBlank(t *testing.T) {
+	r := NewRound()
+	r.AddHand([]Tile{})
+	r.AddHand([]Tile{{0, 0}})
+	r.AddHand([]Tile{{3, 4}})
+	res := r.Result()
+	if len(res.Winners) != 1 || res.Winner != 0 || res.Points[0] != 7 || res.Points[1] != 0 || res.Reason != "player 1 went out" {
+		t.Fatalf("Expecting player 1 to win alone for going out but got %+v", res)
+	}
+}
+
+func TestDrawGame(t *testing.T) {
+	r := NewRound()
+	r.Draw = true
+	r.AddHand([]Tile{{6, 1}, {4, 5}})
+	r.AddHand([]Tile{{2, 2}, {3, 3}})
+	r.Boneyard = []Tile{{1, 3}, {4, 4}, {5, 5}}
+	r.Place(Tile{6, 6}, Head)
+	r.Turn = 0
+
+	if _, err := r.Play(Move{Tile: Tile{6, 1}, End: Head}); err != nil {
+		t.Fatal(err)
+	}
+
+	// player 1 can not follow [1,6] and draws [5,5] and [4,4] before [1,3] fits
+	if r.Turn != 1 || len(r.Hands[1]) != 5 || len(r.Boneyard) != 0 {
+		t.Fatalf("Expecting player 1 to draw the boneyard but got turn %d, hand %v", r.Turn, r.Hands[1])
+	}
+
+	draws := 0
+	for _, e := range r.History {
+		if e.Draw {
+			draws++
+		}
+	}
+
+	if draws != 3 {
+		t.Fatalf("Expecting 3 recorded draws but got %d", draws)
+	}
+}
+
+func TestPlayMatch(t *testing.T) {
+	rules, _ := LookupRuleset("draw")
+	m, err := PlayMatch(5, rules, []string{"balanced", "heavy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	winners := m.Winners()
+	if len(winners) == 0 || m.Scores[winners[0]] < rules.TargetScore {
+		t.Fatalf("Expecting a winner past %d points but got %v after %d hands", rules.TargetScore, m.Scores, len(m.Hands))
+	}
+}
+
+func TestGaple(t *testing.T) {
+	deal := func() *Round {
+		r := NewRound()
+		r.Scoring = ScoreGaple
+		r.AddHand([]Tile{{6, 6}, {6, 1}, {2, 0}})
+		r.AddHand([]Tile{{2, 2}, {3, 4}})
+		r.AddHand([]Tile{{0, 0}, {5, 5}})
+		return r
+	}
+
+	r := deal()
+	if !r.Open(OpeningDoubleBlank, -1) || r.Line[0] != (Tile{0, 0}) || r.History[0].Player != 2 {
+		t.Fatalf("Expecting player 3 to open with [0,0] but got %v", r.History)
+	}
+
+	r = deal()
+	if !r.Open(OpeningDoubleSix, 1) || r.Started() || r.Turn != 1 {
+		t.Fatalf("Expecting the last winner to lead any tile but got turn %d, line %v", r.Turn, r.Line)
+	}
+
+	// [6,1] leaves 1 and 6 open which nobody can follow: player 1 blocks
+	// the hand and wins it with the fewest pips
+	r = deal()
+	r.Open(OpeningDoubleSix, -1)
+	if _, err := r.Play(Move{Tile: Tile{6, 1}, End: Tail}); err != nil || !r.Over() {
+		t.Fatalf("Expecting [6,1] to block the hand, got %v", err)
+	}
+
+	res := r.Result()
+	if res.Winner != 0 || res.Points[0] != 11+10+GapleBonus {
+		t.Fatalf("Expecting player 1 to score 21 pips and the gaple bonus but got %+v", res)
+	}
+
+	if res.Points[1] != -DoublePenalty || res.Points[2] != -EndDoublePenalty-DoublePenalty {
+		t.Fatalf("Expecting penalties for the doubles left but got %v", res.Points)
+	}
+}
+
+func TestGapleMatch(t *testing.T) {
+	rules, _ := LookupRuleset("gaple")
+	m, err := PlayMatch(3, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if winners := m.Winners(); len(winners) == 0 || m.Scores[winners[0]] < rules.TargetScore {
+		t.Fatalf("Expecting a winner past %d points but got %v", rules.TargetScore, m.Scores)
+	}
+}
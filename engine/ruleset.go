@@ -0,0 +1,489 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Variant is the family of games a ruleset belongs to.
+type Variant string
+
+const (
+	// VariantLine is the two ended line of play, the zero value stands for it
+	VariantLine Variant = "line"
+	// VariantBergen is the line of play scoring "heads" whenever both ends
+	// show the same number
+	VariantBergen Variant = "bergen"
+	// VariantMatador is the line of play where touching halves add up to
+	// seven, with wild matadors
+	VariantMatador Variant = "matador"
+	// VariantMexicanTrain plays trains out of a station double
+	VariantMexicanTrain Variant = "mexican-train"
+	// VariantChickenFoot branches three ways off every double
+	VariantChickenFoot Variant = "chicken-foot"
+	// VariantAllFives plays a line with spinners and scores the open ends
+	// whenever they add up to a multiple of five, as All Fives and Sniff do
+	VariantAllFives Variant = "all-fives"
+	// VariantQiuQiu is the betting game of two pairs, nothing is laid out
+	VariantQiuQiu Variant = "qiuqiu"
+	// VariantPaiGow is Pai Gow with the Chinese set, players against a
+	// dealer
+	VariantPaiGow Variant = "pai-gow"
+	// VariantTienGow is the trick-taking game of the Chinese set
+	VariantTienGow Variant = "tien-gow"
+	// VariantTexas42 is Texas 42, bidding and tricks in partnerships
+	VariantTexas42 Variant = "texas-42"
+)
+
+// Ruleset is everything a house can vary about a game.
+type Ruleset struct {
+	Name    string  `json:"name"`
+	Variant Variant `json:"variant"`
+	// Tiles picks the family of tiles, MinPip and MaxPip then give a
+	// double-n set, e.g. 0 and 6 for a double-six set
+	Tiles    TileSet `json:"tiles"`
+	MinPip   int     `json:"min_pip"`
+	MaxPip   int     `json:"max_pip"`
+	HandSize int     `json:"hand_size"`
+	Players  int     `json:"players"`
+	Opening  Opening `json:"opening"`
+	// Draw makes a player who can not play draw from the boneyard until
+	// they can; otherwise they pass (block game)
+	Draw bool `json:"draw"`
+	// Partners seats the players across the table together, the 1st and
+	// 3rd against the 2nd and 4th
+	Partners bool    `json:"partners"`
+	Tie      TieRule `json:"tie"`
+	Scoring  Scoring `json:"scoring"`
+	// TargetScore ends a match when a player reaches it, zero plays a
+	// single hand
+	TargetScore int `json:"target_score"`
+	// Hands, when set, is the number of hands a match lasts
+	Hands int `json:"hands"`
+	// Spinner and Arms decide which doubles spin and when their arms open
+	// up in the all-fives variant
+	Spinner SpinnerRule `json:"spinner"`
+	Arms    ArmsRule    `json:"arms"`
+	// Ante is what every player puts in the pot before a hand of a wagering
+	// game, MinBet the smallest raise and Chips the stack a player sits
+	// down with
+	Ante   int `json:"ante"`
+	MinBet int `json:"min_bet"`
+	Chips  int `json:"chips"`
+}
+
+// DefaultRuleset is the game as it has always been played here: three
+// players with five tiles each from a set without blanks.
+var DefaultRuleset = Ruleset{
+	Name:     "classic",
+	MinPip:   1,
+	MaxPip:   6,
+	HandSize: 5,
+	Players:  3,
+	Opening:  DefaultOpening,
+	Tie:      TieLowestTile,
+	Scoring:  ScoreOpponents,
+}
+
+var rulesets = map[string]Ruleset{
+	"classic": DefaultRuleset,
+	"block": {
+		Name: "block", MinPip: 0, MaxPip: 6, HandSize: 7, Players: 2,
+		Opening: OpeningWinner, Tie: TieLowestTile, Scoring: ScoreOpponents, TargetScore: 100,
+	},
+	"draw": {
+		Name: "draw", MinPip: 0, MaxPip: 6, HandSize: 7, Players: 2, Draw: true,
+		Opening: OpeningWinner, Tie: TieLowestTile, Scoring: ScoreOpponents, TargetScore: 100,
+	},
+	"partnership": {
+		Name: "partnership", MinPip: 0, MaxPip: 6, HandSize: 7, Players: 4, Partners: true,
+		Opening: OpeningWinner, Tie: TieShared, Scoring: ScoreOpponents, TargetScore: 150,
+	},
+	"gaple": {
+		Name: "gaple", MinPip: 0, MaxPip: 6, HandSize: 7, Players: 4,
+		Opening: OpeningDoubleBlank, Tie: TieLowestTile, Scoring: ScoreGaple, TargetScore: 150,
+	},
+	"gaple-money": {
+		Name: "gaple-money", MinPip: 0, MaxPip: 6, HandSize: 7, Players: 4,
+		Opening: OpeningDoubleBlank, Tie: TieLowestTile, Scoring: ScoreGaple, Hands: 10,
+		Ante: 50, Chips: 1000,
+	},
+	"qiuqiu": {
+		Name: "qiuqiu", Variant: VariantQiuQiu, MinPip: 0, MaxPip: 6, HandSize: QiuTiles, Players: 4,
+		Opening: DefaultOpening, Tie: TieShared, Scoring: ScoreOpponents, Hands: 10,
+		Ante: 10, MinBet: 10, Chips: 1000,
+	},
+	"pai-gow": {
+		Name: "pai-gow", Variant: VariantPaiGow, Tiles: SetChinese, MinPip: 1, MaxPip: 6, HandSize: PaiGowTiles, Players: 4,
+		Opening: DefaultOpening, Tie: TieShared, Scoring: ScoreOpponents, Hands: 10, Ante: 10, Chips: 1000,
+	},
+	"tien-gow": {
+		Name: "tien-gow", Variant: VariantTienGow, Tiles: SetChinese, MinPip: 1, MaxPip: 6, HandSize: 8, Players: 4,
+		Opening: DefaultOpening, Tie: TieShared, Scoring: ScoreOpponents, Hands: 8,
+	},
+	"texas-42": {
+		Name: "texas-42", Variant: VariantTexas42, MinPip: 0, MaxPip: 6, HandSize: 7, Players: 4, Partners: true,
+		Opening: DefaultOpening, Tie: TieShared, Scoring: ScoreOpponents, TargetScore: 7,
+	},
+	"bergen": {
+		Name: "bergen", Variant: VariantBergen, MinPip: 0, MaxPip: 6, HandSize: 6, Players: 2, Draw: true,
+		Opening: OpeningDoubleBlank, Tie: TieLowestTile, Scoring: ScoreBergen, TargetScore: 15,
+	},
+	"matador": {
+		Name: "matador", Variant: VariantMatador, MinPip: 0, MaxPip: 6, HandSize: 7, Players: 2, Draw: true,
+		Opening: OpeningWinner, Tie: TieLowestTile, Scoring: ScoreOpponents, TargetScore: 100,
+	},
+	"mexican-train": {
+		Name: "mexican-train", Variant: VariantMexicanTrain, MinPip: 0, MaxPip: 12, HandSize: 15, Players: 4,
+		Draw: true, Opening: OpeningHighestDouble, Tie: TieShared, Scoring: ScorePenalty, Hands: 13,
+	},
+	"chicken-foot": {
+		Name: "chicken-foot", Variant: VariantChickenFoot, MinPip: 0, MaxPip: 9, HandSize: 11, Players: 4,
+		Draw: true, Opening: OpeningHighestDouble, Tie: TieShared, Scoring: ScorePenalty, Hands: 10,
+	},
+	"all-fives": {
+		Name: "all-fives", Variant: VariantAllFives, MinPip: 0, MaxPip: 6, HandSize: 7, Players: 2, Draw: true,
+		Opening: OpeningWinner, Tie: TieNone, Scoring: ScoreFives, TargetScore: 200, Spinner: SpinnerFirst, Arms: ArmsAfterSides,
+	},
+	"sniff": {
+		Name: "sniff", Variant: VariantAllFives, MinPip: 0, MaxPip: 6, HandSize: 7, Players: 2, Draw: true,
+		Opening: OpeningWinner, Tie: TieShared, Scoring: ScoreFives, TargetScore: 250, Spinner: SpinnerFirst, Arms: ArmsAfterSides,
+	},
+}
+
+// RegisterRuleset adds or replaces a named ruleset.
+func RegisterRuleset(rs Ruleset) error {
+	if err := rs.Validate(); err != nil {
+		return err
+	}
+
+	rulesets[rs.Name] = rs
+	return nil
+}
+
+// LookupRuleset returns the ruleset registered under name.
+func LookupRuleset(name string) (Ruleset, error) {
+	rs, ok := rulesets[name]
+	if !ok {
+		return Ruleset{}, fmt.Errorf("unknown ruleset %q", name)
+	}
+
+	return rs, nil
+}
+
+// Rulesets returns the names of all registered rulesets, sorted.
+func Rulesets() []string {
+	names := make([]string, 0, len(rulesets))
+	for name := range rulesets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Set returns the tiles of the ruleset's set.
+func (rs Ruleset) Set() []Tile {
+	if rs.Tiles == SetChinese {
+		return ChineseSet()
+	}
+
+	return Set(rs.MinPip, rs.MaxPip)
+}
+
+// Validate checks the ruleset describes a playable game.
+func (rs Ruleset) Validate() error {
+	switch {
+	case rs.Name == "":
+		return fmt.Errorf("ruleset needs a name")
+	case rs.MinPip < 0 || rs.MaxPip < rs.MinPip:
+		return fmt.Errorf("ruleset %s: bad set %d to %d", rs.Name, rs.MinPip, rs.MaxPip)
+	case rs.Players < 2:
+		return fmt.Errorf("ruleset %s: needs at least 2 players", rs.Name)
+	case rs.HandSize < 1 || rs.Players*rs.HandSize+rs.stationTiles() > len(rs.Set()):
+		return fmt.Errorf("ruleset %s: %d players with %d tiles do not fit a set of %d", rs.Name, rs.Players, rs.HandSize, len(rs.Set()))
+	case rs.Partners && rs.Players%2 != 0:
+		return fmt.Errorf("ruleset %s: partners need an even number of players", rs.Name)
+	}
+
+	switch rs.Variant {
+	case "", VariantLine, VariantBergen, VariantMatador, VariantMexicanTrain, VariantChickenFoot, VariantAllFives:
+	case VariantQiuQiu:
+		if rs.HandSize != QiuTiles {
+			return fmt.Errorf("ruleset %s: qiuqiu hands hold %d tiles", rs.Name, QiuTiles)
+		}
+		if rs.Chips <= 0 || rs.MinBet <= 0 {
+			return fmt.Errorf("ruleset %s: qiuqiu needs chips and a minimum bet", rs.Name)
+		}
+	case VariantPaiGow:
+		if rs.Tiles != SetChinese || rs.HandSize != PaiGowTiles {
+			return fmt.Errorf("ruleset %s: pai-gow deals %d tiles of the %s set", rs.Name, PaiGowTiles, SetChinese)
+		}
+	case VariantTienGow:
+		if rs.Tiles != SetChinese || rs.Players*rs.HandSize != len(rs.Set()) {
+			return fmt.Errorf("ruleset %s: tien-gow deals out the whole %s set", rs.Name, SetChinese)
+		}
+	case VariantTexas42:
+		if rs.MinPip != 0 || rs.MaxPip != 6 || rs.Players != 4 || rs.HandSize != 7 {
+			return fmt.Errorf("ruleset %s: texas-42 deals the double-six set to 4 players", rs.Name)
+		}
+	default:
+		return fmt.Errorf("ruleset %s: unknown variant %q", rs.Name, rs.Variant)
+	}
+
+	switch rs.Tiles {
+	case "", SetDoubleN:
+	case SetChinese:
+		if rs.Variant != VariantPaiGow && rs.Variant != VariantTienGow {
+			return fmt.Errorf("ruleset %s: the %s set is only played in %s and %s", rs.Name, SetChinese, VariantPaiGow, VariantTienGow)
+		}
+	default:
+		return fmt.Errorf("ruleset %s: unknown tile set %q", rs.Name, rs.Tiles)
+	}
+
+	if rs.Ante < 0 || rs.MinBet < 0 || rs.Chips < 0 || rs.Ante > rs.Chips {
+		return fmt.Errorf("ruleset %s: bad stakes, ante %d and min bet %d with %d chips", rs.Name, rs.Ante, rs.MinBet, rs.Chips)
+	}
+
+	switch rs.Opening {
+	case OpeningHighestDouble, OpeningWinner, OpeningBoneyard, OpeningDoubleBlank, OpeningDoubleSix:
+	default:
+		return fmt.Errorf("ruleset %s: unknown opening %q", rs.Name, rs.Opening)
+	}
+
+	switch rs.Tie {
+	case TieLowestTile, TieShared, TieNone:
+	default:
+		return fmt.Errorf("ruleset %s: unknown tie rule %q", rs.Name, rs.Tie)
+	}
+
+	switch rs.Scoring {
+	case ScoreOpponents, ScoreNet, ScorePenalty, ScoreGaple, ScoreFives, ScoreBergen:
+	default:
+		return fmt.Errorf("ruleset %s: unknown scoring %q", rs.Name, rs.Scoring)
+	}
+
+	if (rs.Variant == VariantAllFives) != (rs.Scoring == ScoreFives) {
+		return fmt.Errorf("ruleset %s: %s scoring only goes with the %s variant", rs.Name, ScoreFives, VariantAllFives)
+	}
+
+	if (rs.Variant == VariantBergen) != (rs.Scoring == ScoreBergen) {
+		return fmt.Errorf("ruleset %s: %s scoring only goes with the %s variant", rs.Name, ScoreBergen, VariantBergen)
+	}
+
+	switch rs.Spinner {
+	case "", SpinnerFirst, SpinnerEvery, SpinnerNone:
+	default:
+		return fmt.Errorf("ruleset %s: unknown spinner rule %q", rs.Name, rs.Spinner)
+	}
+
+	switch rs.Arms {
+	case "", ArmsAfterSides, ArmsImmediate:
+	default:
+		return fmt.Errorf("ruleset %s: unknown arms rule %q", rs.Name, rs.Arms)
+	}
+
+	return nil
+}
+
+// Team returns the side the seat plays for: its partnership when the
+// ruleset seats partners, otherwise the seat alone.
+func (rs Ruleset) Team(seat int) int {
+	if rs.Partners {
+		return seat % 2
+	}
+
+	return seat
+}
+
+// CheckStrategy returns why the named strategy can not play the ruleset,
+// or nil. External bots only speak the protocol of the two-ended line, so
+// they have no seat at trains, spinners or the table games.
+func (rs Ruleset) CheckStrategy(name string) error {
+	if !strings.HasPrefix(name, ExternalPrefix) {
+		return nil
+	}
+
+	switch rs.Variant {
+	case "", VariantLine, VariantBergen, VariantMatador:
+		return nil
+	}
+
+	return fmt.Errorf("ruleset %s: external bots only play line games, not %s", rs.Name, rs.Variant)
+}
+
+func (rs Ruleset) stationTiles() int {
+	if rs.Variant == VariantMexicanTrain || rs.Variant == VariantChickenFoot {
+		return 1
+	}
+
+	return 0
+}
+
+// Station returns the double a train or chicken foot hand starts from: the highest
+// double for the first hand, one lower for every following hand.
+func (rs Ruleset) Station(hand int) Tile {
+	pip := rs.MaxPip - hand%(rs.MaxPip-rs.MinPip+1)
+	return Tile{pip, pip}
+}
+
+// Matching returns how tiles join on the line of play in the ruleset's
+// variant: to seven in Matador, equal numbers everywhere else.
+func (rs Ruleset) Matching() Matching {
+	if rs.Variant == VariantMatador {
+		return MatchSeven
+	}
+
+	return MatchEqual
+}
+
+// NewRound returns an empty round playing by the ruleset.
+func (rs Ruleset) NewRound() *Round {
+	r := NewRound()
+	r.Draw, r.Tie, r.Scoring, r.Matching = rs.Draw, rs.Tie, rs.Scoring, rs.Matching()
+	return r
+}
+
+// Deal shuffles the ruleset's set and deals a round to the players.
+func (rs Ruleset) Deal(rng *rand.Rand, players int) *Round {
+	r := Deal(rng, rs.Set(), players, rs.HandSize)
+	r.Draw, r.Tie, r.Scoring, r.Matching = rs.Draw, rs.Tie, rs.Scoring, rs.Matching()
+	return r
+}
+
+// LoadRuleset reads a ruleset from a .json, .yaml/.yml or .toml file. Keys
+// missing from the file keep the values of DefaultRuleset. YAML and TOML
+// files are read as flat "key: value" and "key = value" lists.
+func LoadRuleset(path string) (Ruleset, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Ruleset{}, err
+	}
+
+	rs := DefaultRuleset
+	rs.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		data, err = flatToJSON(data, ":")
+	case ".toml":
+		data, err = flatToJSON(data, "=")
+	default:
+		return Ruleset{}, fmt.Errorf("%s: unknown ruleset format", path)
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, &rs)
+	}
+
+	if err == nil {
+		err = rs.Validate()
+	}
+
+	if err != nil {
+		return Ruleset{}, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return rs, nil
+}
+
+// LoadRulesets registers every ruleset file in the directory and returns
+// their names. A missing directory holds no rulesets.
+func LoadRulesets(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, f := range files {
+		switch strings.ToLower(filepath.Ext(f.Name())) {
+		case ".json", ".yaml", ".yml", ".toml":
+		default:
+			continue
+		}
+
+		rs, err := LoadRuleset(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return names, err
+		}
+
+		rulesets[rs.Name] = rs
+		names = append(names, rs.Name)
+	}
+
+	return names, nil
+}
+
+// FindRuleset registers the rulesets found in dir and returns the one
+// called name. A name with a file extension is read from that file instead.
+func FindRuleset(dir, name string) (Ruleset, error) {
+	if filepath.Ext(name) != "" {
+		return LoadRuleset(name)
+	}
+
+	if dir != "" {
+		if _, err := LoadRulesets(dir); err != nil {
+			return Ruleset{}, err
+		}
+	}
+
+	rs, err := LookupRuleset(name)
+	if err != nil {
+		return rs, fmt.Errorf("%v, known rulesets: %s", err, strings.Join(Rulesets(), ", "))
+	}
+
+	return rs, nil
+}
+
+// flatToJSON turns lines of key/value pairs separated by sep into a JSON
+// object. Comments start with '#', values may be quoted, numbers and
+// booleans keep their type.
+func flatToJSON(data []byte, sep string) ([]byte, error) {
+	values := map[string]interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(text, "#"); i >= 0 && !strings.Contains(text[:i], "\"") {
+			text = strings.TrimSpace(text[:i])
+		}
+
+		if text == "" || text == "---" || strings.HasPrefix(text, "[") {
+			continue
+		}
+
+		parts := strings.SplitN(text, sep, 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected key%svalue", line, sep)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		raw := strings.TrimSpace(parts[1])
+		if unquoted, err := strconv.Unquote(raw); err == nil {
+			values[key] = unquoted
+		} else if n, err := strconv.Atoi(raw); err == nil {
+			values[key] = n
+		} else if b, err := strconv.ParseBool(raw); err == nil {
+			values[key] = b
+		} else {
+			values[key] = strings.Trim(raw, "'")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(values)
+}
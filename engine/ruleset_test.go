@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRuleset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"json.json": `{"name": "house", "min_pip": 0, "hand_size": 7, "players": 2, "draw": true, "target_score": 61}`,
+		"yaml.yaml": "# house rules\nname: house\nmin_pip: 0\nhand_size: 7\nplayers: 2\ndraw: true\ntarget_score: 61\n",
+		"toml.toml": "name = \"house\"\nmin_pip = 0\nhand_size = 7 # a full hand\nplayers = 2\ndraw = true\ntarget_score = 61\n",
+	}
+
+	for file, content := range files {
+		path := filepath.Join(dir, file)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		rs, err := LoadRuleset(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := DefaultRuleset
+		want.Name, want.MinPip, want.HandSize, want.Players, want.Draw, want.TargetScore = "house", 0, 7, 2, true, 61
+		if rs != want {
+			t.Errorf("%s: expecting %+v but got %+v", file, want, rs)
+		}
+	}
+
+	if _, err := LoadRulesets(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs, err := FindRuleset("", "house"); err != nil || len(rs.Set()) != 28 {
+		t.Fatalf("Expecting the house ruleset registered with a double-six set but got %+v, %v", rs, err)
+	}
+}
+
+func TestValidateRuleset(t *testing.T) {
+	rs := DefaultRuleset
+	rs.HandSize = 8
+	if rs.Validate() == nil {
+		t.Errorf("Expecting 3 hands of 8 to not fit 21 tiles")
+	}
+
+	rs = DefaultRuleset
+	rs.Opening = "coin-toss"
+	if rs.Validate() == nil {
+		t.Errorf("Expecting an unknown opening to be rejected")
+	}
+
+	rs = DefaultRuleset
+	rs.Scoring = ScoreFives
+	if rs.Validate() == nil {
+		t.Errorf("Expecting fives scoring to need the all-fives variant")
+	}
+
+	rs = DefaultRuleset
+	rs.Variant = VariantBergen
+	if rs.Validate() == nil {
+		t.Errorf("Expecting the bergen variant to need bergen scoring")
+	}
+
+	rs = DefaultRuleset
+	rs.Tiles = SetChinese
+	if rs.Validate() == nil {
+		t.Errorf("Expecting the chinese set to need the pai-gow variant")
+	}
+}
@@ -0,0 +1,304 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// SolitaireKind is a single player game laid out from a double-six set.
+type SolitaireKind string
+
+const (
+	// Luzon lays 8 columns of 3 tiles and a reserve of 4. The bottom tile
+	// of every column and the reserve are free; free tiles are removed in
+	// pairs of 12 pips, [6,6] on its own.
+	Luzon SolitaireKind = "luzon"
+	// FiveUp lays 4 columns of 7 tiles whose bottom tiles are played on a
+	// two ended line. Ends adding up to a multiple of five score.
+	FiveUp SolitaireKind = "five-up"
+	// Jubilee lays a pyramid of 7 rows, a tile being free once both tiles
+	// below it are gone. Free tiles are removed like in Luzon.
+	Jubilee SolitaireKind = "jubilee"
+)
+
+// SolitairePips is what a pair of tiles removed in Luzon and Jubilee
+// adds up to.
+const SolitairePips = 12
+
+// SolitaireKinds returns every solitaire game.
+func SolitaireKinds() []SolitaireKind {
+	return []SolitaireKind{Luzon, FiveUp, Jubilee}
+}
+
+// SolitaireSlot is a place of the layout, Row and Col telling where it is
+// drawn. Covers holds the slots lying on top of it.
+type SolitaireSlot struct {
+	Tile     Tile
+	Row, Col int
+	Covers   []int
+	Gone     bool
+}
+
+// SolitaireMove removes the tiles of the slots, or in Five-Up plays the
+// tile of the only slot on the end.
+type SolitaireMove struct {
+	Slots []int
+	End   End
+}
+
+// Solitaire is a deal of a solitaire game. It has no notion of a screen.
+type Solitaire struct {
+	Kind  SolitaireKind
+	Seed  int64
+	Slots []SolitaireSlot
+	// Line is the line of play of Five-Up, nobody holds a hand
+	Line  *Round
+	Score int
+	Moves int
+}
+
+// DailySeed returns the seed everybody plays on the day.
+func DailySeed(day time.Time) int64 {
+	y, m, d := day.Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// SolitaireTiles returns the double-six set shuffled by the seed, the way
+// a Deck shuffled with the same seed holds it.
+func SolitaireTiles(seed int64) []Tile {
+	tiles := Set(0, 6)
+	rand.New(rand.NewSource(seed)).Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	return tiles
+}
+
+// DealSolitaire deals the game from the seed, the same seed always giving
+// the same layout.
+func DealSolitaire(kind SolitaireKind, seed int64) (*Solitaire, error) {
+	s, err := NewSolitaire(kind, SolitaireTiles(seed))
+	if s != nil {
+		s.Seed = seed
+	}
+
+	return s, err
+}
+
+// NewSolitaire lays the 28 tiles out for the game, the last tile going to
+// the first slot.
+func NewSolitaire(kind SolitaireKind, tiles []Tile) (*Solitaire, error) {
+	if len(tiles) != 28 {
+		return nil, fmt.Errorf("solitaire needs the 28 tiles of a double-six set, got %d", len(tiles))
+	}
+
+	s := &Solitaire{Kind: kind, Line: NewRound()}
+	add := func(row, col int) int {
+		s.Slots = append(s.Slots, SolitaireSlot{Tile: tiles[len(tiles)-1-len(s.Slots)], Row: row, Col: col})
+		return len(s.Slots) - 1
+	}
+
+	switch kind {
+	case Luzon:
+		s.columns(add, 8, 3)
+		for col := 0; col < 4; col++ {
+			add(3, col)
+		}
+	case FiveUp:
+		s.columns(add, 4, 7)
+	case Jubilee:
+		for row := 0; row < 7; row++ {
+			for col := 0; col <= row; col++ {
+				add(row, col)
+			}
+		}
+
+		// the tiles of a row lie on the two below them
+		for i := range s.Slots {
+			row, col := s.Slots[i].Row, s.Slots[i].Col
+			if row < 6 {
+				below := (row+1)*(row+2)/2 + col
+				s.Slots[i].Covers = []int{below, below + 1}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown solitaire %q", kind)
+	}
+
+	return s, nil
+}
+
+// columns lays columns of tiles, each lying on the one above it.
+func (s *Solitaire) columns(add func(row, col int) int, cols, rows int) {
+	for col := 0; col < cols; col++ {
+		for row := 0; row < rows; row++ {
+			i := add(row, col)
+			if row > 0 {
+				s.Slots[i-1].Covers = []int{i}
+			}
+		}
+	}
+}
+
+// Free reports whether the slot's tile may be taken: it is still there and
+// nothing lies on it.
+func (s *Solitaire) Free(slot int) bool {
+	if slot < 0 || slot >= len(s.Slots) || s.Slots[slot].Gone {
+		return false
+	}
+
+	for _, c := range s.Slots[slot].Covers {
+		if !s.Slots[c].Gone {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Left returns how many tiles are still laid out.
+func (s *Solitaire) Left() int {
+	left := 0
+	for _, slot := range s.Slots {
+		if !slot.Gone {
+			left++
+		}
+	}
+
+	return left
+}
+
+// Won reports whether every tile is cleared.
+func (s *Solitaire) Won() bool {
+	return s.Left() == 0
+}
+
+// Stuck reports whether the game is lost: tiles are left and none can be
+// moved.
+func (s *Solitaire) Stuck() bool {
+	return !s.Won() && len(s.Legal()) == 0
+}
+
+// Legal lists every move: the free tiles or pairs of 12 pips, or in
+// Five-Up the free tiles fitting an end of the line.
+func (s *Solitaire) Legal() []SolitaireMove {
+	free := []int{}
+	for i := range s.Slots {
+		if s.Free(i) {
+			free = append(free, i)
+		}
+	}
+
+	moves := []SolitaireMove{}
+	if s.Kind == FiveUp {
+		pos := s.Line.Position(-1)
+		for _, i := range free {
+			pos.Hand = append(pos.Hand[:0], s.Slots[i].Tile)
+			for _, m := range pos.LegalMoves() {
+				moves = append(moves, SolitaireMove{Slots: []int{i}, End: m.End})
+			}
+		}
+		return moves
+	}
+
+	for j, a := range free {
+		if s.Slots[a].Tile.Pips() == SolitairePips {
+			moves = append(moves, SolitaireMove{Slots: []int{a}})
+		}
+
+		for _, b := range free[j+1:] {
+			if s.Slots[a].Tile.Pips()+s.Slots[b].Tile.Pips() == SolitairePips {
+				moves = append(moves, SolitaireMove{Slots: []int{a, b}})
+			}
+		}
+	}
+
+	return moves
+}
+
+// Play makes the move.
+func (s *Solitaire) Play(m SolitaireMove) error {
+	for i, slot := range m.Slots {
+		if !s.Free(slot) {
+			return fmt.Errorf("tile %d is not free", slot)
+		}
+
+		for _, other := range m.Slots[:i] {
+			if other == slot {
+				return fmt.Errorf("tile %d is picked twice", slot)
+			}
+		}
+	}
+
+	if s.Kind == FiveUp {
+		return s.playLine(m)
+	}
+
+	pips := 0
+	for _, slot := range m.Slots {
+		pips += s.Slots[slot].Tile.Pips()
+	}
+
+	if len(m.Slots) == 0 || len(m.Slots) > 2 || pips != SolitairePips {
+		return fmt.Errorf("only one or two tiles of %d pips are removed", SolitairePips)
+	}
+
+	for _, slot := range m.Slots {
+		s.Slots[slot].Gone = true
+	}
+
+	s.Moves++
+	return nil
+}
+
+// playLine plays a tile on the line of Five-Up and scores the ends when
+// they add up to a multiple of five.
+func (s *Solitaire) playLine(m SolitaireMove) error {
+	if len(m.Slots) != 1 {
+		return fmt.Errorf("one tile at a time goes on the line")
+	}
+
+	slot := &s.Slots[m.Slots[0]]
+	if !s.Line.Position(-1).CanPlay(slot.Tile, m.End) {
+		return fmt.Errorf("%s does not fit on the %s", slot.Tile, m.End)
+	}
+
+	s.Line.Place(slot.Tile, m.End)
+	slot.Gone = true
+	s.Moves++
+	if ends := s.Line.Head() + s.Line.Tail(); len(s.Line.Line) > 1 && ends%5 == 0 {
+		s.Score += ends
+	}
+
+	return nil
+}
+
+// Hint returns a move worth making, preferring the ones that free the
+// most tiles, and false when there is none.
+func (s *Solitaire) Hint() (SolitaireMove, bool) {
+	moves := s.Legal()
+	if len(moves) == 0 {
+		return SolitaireMove{}, false
+	}
+
+	best, freed := moves[0], -1
+	for _, m := range moves {
+		n := 0
+		for _, slot := range m.Slots {
+			for i := range s.Slots {
+				for _, c := range s.Slots[i].Covers {
+					if c == slot {
+						n++
+					}
+				}
+			}
+		}
+
+		if n > freed {
+			best, freed = m, n
+		}
+	}
+
+	return best, true
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDealSolitaire(t *testing.T) {
+	free := map[SolitaireKind]int{Luzon: 12, FiveUp: 4, Jubilee: 7}
+	for _, kind := range SolitaireKinds() {
+		a, err := DealSolitaire(kind, 42)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, _ := DealSolitaire(kind, 42)
+		if !reflect.DeepEqual(a.Slots, b.Slots) || len(a.Slots) != 28 {
+			t.Fatalf("Expecting the same 28 tiles laid out for the same seed of %s", kind)
+		}
+
+		n := 0
+		for i := range a.Slots {
+			if a.Free(i) {
+				n++
+			}
+		}
+
+		if n != free[kind] {
+			t.Errorf("Expecting %d free tiles in %s but got %d", free[kind], kind, n)
+		}
+	}
+
+	if _, err := DealSolitaire("klondike", 1); err == nil {
+		t.Fatal("Expecting unknown solitaires to be rejected")
+	}
+
+	if seed := DailySeed(time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC)); seed != 20261019 {
+		t.Fatalf("Expecting the daily seed to be the date but got %d", seed)
+	}
+}
+
+func TestSolitairePlay(t *testing.T) {
+	tiles := Set(0, 6)
+	s, _ := NewSolitaire(Luzon, tiles)
+	// the last tiles go first: columns of [6,6] [5,6] [5,5], then [4,6]...
+	if s.Slots[0].Tile != (Tile{6, 6}) || !s.Free(2) || s.Free(1) {
+		t.Fatalf("Expecting the bottom of the first column free but got %+v", s.Slots[:3])
+	}
+
+	if err := s.Play(SolitaireMove{Slots: []int{2, 5}}); err == nil {
+		t.Fatal("Expecting [5,5] and [4,4] to not make 12")
+	}
+
+	for !s.Won() && !s.Stuck() {
+		m, _ := s.Hint()
+		if err := s.Play(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.Won() != (s.Left() == 0) || s.Moves == 0 {
+		t.Fatalf("Expecting a finished deal but got %d tiles left after %d moves", s.Left(), s.Moves)
+	}
+
+	five, _ := NewSolitaire(FiveUp, tiles)
+	// [3,6], then [2,3] leaves 2 and 6 open, [2,4] 4 and 6
+	five.Play(SolitaireMove{Slots: []int{6}})
+	five.Play(SolitaireMove{Slots: []int{13}, End: Head})
+	if err := five.Play(SolitaireMove{Slots: []int{12}, End: Head}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(five.Line.Line) != 3 || five.Score != 10 {
+		t.Fatalf("Expecting only the ends adding up to 10 scored but got %d", five.Score)
+	}
+}
@@ -0,0 +1,478 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Clone returns a copy of the round sharing nothing with it.
+func (r *Round) Clone() *Round {
+	c := *r
+	c.Hands = make([][]Tile, len(r.Hands))
+	for i, h := range r.Hands {
+		c.Hands[i] = append([]Tile(nil), h...)
+	}
+
+	c.Boneyard = append([]Tile(nil), r.Boneyard...)
+	c.Line = append([]Tile(nil), r.Line...)
+	c.History = append([]Event(nil), r.History...)
+	return &c
+}
+
+// Wins reports whether the hero wins the round from here, playing their
+// best against anything the others do. Every hand is known and nothing is
+// drawn, so it only holds for block games.
+func Wins(r *Round, hero int) bool {
+	if r.Over() {
+		return r.Result().IsWinner(hero)
+	}
+
+	moves := r.Position(r.Turn).LegalMoves()
+	for _, m := range moves {
+		next := r.Clone()
+		next.Play(m)
+		won := Wins(next, hero)
+		if won && r.Turn == hero {
+			return true
+		}
+		if !won && r.Turn != hero {
+			return false
+		}
+	}
+
+	return r.Turn != hero
+}
+
+// WinningMoves returns the moves of the player on turn after which the
+// hero still wins.
+func WinningMoves(r *Round, hero int) []Move {
+	moves := []Move{}
+	for _, m := range r.Position(r.Turn).LegalMoves() {
+		next := r.Clone()
+		next.Play(m)
+		if Wins(next, hero) {
+			moves = append(moves, m)
+		}
+	}
+
+	return moves
+}
+
+// Payoffs returns what every player makes of the finished round: the points
+// they score less the pips left in their hand, so that a player who can not
+// win still plays to keep their hand light. Penalty scoring counts the
+// points against the player.
+func Payoffs(r *Round) []int {
+	res := r.Result()
+	payoffs := make([]int, len(r.Hands))
+	for i := range payoffs {
+		if r.Scoring == ScorePenalty {
+			payoffs[i] = -res.Points[i]
+		} else {
+			payoffs[i] = res.Points[i] - res.Pips[i]
+		}
+	}
+
+	return payoffs
+}
+
+// SolverPlayers and SolverMaxPip bound the rounds the solver takes on.
+const (
+	SolverPlayers = 4
+	SolverMaxPip  = 14
+)
+
+// Solution is the outcome of a round under best play by everybody.
+type Solution struct {
+	// Payoffs holds what every player ends up with, see Payoffs
+	Payoffs []int
+	// Line is the best play of every player from the position to the end,
+	// Line[0] being the best move of the player on turn
+	Line   []Move
+	Result Result
+}
+
+// MoveValue is a legal move with the payoffs it leads to under best play.
+type MoveValue struct {
+	Move    Move
+	Payoffs []int
+}
+
+// Solver searches block games exhaustively, every hand being known. Two
+// players search the difference of their payoffs with alpha-beta, three or
+// four players search with max^n, everybody making the most of their own
+// payoff. Positions already searched are kept in a transposition table,
+// so a Solver is best reused over the moves of a round.
+type Solver struct {
+	// Nodes counts the positions searched, the ones found in the table not
+	// included
+	Nodes int
+	table map[solverKey]solverEntry
+}
+
+// solverKey is a position: the hands as sets of tile indexes, the open ends
+// and the player on turn. What follows does not depend on the line played so
+// far, but what that line scored does, the heads of Bergen, so the table
+// keeps the payoffs still to come, see banked.
+type solverKey struct {
+	hands      [SolverPlayers][2]uint64
+	head, tail int8
+	turn       int8
+}
+
+// solverEntry is a searched position. With two players the payoffs may
+// only bound its value, see bound. The payoffs leave out what was banked
+// before the position.
+type solverEntry struct {
+	payoffs []int
+	move    Move
+	bound   int8
+}
+
+const (
+	exact int8 = iota
+	lowerBound
+	upperBound
+)
+
+// infinity is beyond any difference of payoffs.
+const infinity = 1 << 30
+
+func NewSolver() *Solver {
+	return &Solver{table: map[solverKey]solverEntry{}}
+}
+
+// Reset empties the transposition table.
+func (s *Solver) Reset() {
+	s.table = map[solverKey]solverEntry{}
+}
+
+// Check returns why the round can not be solved, or nil.
+func (s *Solver) Check(r *Round) error {
+	switch {
+	case r.Draw && len(r.Boneyard) > 0:
+		return errors.New("solver needs every tile known, the boneyard of a draw game is not")
+	case len(r.Hands) < 2 || len(r.Hands) > SolverPlayers:
+		return fmt.Errorf("solver plays 2 to %d players, not %d", SolverPlayers, len(r.Hands))
+	case r.Turn < 0 || r.Turn >= len(r.Hands):
+		return errors.New("round has no player on turn")
+	}
+
+	for _, h := range r.Hands {
+		for _, t := range h {
+			if t.A < 0 || t.B < 0 || t.A > SolverMaxPip || t.B > SolverMaxPip {
+				return fmt.Errorf("solver takes tiles up to %d pips, not %s", SolverMaxPip, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Solve returns the outcome of the round under best play and the line
+// leading to it.
+func (s *Solver) Solve(r *Round) (Solution, error) {
+	if r.Over() {
+		return Solution{Payoffs: Payoffs(r), Result: r.Result()}, nil
+	}
+
+	if err := s.Check(r); err != nil {
+		return Solution{}, err
+	}
+
+	sol := Solution{}
+	c := r.Clone()
+	for !c.Over() {
+		payoffs, m := s.search(c, -infinity, infinity)
+		if len(sol.Line) == 0 {
+			sol.Payoffs = payoffs
+		}
+
+		sol.Line = append(sol.Line, m)
+		c.Play(m)
+	}
+
+	sol.Result = c.Result()
+	return sol, nil
+}
+
+// Best returns the best move of the player on turn and the payoffs it
+// leads to.
+func (s *Solver) Best(r *Round) (Move, []int, error) {
+	if err := s.Check(r); err != nil {
+		return Move{}, nil, err
+	}
+
+	if r.Over() {
+		return Move{}, Payoffs(r), errors.New("round is over")
+	}
+
+	payoffs, m := s.search(r, -infinity, infinity)
+	return m, payoffs, nil
+}
+
+// Evaluate returns every legal move of the player on turn with the payoffs
+// it leads to, best first for that player.
+func (s *Solver) Evaluate(r *Round) ([]MoveValue, error) {
+	if err := s.Check(r); err != nil {
+		return nil, err
+	}
+
+	values := []MoveValue{}
+	for _, m := range r.Position(r.Turn).LegalMoves() {
+		next := r.Clone()
+		next.Play(m)
+		payoffs := Payoffs(next)
+		if !next.Over() {
+			payoffs, _ = s.search(next, -infinity, infinity)
+		}
+
+		// insertion keeps the order stable among equal moves
+		i := len(values)
+		for i > 0 && s.better(r, payoffs, values[i-1].Payoffs) {
+			i--
+		}
+		values = append(values, MoveValue{})
+		copy(values[i+1:], values[i:])
+		values[i] = MoveValue{Move: m, Payoffs: payoffs}
+	}
+
+	return values, nil
+}
+
+// value is what the payoffs are worth to the player on turn: with two
+// players their payoff less the opponent's, otherwise their own.
+func (s *Solver) value(r *Round, payoffs []int) int {
+	if len(payoffs) == 2 {
+		return payoffs[r.Turn] - payoffs[1-r.Turn]
+	}
+
+	return payoffs[r.Turn]
+}
+
+// better reports whether the player on turn prefers payoffs a to b, ties
+// between more than two players going to the payoffs leaving the others
+// less.
+func (s *Solver) better(r *Round, a, b []int) bool {
+	if va, vb := s.value(r, a), s.value(r, b); va != vb || len(a) == 2 {
+		return va > vb
+	}
+
+	return sum(a) < sum(b)
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+
+	return total
+}
+
+// banked returns the points every player scored during play so far, nil
+// when the scoring counts nothing before the round is over.
+func (s *Solver) banked(r *Round) []int {
+	if r.Scoring != ScoreBergen {
+		return nil
+	}
+
+	points := make([]int, len(r.Hands))
+	for _, e := range r.History {
+		points[e.Player] += e.Score
+	}
+
+	return points
+}
+
+// shift returns the payoffs with every player's points added, negated
+// when sign is -1.
+func shift(payoffs, points []int, sign int) []int {
+	if points == nil {
+		return payoffs
+	}
+
+	shifted := make([]int, len(payoffs))
+	for i := range payoffs {
+		shifted[i] = payoffs[i] + sign*points[i]
+	}
+
+	return shifted
+}
+
+func (s *Solver) key(r *Round) solverKey {
+	k := solverKey{head: -1, tail: -1, turn: int8(r.Turn)}
+	if r.Started() {
+		k.head, k.tail = int8(r.Head()), int8(r.Tail())
+	}
+
+	for i, h := range r.Hands {
+		for _, t := range h {
+			hi, lo := max(t.A, t.B), min(t.A, t.B)
+			idx := hi*(hi+1)/2 + lo
+			k.hands[i][idx/64] |= 1 << uint(idx%64)
+		}
+	}
+
+	return k
+}
+
+// search returns the payoffs of the position under best play and the move
+// of the player on turn getting there. alpha and beta bound the value of
+// a two player round, player 1's payoff taken from player 0's.
+func (s *Solver) search(r *Round, alpha, beta int) ([]int, Move) {
+	key, banked := s.key(r), s.banked(r)
+	e, ok := s.table[key]
+	if ok {
+		payoffs := shift(e.payoffs, banked, 1)
+		v := payoffs[0] - payoffs[1]
+		switch {
+		case e.bound == exact,
+			e.bound == lowerBound && v >= beta,
+			e.bound == upperBound && v <= alpha:
+			return payoffs, e.move
+		}
+	}
+
+	s.Nodes++
+	moves := r.Position(r.Turn).LegalMoves()
+	if ok {
+		// the move found before is likely best again, try it first
+		for i, m := range moves {
+			if m == e.move {
+				moves[0], moves[i] = moves[i], moves[0]
+				break
+			}
+		}
+	}
+
+	two := len(r.Hands) == 2
+	low, high := alpha, beta
+	var best []int
+	var bestMove Move
+	for _, m := range moves {
+		next := r.Clone()
+		next.Play(m)
+		var payoffs []int
+		if next.Over() {
+			payoffs = Payoffs(next)
+		} else {
+			payoffs, _ = s.search(next, alpha, beta)
+		}
+
+		if best == nil || s.better(r, payoffs, best) {
+			best, bestMove = payoffs, m
+		}
+
+		if !two {
+			continue
+		}
+
+		v := best[0] - best[1]
+		if r.Turn == 0 {
+			alpha = max(alpha, v)
+		} else {
+			beta = min(beta, v)
+		}
+
+		if alpha >= beta {
+			break
+		}
+	}
+
+	bound := exact
+	if two {
+		switch v := best[0] - best[1]; {
+		case v <= low:
+			bound = upperBound
+		case v >= high:
+			bound = lowerBound
+		}
+	}
+
+	s.table[key] = solverEntry{payoffs: shift(best, banked, -1), move: bestMove, bound: bound}
+	return best, bestMove
+}
+
+// Peeker is a strategy that looks at the whole round, every hand included,
+// before it chooses. Round.Run and the table show it the round before each
+// of its moves.
+type Peeker interface {
+	Peek(r *Round)
+}
+
+// Defender plays against the hero of a puzzle. It sees every hand of the
+// round and picks a move that stops the hero from winning whenever there
+// is one. It thinks on the Round it was given or last peeked at, so that
+// has to be a copy nobody else plays on.
+type Defender struct {
+	Round *Round
+	Hero  int
+}
+
+func (d *Defender) Name() string { return "defender" }
+
+func (d *Defender) Peek(r *Round) { d.Round = r }
+
+func (d *Defender) Choose(pos Position) (Move, bool) {
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	for _, m := range moves {
+		next := d.Round.Clone()
+		next.Play(m)
+		if !Wins(next, d.Hero) {
+			return m, true
+		}
+	}
+
+	return moves[0], true
+}
+
+// oracleStrategy cheats: it peeks at every hand and plays the solver's best
+// move. Measured against it, the fair strategies see how far they are from
+// perfect play. Where the solver can not see everything, in draw games with
+// a boneyard, it plays balanced.
+type oracleStrategy struct {
+	round  *Round
+	solver *Solver
+}
+
+func (s *oracleStrategy) Name() string { return "oracle" }
+
+func (s *oracleStrategy) Peek(r *Round) {
+	// a new deal shares no positions with the last one, while the same
+	// deal comes back as a copy every move
+	if s.round == nil || !continues(s.round, r) {
+		s.solver.Reset()
+	}
+	s.round = r
+}
+
+// continues reports whether r carries on the deal of last, its history
+// going on from last's.
+func continues(last, r *Round) bool {
+	if len(r.Hands) != len(last.Hands) || len(r.History) < len(last.History) {
+		return false
+	}
+
+	for i, e := range last.History {
+		if r.History[i] != e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *oracleStrategy) Choose(pos Position) (Move, bool) {
+	if s.round != nil {
+		if m, _, err := s.solver.Best(s.round); err == nil {
+			return m, true
+		}
+	}
+
+	return balancedStrategy{}.Choose(pos)
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// minimax is the plain search the solver has to agree with: player 0 makes
+// the most of the difference of the payoffs, player 1 the least.
+func minimax(r *Round) int {
+	if r.Over() {
+		p := Payoffs(r)
+		return p[0] - p[1]
+	}
+
+	best := 0
+	for i, m := range r.Position(r.Turn).LegalMoves() {
+		next := r.Clone()
+		next.Play(m)
+		v := minimax(next)
+		if i == 0 || r.Turn == 0 && v > best || r.Turn == 1 && v < best {
+			best = v
+		}
+	}
+
+	return best
+}
+
+func TestSolver(t *testing.T) {
+	// Bergen scores heads along the line, so positions reached by
+	// different lines are worth different payoffs
+	for _, name := range []string{"block", "bergen"} {
+		rules, _ := LookupRuleset(name)
+		rules.HandSize, rules.Draw = 6, false
+		s := NewSolver()
+		for seed := int64(0); seed < 50; seed++ {
+			r := rules.Deal(rand.New(rand.NewSource(seed)), 2)
+			r.Open(OpeningWinner, -1)
+			if r.Over() {
+				continue
+			}
+
+			sol, err := s.Solve(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := minimax(r)
+			if sol.Payoffs[0]-sol.Payoffs[1] != v {
+				t.Fatalf("Expecting %s deal %d worth %d but solved %v", name, seed, v, sol.Payoffs)
+			}
+
+			// playing the line out gives the payoffs
+			c := r.Clone()
+			for _, m := range sol.Line {
+				if _, err := c.Play(m); err != nil {
+					t.Fatalf("Expecting a legal line but got %v", err)
+				}
+			}
+
+			if p := Payoffs(c); !c.Over() || p[0] != sol.Payoffs[0] || p[1] != sol.Payoffs[1] {
+				t.Fatalf("Expecting the line of %s deal %d to end on %v but got %v", name, seed, sol.Payoffs, p)
+			}
+
+			values, _ := s.Evaluate(r)
+			if best := values[0].Payoffs; best[0]-best[1] != v {
+				t.Fatalf("Expecting Evaluate to rank the best move first but got %v", values)
+			}
+		}
+	}
+}
+
+// maxn is the plain max^n search, each player making the most of their
+// own payoff.
+func maxn(s *Solver, r *Round) []int {
+	if r.Over() {
+		return Payoffs(r)
+	}
+
+	var best []int
+	for _, m := range r.Position(r.Turn).LegalMoves() {
+		next := r.Clone()
+		next.Play(m)
+		if p := maxn(s, next); best == nil || s.better(r, p, best) {
+			best = p
+		}
+	}
+
+	return best
+}
+
+func TestSolverMaxN(t *testing.T) {
+	rules := DefaultRuleset
+	rules.HandSize = 4
+	s := NewSolver()
+	for seed := int64(0); seed < 20; seed++ {
+		r := rules.Deal(rand.New(rand.NewSource(seed)), 3)
+		r.Open(OpeningWinner, -1)
+		if r.Over() {
+			continue
+		}
+
+		sol, err := s.Solve(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := maxn(s, r)
+		for i := range want {
+			if sol.Payoffs[i] != want[i] {
+				t.Fatalf("Expecting deal %d worth %v but solved %v", seed, want, sol.Payoffs)
+			}
+		}
+	}
+
+	r := &Round{Hands: [][]Tile{{{1, 1}}, {{2, 2}}}, Draw: true, Boneyard: []Tile{{1, 2}}}
+	if err := s.Check(r); err == nil {
+		t.Fatal("Expecting a draw game with a boneyard refused")
+	}
+}
+
+func TestOracle(t *testing.T) {
+	rules, _ := LookupRuleset("block")
+	wins := 0
+	for seed := int64(0); seed < 40; seed++ {
+		res, ok, err := PlayRound(seed, rules, []string{"oracle", "balanced"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ok && res.IsWinner(0) {
+			wins++
+		}
+	}
+
+	if wins < 24 {
+		t.Fatalf("Expecting the oracle to win most deals but won %d of 40", wins)
+	}
+}
+
+func TestOraclePeek(t *testing.T) {
+	rules, _ := LookupRuleset("block")
+	s := &oracleStrategy{solver: NewSolver()}
+	r := rules.Deal(rand.New(rand.NewSource(1)), 2)
+	r.Open(OpeningWinner, -1)
+
+	// every move peeks at a fresh copy of the same deal
+	s.Peek(r.Clone())
+	m, _ := s.Choose(r.Position(r.Turn))
+	r.Play(m)
+	searched := len(s.solver.table)
+	s.Peek(r.Clone())
+	if searched == 0 || len(s.solver.table) != searched {
+		t.Fatalf("Expecting the table kept over the deal but got %d then %d positions", searched, len(s.solver.table))
+	}
+
+	next := rules.Deal(rand.New(rand.NewSource(2)), 2)
+	next.Open(OpeningWinner, -1)
+	s.Peek(next)
+	if len(s.solver.table) != 0 {
+		t.Fatal("Expecting the table emptied for a new deal")
+	}
+}
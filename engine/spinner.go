@@ -0,0 +1,592 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// SpinnerRule decides which doubles are spinners, doubles that can be
+// played on from all four sides.
+type SpinnerRule string
+
+const (
+	// SpinnerFirst makes the first double played the only spinner, the zero
+	// value stands for it
+	SpinnerFirst SpinnerRule = "first"
+	// SpinnerEvery makes every double a spinner
+	SpinnerEvery SpinnerRule = "every"
+	// SpinnerNone plays a plain two ended line
+	SpinnerNone SpinnerRule = "none"
+)
+
+// ArmsRule decides when the two arms across a spinner open up.
+type ArmsRule string
+
+const (
+	// ArmsAfterSides opens the arms once both sides of the spinner are
+	// played on, the zero value stands for it
+	ArmsAfterSides ArmsRule = "after-sides"
+	// ArmsImmediate opens all four sides at once
+	ArmsImmediate ArmsRule = "immediate"
+)
+
+// LayoutNode is a tile of an n-ended layout. Tiles are oriented so that A
+// touches the parent tile and B is the open side; the first tile keeps the
+// orientation it was played with.
+type LayoutNode struct {
+	Tile Tile
+	// Parent is the node the tile was played on, -1 for the first tile
+	Parent   int
+	Children []int
+	Spinner  bool
+}
+
+// LayoutEnd is a place a tile can go: a node and the pips it shows there.
+// Node is -1 while the layout is empty.
+type LayoutEnd struct {
+	Node int
+	Pip  int
+}
+
+// Layout is a line of play with any number of open ends. Without spinners
+// it is the usual two ended line, every spinner adds two arms across.
+type Layout struct {
+	Nodes    []LayoutNode
+	Spinners SpinnerRule
+	Arms     ArmsRule
+}
+
+// NewLayout returns an empty layout playing by the ruleset's spinner rules.
+func (rs Ruleset) NewLayout() Layout {
+	return Layout{Spinners: rs.Spinner, Arms: rs.Arms}
+}
+
+func (l Layout) Started() bool {
+	return len(l.Nodes) > 0
+}
+
+// Spinner returns the first spinner of the layout, or -1.
+func (l Layout) Spinner() int {
+	for i, n := range l.Nodes {
+		if n.Spinner {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// capacity is how many tiles may be played on the node right now. The first
+// tile has two sides, a spinner two more once its sides are taken.
+func (l Layout) capacity(node int) int {
+	n := l.Nodes[node]
+	sides := 1
+	if n.Parent < 0 {
+		sides = 2
+	}
+
+	if !n.Spinner {
+		return sides
+	}
+
+	if l.Arms != ArmsImmediate && len(n.Children) < sides {
+		return sides
+	}
+
+	return sides + 2
+}
+
+// Ends returns every place that takes a tile. Several free sides of a node
+// showing the same pips are one end.
+func (l Layout) Ends() []LayoutEnd {
+	if !l.Started() {
+		return []LayoutEnd{{Node: -1}}
+	}
+
+	ends := []LayoutEnd{}
+	for i, n := range l.Nodes {
+		if len(n.Children) >= l.capacity(i) {
+			continue
+		}
+
+		if n.Parent >= 0 || n.Tile.IsDouble() {
+			ends = append(ends, LayoutEnd{Node: i, Pip: n.Tile.B})
+			continue
+		}
+
+		// the first tile, not a double, has a side for each half
+		for _, pip := range l.freeSides(i) {
+			ends = append(ends, LayoutEnd{Node: i, Pip: pip})
+		}
+	}
+
+	return ends
+}
+
+// freeSides returns the halves of the first tile nothing is played on yet.
+func (l Layout) freeSides(node int) []int {
+	sides := []int{l.Nodes[node].Tile.A, l.Nodes[node].Tile.B}
+	for _, c := range l.Nodes[node].Children {
+		pip := l.Nodes[c].Tile.A
+		for i, s := range sides {
+			if s == pip {
+				sides = append(sides[:i:i], sides[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return sides
+}
+
+// Fits reports whether the tile can go on the end.
+func (l Layout) Fits(t Tile, e LayoutEnd) bool {
+	if !l.Started() {
+		return e.Node < 0
+	}
+
+	for _, open := range l.Ends() {
+		if open == e {
+			return t.Matches(e.Pip)
+		}
+	}
+
+	return false
+}
+
+// Place puts the tile on the end, which has to fit, and returns the new
+// node. A double becomes a spinner as the layout's SpinnerRule says.
+func (l *Layout) Place(t Tile, e LayoutEnd) int {
+	node := LayoutNode{Tile: t, Parent: e.Node}
+	if l.Started() && t.A != e.Pip {
+		node.Tile = Tile{t.B, t.A}
+	}
+
+	switch l.Spinners {
+	case SpinnerEvery:
+		node.Spinner = t.IsDouble()
+	case SpinnerNone:
+	default:
+		node.Spinner = t.IsDouble() && l.Spinner() < 0
+	}
+
+	l.Nodes = append(l.Nodes, node)
+	idx := len(l.Nodes) - 1
+	if e.Node >= 0 {
+		l.Nodes[e.Node].Children = append(l.Nodes[e.Node].Children, idx)
+	}
+
+	return idx
+}
+
+// Count adds up the open ends of every arm as All Fives scores them. A
+// double at the end of an arm counts both halves, the arms across a spinner
+// only count once something is played on them.
+func (l Layout) Count() int {
+	if !l.Started() {
+		return 0
+	}
+
+	total := 0
+	for i, n := range l.Nodes {
+		switch {
+		case n.Parent < 0 && n.Tile.IsDouble():
+			if len(n.Children) < 2 {
+				total += n.Tile.Pips()
+			}
+		case n.Parent < 0:
+			for _, pip := range l.freeSides(i) {
+				total += pip
+			}
+		case len(n.Children) == 0 && n.Tile.IsDouble():
+			total += n.Tile.Pips()
+		case len(n.Children) == 0:
+			total += n.Tile.B
+		}
+	}
+
+	return total
+}
+
+// Clone returns a copy sharing nothing with the layout.
+func (l Layout) Clone() Layout {
+	c := Layout{Spinners: l.Spinners, Arms: l.Arms, Nodes: make([]LayoutNode, len(l.Nodes))}
+	for i, n := range l.Nodes {
+		n.Children = append([]int(nil), n.Children...)
+		c.Nodes[i] = n
+	}
+
+	return c
+}
+
+// SpinnerMove puts a tile on an end of the layout.
+type SpinnerMove struct {
+	Tile Tile
+	End  LayoutEnd
+}
+
+// SpinnerEvent is one action of an All Fives round: a move with the points
+// it scored, a tile drawn from the boneyard (kept in Move.Tile) or a pass.
+type SpinnerEvent struct {
+	Player int
+	Move   SpinnerMove
+	Points int
+	Draw   bool
+	Pass   bool
+}
+
+// SpinnerRound is a hand of All Fives or Sniff played on a Layout. Whenever
+// the open ends add up to a multiple of five the player who made them
+// scores the count. A player who can not play draws until they can, and
+// passes once the boneyard is empty or in a block game.
+type SpinnerRound struct {
+	Layout   Layout
+	Hands    [][]Tile
+	Boneyard []Tile
+	Turn     int
+	History  []SpinnerEvent
+	// Scores holds the points every player made during play
+	Scores []int
+	// Block forbids drawing, a player who can not play passes
+	Block  bool
+	Tie    TieRule
+	passes int
+}
+
+// DealSpinner deals a hand by the ruleset. The winner of the previous hand
+// leads with any tile when the opening rule says so, otherwise the highest
+// double is played for its holder.
+func DealSpinner(rng *rand.Rand, rules Ruleset, players, lastWinner int) *SpinnerRound {
+	tiles := rules.Set()
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	r := &SpinnerRound{Layout: rules.NewLayout(), Scores: make([]int, players), Block: !rules.Draw, Tie: rules.Tie}
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-rules.HandSize:]...))
+		tiles = tiles[:len(tiles)-rules.HandSize]
+	}
+
+	r.Boneyard = tiles
+	if rules.Opening == OpeningWinner && lastWinner >= 0 && lastWinner < players {
+		r.Turn = lastWinner
+		return r
+	}
+
+	best, found := Tile{}, false
+	for i, h := range r.Hands {
+		for _, t := range h {
+			if !found || leads(t, best) {
+				r.Turn, best, found = i, t, true
+			}
+		}
+	}
+
+	r.Play(SpinnerMove{Tile: best, End: LayoutEnd{Node: -1}})
+	return r
+}
+
+// LegalMoves lists every tile of the player's hand that fits an end.
+func (r *SpinnerRound) LegalMoves(player int) []SpinnerMove {
+	moves := []SpinnerMove{}
+	ends := r.Layout.Ends()
+	for _, tile := range r.Hands[player] {
+		for _, e := range ends {
+			if r.Layout.Fits(tile, e) {
+				moves = append(moves, SpinnerMove{Tile: tile, End: e})
+			}
+		}
+	}
+
+	return moves
+}
+
+// Play makes the move for the player on turn, scores the count when it is
+// a multiple of five and passes the turn on.
+func (r *SpinnerRound) Play(m SpinnerMove) error {
+	hand := r.Hands[r.Turn]
+	idx := -1
+	for i, t := range hand {
+		if t.Same(m.Tile) {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("%s is not in hand", m.Tile)
+	}
+
+	if !r.Layout.Fits(m.Tile, m.End) {
+		return fmt.Errorf("%s does not fit there", m.Tile)
+	}
+
+	r.Layout.Place(m.Tile, m.End)
+	r.Hands[r.Turn] = append(hand[:idx:idx], hand[idx+1:]...)
+	points := FivesPoints(r.Layout.Count())
+	r.Scores[r.Turn] += points
+	r.History = append(r.History, SpinnerEvent{Player: r.Turn, Move: m, Points: points})
+	r.passes = 0
+	r.next()
+	return nil
+}
+
+// FivesPoints is what a count scores: itself when it is a multiple of five.
+func FivesPoints(count int) int {
+	if count > 0 && count%5 == 0 {
+		return count
+	}
+
+	return 0
+}
+
+// Draw takes a tile from the boneyard for the player on turn, who must not
+// have a move.
+func (r *SpinnerRound) Draw() (Tile, error) {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return Tile{}, fmt.Errorf("player %d has a move", r.Turn+1)
+	case r.Block:
+		return Tile{}, fmt.Errorf("this is a block game")
+	case len(r.Boneyard) == 0:
+		return Tile{}, fmt.Errorf("the boneyard is empty")
+	}
+
+	t := r.Boneyard[len(r.Boneyard)-1]
+	r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+	r.Hands[r.Turn] = append(r.Hands[r.Turn], t)
+	r.History = append(r.History, SpinnerEvent{Player: r.Turn, Move: SpinnerMove{Tile: t, End: LayoutEnd{Node: -1}}, Draw: true})
+	return t, nil
+}
+
+// Pass ends the turn of a player who can neither play nor draw.
+func (r *SpinnerRound) Pass() error {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return fmt.Errorf("player %d has a move", r.Turn+1)
+	case !r.Block && len(r.Boneyard) > 0:
+		return fmt.Errorf("player %d has to draw first", r.Turn+1)
+	}
+
+	r.History = append(r.History, SpinnerEvent{Player: r.Turn, Pass: true})
+	r.passes++
+	r.next()
+	return nil
+}
+
+func (r *SpinnerRound) next() {
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+}
+
+// Over reports whether a player has gone out or every player passed in a
+// row.
+func (r *SpinnerRound) Over() bool {
+	for _, h := range r.Hands {
+		if len(h) == 0 {
+			return true
+		}
+	}
+
+	return r.passes >= len(r.Hands)
+}
+
+// roundFive rounds to the nearest multiple of five.
+func roundFive(n int) int {
+	return (n + 2) / 5 * 5
+}
+
+// Result adds the end of hand bonus to the points made during play. The
+// player who went out scores the opponents' pips rounded to the nearest
+// five. A blocked hand goes to the lowest hand, which scores the difference
+// to the others; tied lowest hands share it under TieShared and score
+// nothing otherwise.
+func (r *SpinnerRound) Result() Result {
+	res := Result{Winner: -1, Blocked: true, Turns: len(r.History), Points: append([]int(nil), r.Scores...)}
+	least := -1
+	for _, h := range r.Hands {
+		pips := 0
+		for _, t := range h {
+			pips += t.Pips()
+		}
+
+		res.Pips = append(res.Pips, pips)
+		if len(h) == 0 {
+			res.Blocked = false
+		}
+
+		if least < 0 || pips < least {
+			least = pips
+		}
+	}
+
+	// a player who went out wins alone even against a hand of [0,0]
+	tied := []int{}
+	for i, pips := range res.Pips {
+		if res.Blocked && pips == least || !res.Blocked && len(r.Hands[i]) == 0 {
+			tied = append(tied, i)
+		}
+	}
+
+	switch {
+	case !res.Blocked:
+		res.Reason = fmt.Sprintf("player %d went out", tied[0]+1)
+	case len(tied) == 1:
+		res.Reason = fmt.Sprintf("blocked, lowest hand with %d pips", least)
+	case r.Tie == TieShared:
+		res.Reason = fmt.Sprintf("blocked, %d players tied on %d pips share the win", len(tied), least)
+	default:
+		res.Reason = fmt.Sprintf("blocked, %d players tied on %d pips, no winner", len(tied), least)
+		return res
+	}
+
+	res.Winners, res.Winner = tied, tied[0]
+	others := 0
+	for i, pips := range res.Pips {
+		if !res.IsWinner(i) {
+			others += pips - least
+		}
+	}
+
+	for _, w := range res.Winners {
+		res.Points[w] += roundFive(others / len(res.Winners))
+	}
+
+	return res
+}
+
+// SpinnerPosition is what a player sees of an All Fives round.
+type SpinnerPosition struct {
+	Player    int
+	Hand      []Tile
+	Layout    Layout
+	Scores    []int
+	HandSizes []int
+	Boneyard  int
+	Legal     []SpinnerMove
+}
+
+// Position returns what the player sees.
+func (r *SpinnerRound) Position(player int) SpinnerPosition {
+	pos := SpinnerPosition{
+		Player:   player,
+		Hand:     append([]Tile(nil), r.Hands[player]...),
+		Layout:   r.Layout.Clone(),
+		Scores:   append([]int(nil), r.Scores...),
+		Boneyard: len(r.Boneyard),
+		Legal:    r.LegalMoves(player),
+	}
+
+	for _, h := range r.Hands {
+		pos.HandSizes = append(pos.HandSizes, len(h))
+	}
+
+	return pos
+}
+
+// Run plays the hand to the end with one strategy per seat. Strategies that
+// do not implement SpinnerStrategy play the balanced All Fives moves.
+func (r *SpinnerRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		pos := r.Position(r.Turn)
+		if len(pos.Legal) == 0 {
+			if _, err := r.Draw(); err != nil {
+				r.Pass()
+			}
+			continue
+		}
+
+		m, ok := ChooseSpinner(strategies[r.Turn], pos)
+		if !ok {
+			return Result{}, fmt.Errorf("%s found no move for player %d", strategies[r.Turn].Name(), r.Turn)
+		}
+
+		if err := r.Play(m); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// SpinnerStrategy is implemented by strategies that know how to play All
+// Fives.
+type SpinnerStrategy interface {
+	ChooseSpinner(pos SpinnerPosition) (SpinnerMove, bool)
+}
+
+// ChooseSpinner asks the strategy for an All Fives move, falling back to
+// the top move of EvaluateSpinner.
+func ChooseSpinner(s Strategy, pos SpinnerPosition) (SpinnerMove, bool) {
+	if ss, ok := s.(SpinnerStrategy); ok {
+		return ss.ChooseSpinner(pos)
+	}
+
+	return balancedStrategy{}.ChooseSpinner(pos)
+}
+
+// ScoredSpinnerMove is a legal All Fives move with its score and
+// explanation.
+type ScoredSpinnerMove struct {
+	SpinnerMove
+	Score  float64
+	Reason string
+}
+
+// EvaluateSpinner scores every legal move, best first. Points made now
+// count most, then shedding pips.
+func EvaluateSpinner(pos SpinnerPosition) []ScoredSpinnerMove {
+	scored := make([]ScoredSpinnerMove, 0, len(pos.Legal))
+	for _, m := range pos.Legal {
+		after := pos.Layout.Clone()
+		after.Place(m.Tile, m.End)
+		count := after.Count()
+		s := ScoredSpinnerMove{SpinnerMove: m, Score: float64(m.Tile.Pips()) / 2}
+		s.Reason = fmt.Sprintf("gets rid of %d pips", m.Tile.Pips())
+		if points := FivesPoints(count); points > 0 {
+			s.Score += float64(points)
+			s.Reason = fmt.Sprintf("scores %d with the ends counting %d", points, count)
+		}
+
+		scored = append(scored, s)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	return scored
+}
+
+func (s *randomStrategy) ChooseSpinner(pos SpinnerPosition) (SpinnerMove, bool) {
+	if len(pos.Legal) == 0 {
+		return SpinnerMove{}, false
+	}
+
+	return pos.Legal[s.rng.Intn(len(pos.Legal))], true
+}
+
+func (heavyStrategy) ChooseSpinner(pos SpinnerPosition) (SpinnerMove, bool) {
+	if len(pos.Legal) == 0 {
+		return SpinnerMove{}, false
+	}
+
+	best := pos.Legal[0]
+	for _, m := range pos.Legal[1:] {
+		if m.Tile.Pips() > best.Tile.Pips() {
+			best = m
+		}
+	}
+
+	return best, true
+}
+
+func (balancedStrategy) ChooseSpinner(pos SpinnerPosition) (SpinnerMove, bool) {
+	scored := EvaluateSpinner(pos)
+	if len(scored) == 0 {
+		return SpinnerMove{}, false
+	}
+
+	return scored[0].SpinnerMove, true
+}
@@ -0,0 +1,81 @@
+package engine
+
+import "testing"
+
+func TestLayoutSpinner(t *testing.T) {
+	l := Layout{}
+	l.Place(Tile{5, 5}, LayoutEnd{Node: -1})
+	if l.Count() != 10 || len(l.Ends()) != 1 {
+		t.Fatalf("Expecting the spinner to count 10 with one end but got %d, %v", l.Count(), l.Ends())
+	}
+
+	// the arms across open once both sides are played on
+	l.Place(Tile{0, 5}, LayoutEnd{Node: 0, Pip: 5})
+	if l.Count() != 10 || len(l.Nodes[0].Children) != 1 || l.capacity(0) != 2 {
+		t.Fatalf("Expecting 10 with the spinner still open on its side but got %d", l.Count())
+	}
+
+	l.Place(Tile{5, 3}, LayoutEnd{Node: 0, Pip: 5})
+	if l.Count() != 3 || l.capacity(0) != 4 {
+		t.Fatalf("Expecting 0+3 with the arms open but got %d, capacity %d", l.Count(), l.capacity(0))
+	}
+
+	l.Place(Tile{5, 2}, LayoutEnd{Node: 0, Pip: 5})
+	l.Place(Tile{3, 3}, LayoutEnd{Node: 2, Pip: 3})
+	if l.Count() != 0+6+2 || l.Nodes[4].Spinner {
+		t.Fatalf("Expecting 8 with the end double counting both halves but got %d", l.Count())
+	}
+
+	if ends := l.Ends(); len(ends) != 4 {
+		t.Fatalf("Expecting the spinner, two arms and the double open but got %v", ends)
+	}
+
+	l = Layout{Spinners: SpinnerNone}
+	l.Place(Tile{4, 1}, LayoutEnd{Node: -1})
+	l.Place(Tile{1, 1}, LayoutEnd{Node: 0, Pip: 1})
+	if l.Spinner() >= 0 || l.Count() != 4+2 || len(l.Ends()) != 2 {
+		t.Fatalf("Expecting a two ended line counting 6 but got %d, %v", l.Count(), l.Ends())
+	}
+}
+
+func TestSpinnerPlay(t *testing.T) {
+	r := &SpinnerRound{Layout: Layout{}, Scores: make([]int, 2)}
+	r.Hands = [][]Tile{{{5, 5}, {5, 0}}, {{5, 6}, {6, 6}}}
+	r.Play(SpinnerMove{Tile: Tile{5, 5}, End: LayoutEnd{Node: -1}})
+	r.Play(SpinnerMove{Tile: Tile{5, 6}, End: LayoutEnd{Node: 0, Pip: 5}})
+	if r.Scores[0] != 10 || r.Scores[1] != 0 {
+		t.Fatalf("Expecting the opening spinner to score 10 but got %v", r.Scores)
+	}
+
+	if err := r.Play(SpinnerMove{Tile: Tile{5, 0}, End: LayoutEnd{Node: 0, Pip: 5}}); err != nil {
+		t.Fatal(err)
+	}
+
+	res := r.Result()
+	if res.Winner != 0 || res.Points[0] != 10+10 {
+		t.Fatalf("Expecting player 1 out scoring 10 for the 12 pips left but got %+v", res)
+	}
+}
+
+func TestSpinnerOutBeatsDoubleBlank(t *testing.T) {
+	r := &SpinnerRound{Layout: Layout{}, Scores: make([]int, 3), Tie: TieShared}
+	r.Hands = [][]Tile{{{0, 0}}, {}, {{6, 6}}}
+	res := r.Result()
+	if len(res.Winners) != 1 || res.Winner != 1 || res.Points[1] != 10 || res.Points[0] != 0 || res.Reason != "player 2 went out" {
+		t.Fatalf("Expecting player 2 to win the 12 pips alone for going out but got %+v", res)
+	}
+}
+
+func TestSpinnerMatch(t *testing.T) {
+	for _, name := range []string{"all-fives", "sniff"} {
+		rules, _ := LookupRuleset(name)
+		m, err := PlayMatch(5, rules, []string{"balanced", "heavy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w := m.Winners(); len(w) == 0 || m.Scores[w[0]] < rules.TargetScore {
+			t.Fatalf("%s: expecting a winner past %d but got %v", name, rules.TargetScore, m.Scores)
+		}
+	}
+}
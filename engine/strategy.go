@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScoredMove is a legal move together with how much a strategy likes it and
+// a short human readable explanation.
+type ScoredMove struct {
+	Move
+	Score  float64
+	Reason string
+}
+
+// Evaluate scores every legal move in the position, best first. It favours
+// shedding heavy tiles and doubles, keeping follow-up plays in hand and
+// exposing numbers the opponents are unlikely to hold, above all the ones
+// they have shown to lack.
+func Evaluate(pos Position) []ScoredMove {
+	moves := pos.LegalMoves()
+	scored := make([]ScoredMove, 0, len(moves))
+
+	for _, m := range moves {
+		weight := float64(m.Tile.Pips())
+		double := 0.0
+		if m.Tile.IsDouble() {
+			double = 4
+		}
+
+		head, tail := pos.After(m)
+		followUps := 0
+		rest := 0
+		for _, t := range pos.Hand {
+			if t.Same(m.Tile) {
+				continue
+			}
+
+			rest++
+			if pos.Matching.Fits(t, head) || pos.Matching.Fits(t, tail) {
+				followUps++
+			}
+		}
+
+		flexibility := 2 * float64(followUps)
+		if followUps == 0 && rest > 0 {
+			flexibility = -5
+		}
+
+		blocking := 0.5*float64(pos.seen(head, m.Tile)+pos.seen(tail, m.Tile)) +
+			2*float64(pos.lacking(head)+pos.lacking(tail))
+
+		s := ScoredMove{Move: m, Score: weight + double + flexibility + blocking}
+		switch {
+		case flexibility < 0:
+			s.Reason = "only move that fits, but it leaves nothing to follow"
+		case double > 0 && double+weight >= flexibility && double+weight >= blocking:
+			s.Reason = fmt.Sprintf("play the double %s while it still fits", m.Tile)
+		case weight >= flexibility && weight >= blocking:
+			s.Reason = fmt.Sprintf("gets rid of %d heavy pips", m.Tile.Pips())
+		case flexibility >= blocking:
+			s.Reason = fmt.Sprintf("keeps %d follow-up plays in your hand", followUps)
+		default:
+			s.Reason = fmt.Sprintf("leaves %d and %d open, which opponents are short of", head, tail)
+		}
+
+		scored = append(scored, s)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	return scored
+}
+
+// lacking counts the opponents who have shown to hold no tile fitting an
+// end showing n.
+func (p Position) lacking(n int) int {
+	need, ok := p.Matching.needs(n)
+	count := 0
+	for _, lacks := range p.OpponentLacks {
+		for _, l := range lacks {
+			if ok && l == need {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// seen counts the tiles fitting an end showing n that are already out of
+// the opponents' reach, either on the table or in the player's own hand.
+func (p Position) seen(n int, playing Tile) int {
+	need, ok := p.Matching.needs(n)
+	if !ok {
+		return 0
+	}
+
+	count := 0
+	if playing.Matches(need) {
+		count++
+	}
+
+	for _, t := range p.Played {
+		if t.Matches(need) {
+			count++
+		}
+	}
+
+	for _, t := range p.Hand {
+		if t.Matches(need) && !t.Same(playing) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Strategy decides which move a CPU player makes.
+type Strategy interface {
+	Name() string
+	Choose(pos Position) (Move, bool)
+}
+
+var strategies = map[string]func(seed int64) Strategy{
+	"random": func(seed int64) Strategy {
+		return &randomStrategy{rng: rand.New(rand.NewSource(seed))}
+	},
+	"heavy": func(seed int64) Strategy {
+		return heavyStrategy{}
+	},
+	"balanced": func(seed int64) Strategy {
+		return balancedStrategy{}
+	},
+	"oracle": func(seed int64) Strategy {
+		return &oracleStrategy{solver: NewSolver()}
+	},
+}
+
+// DefaultStrategy is used for CPU players that do not ask for a specific one.
+const DefaultStrategy = "balanced"
+
+// NewStrategy returns the named strategy seeded from the clock.
+func NewStrategy(name string) (Strategy, error) {
+	return NewSeededStrategy(name, time.Now().UnixNano())
+}
+
+// NewSeededStrategy returns the named strategy using a fixed random seed.
+// Names starting with ExternalPrefix launch the rest of the name as an
+// external bot.
+func NewSeededStrategy(name string, seed int64) (Strategy, error) {
+	if strings.HasPrefix(name, ExternalPrefix) {
+		return NewExternalStrategy(strings.Fields(strings.TrimPrefix(name, ExternalPrefix)), DefaultMoveTimeout)
+	}
+
+	f, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+
+	return f(seed), nil
+}
+
+// Strategies returns the names of all registered strategies, sorted.
+func Strategies() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// randomStrategy plays any legal move.
+type randomStrategy struct {
+	rng *rand.Rand
+}
+
+func (s *randomStrategy) Name() string { return "random" }
+
+func (s *randomStrategy) Choose(pos Position) (Move, bool) {
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	return moves[s.rng.Intn(len(moves))], true
+}
+
+// heavyStrategy always dumps the tile with most pips.
+type heavyStrategy struct{}
+
+func (heavyStrategy) Name() string { return "heavy" }
+
+func (heavyStrategy) Choose(pos Position) (Move, bool) {
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	best := moves[0]
+	for _, m := range moves[1:] {
+		if m.Tile.Pips() > best.Tile.Pips() {
+			best = m
+		}
+	}
+
+	return best, true
+}
+
+// balancedStrategy plays the top move from Evaluate.
+type balancedStrategy struct{}
+
+func (balancedStrategy) Name() string { return "balanced" }
+
+func (balancedStrategy) Choose(pos Position) (Move, bool) {
+	scored := Evaluate(pos)
+	if len(scored) == 0 {
+		return Move{}, false
+	}
+
+	return scored[0].Move, true
+}
@@ -0,0 +1,70 @@
+package engine
+
+import "testing"
+
+func TestLegalMoves(t *testing.T) {
+	pos := Position{
+		Started: true,
+		Head:    6,
+		Tail:    2,
+		Hand:    []Tile{{6, 2}, {3, 3}, {2, 5}},
+	}
+
+	moves := pos.LegalMoves()
+	if len(moves) != 3 {
+		t.Fatalf("Expecting 3 legal moves but got %v", moves)
+	}
+
+	// both ends show 4, only one move per tile
+	pos = Position{Started: true, Head: 4, Tail: 4, Hand: []Tile{{4, 1}}}
+	if moves := pos.LegalMoves(); len(moves) != 1 {
+		t.Fatalf("Expecting 1 legal move when both ends match but got %v", moves)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	pos := Position{
+		Started: true,
+		Head:    6,
+		Tail:    1,
+		Hand:    []Tile{{6, 6}, {1, 2}, {6, 3}},
+	}
+
+	scored := Evaluate(pos)
+	if len(scored) != 3 {
+		t.Fatalf("Expecting 3 scored moves but got %d", len(scored))
+	}
+
+	if !scored[0].Tile.Same(Tile{6, 6}) {
+		t.Fatalf("Expecting heavy double [6,6] to be recommended but got %s (%s)", scored[0].Move, scored[0].Reason)
+	}
+
+	if scored[0].Reason == "" {
+		t.Fatalf("Expecting a reason for the recommended move")
+	}
+
+	for i := 1; i < len(scored); i++ {
+		if scored[i].Score > scored[i-1].Score {
+			t.Fatalf("Expecting moves sorted best first but got %v", scored)
+		}
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	pos := Position{Started: true, Head: 3, Tail: 5, Hand: []Tile{{3, 1}, {5, 6}, {2, 2}}}
+	for _, name := range Strategies() {
+		s, err := NewSeededStrategy(name, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, ok := s.Choose(pos)
+		if !ok || !pos.CanPlay(m.Tile, m.End) {
+			t.Fatalf("Strategy %s chose illegal move %s", name, m)
+		}
+	}
+
+	if _, err := NewStrategy("unknown"); err == nil {
+		t.Fatalf("Expecting error for unknown strategy")
+	}
+}
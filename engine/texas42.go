@@ -0,0 +1,552 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Bids of Texas 42: a bid is the points the bidding team promises to take,
+// from MinBid to the 42 of a hand, or MarksBid to play for two marks.
+const (
+	MinBid   = 30
+	AllBid   = 42
+	MarksBid = 84
+)
+
+// TexasPass is the bid of a player who passes.
+const TexasPass = 0
+
+// TexasCount returns what the tile counts: 10 for [5,5] and [4,6], 5 for
+// the tiles of five pips and nothing for the others.
+func TexasCount(t Tile) int {
+	switch {
+	case t.Same(Tile{5, 5}) || t.Same(Tile{4, 6}):
+		return 10
+	case t.Pips() == 5:
+		return 5
+	}
+
+	return 0
+}
+
+// TexasPlay is a tile put on a trick.
+type TexasPlay struct {
+	Player int
+	Tile   Tile
+}
+
+// TexasTrick is a finished trick, Points its count and the trick itself.
+type TexasTrick struct {
+	Plays  []TexasPlay
+	Winner int
+	Points int
+}
+
+// TexasRound is a hand of Texas 42: four players in two partnerships,
+// seats 0 and 2 against 1 and 3, with seven tiles each. Everybody bids once
+// from the dealer's left, the highest bidder names trumps and leads, and
+// seven tricks are played. Every trick is worth a point plus the count of
+// its tiles, 42 in all.
+type TexasRound struct {
+	Hands  [][]Tile
+	Dealer int
+	Turn   int
+	// Bids holds every bid so far in bidding order, Bid and Bidder the
+	// highest
+	Bids   []int
+	Bid    int
+	Bidder int
+	// Trump is the suit named by the bidder, -1 until named
+	Trump int
+	// Trick holds the tiles of the trick being played, Led its suit
+	Trick   []TexasPlay
+	Led     int
+	History []TexasTrick
+	// Points holds what each team took, team 0 being seats 0 and 2
+	Points [2]int
+}
+
+// DealTexas deals a hand by the ruleset, the dealer moving on every hand.
+func DealTexas(rng *rand.Rand, rules Ruleset, players, hand int) *TexasRound {
+	tiles := rules.Set()
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	hands := [][]Tile{}
+	for i := 0; i < players; i++ {
+		hands = append(hands, append([]Tile(nil), tiles[len(tiles)-rules.HandSize:]...))
+		tiles = tiles[:len(tiles)-rules.HandSize]
+	}
+
+	return NewTexasRound(hands, hand%players)
+}
+
+// NewTexasRound starts the bidding of a hand already dealt, from the
+// dealer's left.
+func NewTexasRound(hands [][]Tile, dealer int) *TexasRound {
+	return &TexasRound{Hands: hands, Dealer: dealer, Turn: (dealer + 1) % len(hands), Bidder: -1, Trump: -1}
+}
+
+// Team returns the partnership of the seat.
+func Team(player int) int {
+	return player % 2
+}
+
+// Bidding reports whether bids are still being made.
+func (r *TexasRound) Bidding() bool {
+	return len(r.Bids) < len(r.Hands)
+}
+
+// MinNextBid returns the lowest bid that tops the bids so far, TexasPass
+// once 2 marks are bid as nothing tops them.
+func (r *TexasRound) MinNextBid() int {
+	switch {
+	case r.Bid == 0:
+		return MinBid
+	case r.Bid < AllBid:
+		return r.Bid + 1
+	case r.Bid == AllBid:
+		return MarksBid
+	}
+
+	return TexasPass
+}
+
+// MakeBid bids for the player on turn, TexasPass to pass. When everybody
+// passed the dealer has to take the hand at the lowest bid.
+func (r *TexasRound) MakeBid(bid int) error {
+	switch {
+	case !r.Bidding():
+		return fmt.Errorf("the bidding is over")
+	case bid != TexasPass && r.MinNextBid() == TexasPass:
+		return fmt.Errorf("nothing tops a bid of 2 marks, pass")
+	case bid != TexasPass && (bid < r.MinNextBid() || bid > AllBid && bid != MarksBid):
+		return fmt.Errorf("bid %d does not top %d", bid, r.Bid)
+	}
+
+	r.Bids = append(r.Bids, bid)
+	if bid != TexasPass {
+		r.Bid, r.Bidder = bid, r.Turn
+	}
+
+	if r.Bidding() {
+		r.Turn = (r.Turn + 1) % len(r.Hands)
+		return nil
+	}
+
+	if r.Bidder < 0 {
+		r.Bid, r.Bidder = MinBid, r.Dealer
+	}
+	r.Turn = r.Bidder
+	return nil
+}
+
+// NameTrump lets the bidder name the trump suit, 0 to 6, and lead.
+func (r *TexasRound) NameTrump(suit int) error {
+	switch {
+	case r.Bidding() || r.Trump >= 0:
+		return fmt.Errorf("trumps are named by the bidder after the bidding")
+	case suit < 0 || suit > 6:
+		return fmt.Errorf("no suit %d", suit)
+	}
+
+	r.Trump = suit
+	return nil
+}
+
+// IsTrump reports whether the tile belongs to the trump suit.
+func (r *TexasRound) IsTrump(t Tile) bool {
+	return r.Trump >= 0 && t.Matches(r.Trump)
+}
+
+// SuitOf returns the suit a tile leads: trumps, or its higher end.
+func (r *TexasRound) SuitOf(t Tile) int {
+	if r.IsTrump(t) {
+		return r.Trump
+	}
+
+	return max(t.A, t.B)
+}
+
+// follows reports whether the tile belongs to the suit, trumps only
+// belonging to the trump suit.
+func (r *TexasRound) follows(t Tile, suit int) bool {
+	if suit == r.Trump {
+		return r.IsTrump(t)
+	}
+
+	return t.Matches(suit) && !r.IsTrump(t)
+}
+
+// strength orders the tiles of a trick: trumps, then the led suit, the
+// double being the highest of a suit and the others ranking by their
+// other end. Tiles of neither suit never win.
+func (r *TexasRound) strength(t Tile) int {
+	rank := func(suit int) int {
+		if t.IsDouble() {
+			return 7
+		}
+		return t.Other(suit)
+	}
+
+	switch {
+	case r.IsTrump(t):
+		return 20 + rank(r.Trump)
+	case r.follows(t, r.Led):
+		return 10 + rank(r.Led)
+	}
+
+	return 0
+}
+
+// LegalMoves returns the tiles the player on turn may play: anything when
+// leading, otherwise a tile of the led suit when they hold one.
+func (r *TexasRound) LegalMoves() []Tile {
+	hand := r.Hands[r.Turn]
+	if len(r.Trick) == 0 {
+		return append([]Tile(nil), hand...)
+	}
+
+	legal := []Tile{}
+	for _, t := range hand {
+		if r.follows(t, r.Led) {
+			legal = append(legal, t)
+		}
+	}
+
+	if len(legal) == 0 {
+		return append([]Tile(nil), hand...)
+	}
+
+	return legal
+}
+
+// Winning returns the play taking the trick so far.
+func (r *TexasRound) Winning() TexasPlay {
+	best := r.Trick[0]
+	for _, p := range r.Trick[1:] {
+		if r.strength(p.Tile) > r.strength(best.Tile) {
+			best = p
+		}
+	}
+
+	return best
+}
+
+// Play puts the tile of the player on turn on the trick.
+func (r *TexasRound) Play(t Tile) error {
+	switch {
+	case r.Bidding() || r.Trump < 0:
+		return fmt.Errorf("trumps are not named yet")
+	case r.Over():
+		return fmt.Errorf("the hand is over")
+	}
+
+	legal := false
+	for _, l := range r.LegalMoves() {
+		legal = legal || l.Same(t)
+	}
+
+	if !legal {
+		return fmt.Errorf("%s does not follow suit", t)
+	}
+
+	hand := r.Hands[r.Turn]
+	for i, h := range hand {
+		if h.Same(t) {
+			r.Hands[r.Turn] = append(hand[:i:i], hand[i+1:]...)
+			break
+		}
+	}
+
+	if len(r.Trick) == 0 {
+		r.Led = r.SuitOf(t)
+	}
+
+	r.Trick = append(r.Trick, TexasPlay{Player: r.Turn, Tile: t})
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+	if len(r.Trick) < len(r.Hands) {
+		return nil
+	}
+
+	trick := TexasTrick{Plays: r.Trick, Winner: r.Winning().Player, Points: 1}
+	for _, p := range r.Trick {
+		trick.Points += TexasCount(p.Tile)
+	}
+
+	r.Points[Team(trick.Winner)] += trick.Points
+	r.History = append(r.History, trick)
+	r.Trick, r.Turn = nil, trick.Winner
+	return nil
+}
+
+// Over reports whether every trick is played.
+func (r *TexasRound) Over() bool {
+	return !r.Bidding() && len(r.Hands[r.Turn]) == 0
+}
+
+// Need returns the points the bidders have to take.
+func (r *TexasRound) Need() int {
+	return min(r.Bid, AllBid)
+}
+
+// Result gives the marks of the hand to the bidders when they took what
+// they bid, and to their opponents when they were set. A hand bid for
+// marks is worth two. Both partners score the marks.
+func (r *TexasRound) Result() Result {
+	res := Result{Winner: -1, Turns: len(r.History), Pips: make([]int, len(r.Hands)), Points: make([]int, len(r.Hands))}
+	marks, team := 1, Team(r.Bidder)
+	if r.Bid == MarksBid {
+		marks = 2
+	}
+
+	made := r.Points[team] >= r.Need()
+	if !made {
+		team = 1 - team
+	}
+
+	for i := range r.Hands {
+		if Team(i) == team {
+			res.Points[i] = marks
+			res.Winners = append(res.Winners, i)
+		}
+	}
+
+	res.Winner = res.Winners[0]
+	if made {
+		res.Reason = fmt.Sprintf("player %d bid %d and the team took %d", r.Bidder+1, r.Bid, r.Points[Team(r.Bidder)])
+	} else {
+		res.Reason = fmt.Sprintf("player %d bid %d but the team took %d and was set", r.Bidder+1, r.Bid, r.Points[Team(r.Bidder)])
+	}
+
+	return res
+}
+
+// TexasPosition is what a player sees of a Texas 42 round.
+type TexasPosition struct {
+	Player int
+	Hand   []Tile
+	// Bid and Bidder are the highest bid so far, MinBid the lowest bid
+	// topping it or TexasPass when only a pass is left
+	Bid, Bidder, MinBid int
+	Trump               int
+	Trick               []TexasPlay
+	Legal               []Tile
+	Points              [2]int
+}
+
+// Position returns what the player on turn sees.
+func (r *TexasRound) Position() TexasPosition {
+	pos := TexasPosition{
+		Player: r.Turn,
+		Hand:   append([]Tile(nil), r.Hands[r.Turn]...),
+		Bid:    r.Bid,
+		Bidder: r.Bidder,
+		MinBid: r.MinNextBid(),
+		Trump:  r.Trump,
+		Trick:  append([]TexasPlay(nil), r.Trick...),
+		Points: r.Points,
+	}
+
+	if !r.Bidding() && r.Trump >= 0 {
+		pos.Legal = r.LegalMoves()
+	}
+
+	return pos
+}
+
+// Run bids and plays the hand to the end with one strategy per seat.
+// Strategies that do not implement TexasStrategy play like the balanced
+// one.
+func (r *TexasRound) Run(strategies []Strategy) (Result, error) {
+	var err error
+	for !r.Over() && err == nil {
+		s := strategies[r.Turn]
+		switch {
+		case r.Bidding():
+			err = r.MakeBid(BidTexas(s, r.Position()))
+		case r.Trump < 0:
+			err = r.NameTrump(TrumpTexas(s, r.Position()))
+		default:
+			err = r.Play(ChooseTexas(s, r.Position()))
+		}
+
+		if err != nil {
+			return Result{}, fmt.Errorf("%s: %v", s.Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// TexasStrategy is implemented by strategies that know how to bid, name
+// trumps and play in Texas 42.
+type TexasStrategy interface {
+	BidTexas(pos TexasPosition) int
+	TrumpTexas(pos TexasPosition) int
+	ChooseTexas(pos TexasPosition) Tile
+}
+
+func texasStrategy(s Strategy) TexasStrategy {
+	if ts, ok := s.(TexasStrategy); ok {
+		return ts
+	}
+
+	return balancedStrategy{}
+}
+
+// BidTexas asks the strategy for a bid, falling back to the balanced one.
+func BidTexas(s Strategy, pos TexasPosition) int {
+	return texasStrategy(s).BidTexas(pos)
+}
+
+// TrumpTexas asks the strategy for trumps, falling back to the balanced
+// one.
+func TrumpTexas(s Strategy, pos TexasPosition) int {
+	return texasStrategy(s).TrumpTexas(pos)
+}
+
+// ChooseTexas asks the strategy for a tile, falling back to the balanced
+// one.
+func ChooseTexas(s Strategy, pos TexasPosition) Tile {
+	return texasStrategy(s).ChooseTexas(pos)
+}
+
+// EvaluateTexas returns the best trump suit for the hand and how many
+// tricks it should take with it: a trick for every trump and every other
+// double, and one more when holding the double of trumps.
+func EvaluateTexas(hand []Tile) (int, int) {
+	best, tricks := 0, -1
+	for suit := 0; suit <= 6; suit++ {
+		n := 0
+		for _, t := range hand {
+			switch {
+			case t.Matches(suit) && t.IsDouble():
+				n += 2
+			case t.Matches(suit) || t.IsDouble():
+				n++
+			}
+		}
+
+		if n > tricks {
+			best, tricks = suit, n
+		}
+	}
+
+	return best, min(tricks, 7)
+}
+
+// texasBid turns the tricks a hand should take into a bid, passing below
+// four of them.
+func texasBid(pos TexasPosition, tricks int) int {
+	bid := MinBid + 2*(tricks-4)
+	if tricks < 4 || bid < pos.MinBid || pos.MinBid > AllBid || pos.MinBid == TexasPass {
+		return TexasPass
+	}
+
+	return bid
+}
+
+func (s *randomStrategy) BidTexas(pos TexasPosition) int {
+	if s.rng.Intn(3) > 0 || pos.MinBid > AllBid || pos.MinBid == TexasPass {
+		return TexasPass
+	}
+
+	return pos.MinBid
+}
+
+func (s *randomStrategy) TrumpTexas(pos TexasPosition) int {
+	return s.rng.Intn(7)
+}
+
+func (s *randomStrategy) ChooseTexas(pos TexasPosition) Tile {
+	return pos.Legal[s.rng.Intn(len(pos.Legal))]
+}
+
+// heavy overbids by a trick and always plays its heaviest tile
+func (heavyStrategy) BidTexas(pos TexasPosition) int {
+	_, tricks := EvaluateTexas(pos.Hand)
+	return texasBid(pos, tricks+1)
+}
+
+func (heavyStrategy) TrumpTexas(pos TexasPosition) int {
+	suit, _ := EvaluateTexas(pos.Hand)
+	return suit
+}
+
+func (heavyStrategy) ChooseTexas(pos TexasPosition) Tile {
+	best := pos.Legal[0]
+	for _, t := range pos.Legal[1:] {
+		if t.Pips() > best.Pips() {
+			best = t
+		}
+	}
+
+	return best
+}
+
+func (balancedStrategy) BidTexas(pos TexasPosition) int {
+	_, tricks := EvaluateTexas(pos.Hand)
+	return texasBid(pos, tricks)
+}
+
+func (balancedStrategy) TrumpTexas(pos TexasPosition) int {
+	suit, _ := EvaluateTexas(pos.Hand)
+	return suit
+}
+
+// balanced takes the trick with its cheapest winning tile unless its
+// partner already holds it, then it gives them its count; otherwise it
+// throws its cheapest tile, keeping the count away from the opponents
+func (balancedStrategy) ChooseTexas(pos TexasPosition) Tile {
+	r := &TexasRound{Trump: pos.Trump, Trick: pos.Trick}
+	if len(pos.Trick) > 0 {
+		r.Led = r.SuitOf(pos.Trick[0].Tile)
+	}
+
+	cheap := func(t Tile) int { return TexasCount(t)*10 + r.strength(t) }
+	partner := len(pos.Trick) > 0 && Team(r.Winning().Player) == Team(pos.Player)
+
+	var win, throw, give *Tile
+	for i := range pos.Legal {
+		t := &pos.Legal[i]
+		if throw == nil || cheap(*t) < cheap(*throw) {
+			throw = t
+		}
+
+		if give == nil || TexasCount(*t) > TexasCount(*give) {
+			give = t
+		}
+
+		if len(pos.Trick) == 0 {
+			if win == nil || r.strengthAsLead(*t) > r.strengthAsLead(*win) {
+				win = t
+			}
+			continue
+		}
+
+		r.Trick = append(pos.Trick, TexasPlay{Player: pos.Player, Tile: *t})
+		if r.Winning().Player == pos.Player && (win == nil || cheap(*t) < cheap(*win)) {
+			win = t
+		}
+	}
+
+	switch {
+	case partner:
+		return *give
+	case win != nil:
+		return *win
+	}
+
+	return *throw
+}
+
+// strengthAsLead rates a tile to lead: the strength it has in its own suit.
+func (r *TexasRound) strengthAsLead(t Tile) int {
+	led := r.Led
+	r.Led = r.SuitOf(t)
+	s := r.strength(t)
+	r.Led = led
+	return s
+}
@@ -0,0 +1,91 @@
+package engine
+
+import "testing"
+
+func TestTexasBidding(t *testing.T) {
+	r := NewTexasRound(make([][]Tile, 4), 3)
+	if err := r.MakeBid(29); err == nil {
+		t.Fatal("Expecting bids to start at 30")
+	}
+
+	r.MakeBid(31)
+	if err := r.MakeBid(31); err == nil {
+		t.Fatal("Expecting bids to top the highest bid")
+	}
+
+	r.MakeBid(TexasPass)
+	r.MakeBid(MarksBid)
+	if err := r.MakeBid(MarksBid); err == nil || r.MinNextBid() != TexasPass {
+		t.Fatal("Expecting only a pass after a bid of 2 marks")
+	}
+
+	if err := r.NameTrump(4); err == nil {
+		t.Fatal("Expecting trumps to wait for the end of the bidding")
+	}
+
+	r.MakeBid(TexasPass)
+	if r.Bidding() || r.Bid != MarksBid || r.Bidder != 2 || r.Turn != 2 {
+		t.Fatalf("Expecting player 3 to win the bidding for two marks but got %+v", r)
+	}
+
+	passed := NewTexasRound(make([][]Tile, 4), 1)
+	for i := 0; i < 4; i++ {
+		passed.MakeBid(TexasPass)
+	}
+
+	if passed.Bid != MinBid || passed.Bidder != 1 {
+		t.Fatalf("Expecting the dealer to take the hand at %d but got %+v", MinBid, passed)
+	}
+}
+
+func TestTexasTrick(t *testing.T) {
+	r := &TexasRound{
+		Hands: [][]Tile{
+			{{4, 6}},
+			{{6, 6}, {5, 5}},
+			{{1, 5}, {3, 3}},
+			{{0, 6}, {2, 2}},
+		},
+		Bids:   []int{30, 0, 0, 0},
+		Bid:    30,
+		Bidder: 0,
+		Trump:  5,
+	}
+
+	if TexasCount(Tile{4, 6}) != 10 || TexasCount(Tile{2, 3}) != 5 || TexasCount(Tile{6, 6}) != 0 {
+		t.Fatal("Expecting [5,5] and [6,4] to count 10 and the fives 5")
+	}
+
+	r.Play(Tile{4, 6})
+	if err := r.Play(Tile{5, 5}); err == nil {
+		t.Fatal("Expecting to follow the six led")
+	}
+
+	r.Play(Tile{6, 6})
+	if legal := r.LegalMoves(); len(legal) != 2 {
+		t.Fatalf("Expecting a player without sixes to play anything but got %v", legal)
+	}
+
+	r.Play(Tile{1, 5})
+	r.Play(Tile{0, 6})
+	if r.Turn != 2 || r.Points != [2]int{11, 0} {
+		t.Fatalf("Expecting the trump to take the trick and its count but got %+v", r.History[0])
+	}
+
+	if res := r.Result(); res.Points[0] != 0 || res.Points[1] != 1 || res.Points[3] != 1 {
+		t.Fatalf("Expecting the bidders to be set with 11 points but got %+v", res)
+	}
+}
+
+func TestTexasMatch(t *testing.T) {
+	rules, _ := LookupRuleset("texas-42")
+	m, err := PlayMatch(3, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	winners := m.Winners()
+	if !m.Over() || len(winners) != 2 || Team(winners[0]) != Team(winners[1]) || m.Scores[winners[0]] < rules.TargetScore {
+		t.Fatalf("Expecting a partnership to reach %d marks but got %v", rules.TargetScore, m.Scores)
+	}
+}
@@ -0,0 +1,437 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// TienKind is what kind of lead a Tien Gow play is.
+type TienKind int
+
+const (
+	TienSingle TienKind = iota
+	TienPair
+	// TienSequence is a civil pair of Heaven, Earth, Man or Goose led with
+	// one or both military tiles of its partner: nine, eight, seven or five
+	TienSequence
+)
+
+func (k TienKind) String() string {
+	switch k {
+	case TienPair:
+		return "pair"
+	case TienSequence:
+		return "sequence"
+	}
+
+	return "single"
+}
+
+// tienPartners maps Heaven, Earth, Man and Goose to the pips of the
+// military tiles they pair with.
+var tienPartners = map[int]int{1: 9, 2: 8, 3: 7, 4: 5}
+
+// TienLead is a set of tiles that may lead a trick. Only a lead of the
+// same kind and size, and for singles of the same suit, can beat it, by a
+// lower Rank.
+type TienLead struct {
+	Tiles []Tile
+	Kind  TienKind
+	Suit  Suit
+	Rank  int
+}
+
+func (l TienLead) String() string {
+	c, _ := Chinese(l.Tiles[0])
+	if l.Kind == TienSingle {
+		return fmt.Sprintf("%s %s", c.Suit, c.Name)
+	}
+
+	return fmt.Sprintf("%s of %d from %s", l.Kind, len(l.Tiles), c.Name)
+}
+
+// Beats reports whether l takes the trick from o.
+func (l TienLead) Beats(o TienLead) bool {
+	return l.Kind == o.Kind && len(l.Tiles) == len(o.Tiles) && l.Suit == o.Suit && l.Rank < o.Rank
+}
+
+// ClassifyTien tells what lead the tiles make, ok is false when they make
+// none. Civil singles rank like the Chinese set, military ones by their
+// pips. Pairs rank Gee Joon first, then the civil pairs, the military
+// pairs and last the mixed pairs of Heaven and a nine, Earth and an eight,
+// Man and a seven and Goose and a five.
+func ClassifyTien(tiles []Tile) (TienLead, bool) {
+	lead := TienLead{Tiles: append([]Tile(nil), tiles...)}
+	cs := []ChineseTile{}
+	for _, t := range tiles {
+		c, ok := Chinese(t)
+		if !ok {
+			return lead, false
+		}
+		cs = append(cs, c)
+	}
+
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Rank < cs[j].Rank })
+	switch len(cs) {
+	case 1:
+		lead.Kind, lead.Suit, lead.Rank = TienSingle, cs[0].Suit, cs[0].Rank
+		if cs[0].Suit == Military {
+			lead.Rank = 9 - cs[0].Tile.Pips()
+		}
+		return lead, true
+	case 2:
+		lead.Kind = TienPair
+		switch {
+		case cs[0].Rank == cs[1].Rank && (cs[0].Suit == Military || cs[0].Tile.Same(cs[1].Tile)):
+			lead.Rank = cs[0].Rank
+			return lead, true
+		case cs[1].Suit == Military && tienPartners[cs[0].Rank] == cs[1].Tile.Pips():
+			lead.Rank = 15 + cs[0].Rank
+			return lead, true
+		}
+	case 3, 4:
+		lead.Kind, lead.Rank = TienSequence, cs[0].Rank
+		pips, ok := tienPartners[cs[0].Rank]
+		if !ok || !cs[0].Tile.Same(cs[1].Tile) {
+			return lead, false
+		}
+
+		for _, c := range cs[2:] {
+			if c.Suit != Military || c.Tile.Pips() != pips {
+				return lead, false
+			}
+		}
+
+		return lead, true
+	}
+
+	return lead, false
+}
+
+// TienPlay is what a player put on a trick. A play that does not beat the
+// best one so far is a discard and is kept face down.
+type TienPlay struct {
+	Player  int
+	Tiles   []Tile
+	Discard bool
+}
+
+// TienTrick is a finished trick.
+type TienTrick struct {
+	Plays  []TienPlay
+	Winner int
+}
+
+// TienGowRound is a hand of Tien Gow: the Chinese set dealt out among four
+// players, eight tiles each. The leader of a trick plays a single tile, a
+// pair or a sequence and everybody else follows with as many tiles, beating
+// the best play so far or discarding. The best play takes the trick and
+// its tiles, and its player leads the next one.
+type TienGowRound struct {
+	Hands  [][]Tile
+	Dealer int
+	Turn   int
+	// Trick holds the plays of the trick being played, Best the index of
+	// the one taking it
+	Trick   []TienPlay
+	Best    int
+	best    TienLead
+	Won     []int
+	History []TienTrick
+}
+
+// DealTienGow deals a hand by the ruleset, the dealer moving on every hand
+// and leading the first trick.
+func DealTienGow(rng *rand.Rand, rules Ruleset, players, hand int) *TienGowRound {
+	tiles := rules.Set()
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	r := &TienGowRound{Dealer: hand % players, Won: make([]int, players)}
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-rules.HandSize:]...))
+		tiles = tiles[:len(tiles)-rules.HandSize]
+	}
+
+	r.Turn = r.Dealer
+	return r
+}
+
+// Leading reports whether the player on turn opens a trick.
+func (r *TienGowRound) Leading() bool {
+	return len(r.Trick) == 0
+}
+
+// Lead returns the best play of the trick so far.
+func (r *TienGowRound) Lead() TienLead {
+	return r.best
+}
+
+// Options lists what the player on turn may play: every lead when
+// leading, otherwise every set of as many tiles as the lead.
+func (r *TienGowRound) Options() [][]Tile {
+	hand := r.Hands[r.Turn]
+	if !r.Leading() {
+		return combinations(hand, len(r.best.Tiles))
+	}
+
+	options := [][]Tile{}
+	for n := 1; n <= 4 && n <= len(hand); n++ {
+		for _, c := range combinations(hand, n) {
+			if _, ok := ClassifyTien(c); ok {
+				options = append(options, c)
+			}
+		}
+	}
+
+	return options
+}
+
+// Beats reports whether the tiles would take the trick so far.
+func (r *TienGowRound) Beats(tiles []Tile) bool {
+	lead, ok := ClassifyTien(tiles)
+	return ok && !r.Leading() && lead.Beats(r.best)
+}
+
+// combinations returns every choice of n tiles of the hand, in hand order.
+func combinations(hand []Tile, n int) [][]Tile {
+	if n == 0 {
+		return [][]Tile{{}}
+	}
+
+	all := [][]Tile{}
+	for i := 0; i+n <= len(hand); i++ {
+		for _, rest := range combinations(hand[i+1:], n-1) {
+			all = append(all, append([]Tile{hand[i]}, rest...))
+		}
+	}
+
+	return all
+}
+
+// Play puts the tiles of the player on turn on the trick.
+func (r *TienGowRound) Play(tiles []Tile) error {
+	if r.Over() {
+		return fmt.Errorf("the hand is over")
+	}
+
+	hand := append([]Tile(nil), r.Hands[r.Turn]...)
+	for _, t := range tiles {
+		i := indexOf(hand, t)
+		if i < 0 {
+			return fmt.Errorf("player %d does not hold %v", r.Turn+1, tiles)
+		}
+		hand = append(hand[:i], hand[i+1:]...)
+	}
+
+	play := TienPlay{Player: r.Turn, Tiles: append([]Tile(nil), tiles...)}
+	if r.Leading() {
+		lead, ok := ClassifyTien(tiles)
+		if !ok {
+			return fmt.Errorf("%v can not lead a trick", tiles)
+		}
+		r.best, r.Best = lead, 0
+	} else if len(tiles) != len(r.best.Tiles) {
+		return fmt.Errorf("follow with %d tiles", len(r.best.Tiles))
+	} else if r.Beats(tiles) {
+		r.best, _ = ClassifyTien(tiles)
+		r.Best = len(r.Trick)
+	} else {
+		play.Discard = true
+	}
+
+	r.Hands[r.Turn] = hand
+	r.Trick = append(r.Trick, play)
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+	if len(r.Trick) == len(r.Hands) {
+		winner := r.Trick[r.Best].Player
+		r.Won[winner] += len(r.Trick) * len(tiles)
+		r.History = append(r.History, TienTrick{Plays: r.Trick, Winner: winner})
+		r.Trick, r.Turn = nil, winner
+	}
+
+	return nil
+}
+
+func indexOf(hand []Tile, t Tile) int {
+	for i, h := range hand {
+		if h.Same(t) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Over reports whether every tile is played.
+func (r *TienGowRound) Over() bool {
+	for _, h := range r.Hands {
+		if len(h) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Result scores every player the tiles they took less the tiles they were
+// dealt, the players taking the most win.
+func (r *TienGowRound) Result() Result {
+	res := Result{Winner: -1, Turns: len(r.History), Pips: make([]int, len(r.Hands)), Points: make([]int, len(r.Hands))}
+	dealt := 0
+	for _, w := range r.Won {
+		dealt += w
+	}
+	dealt /= len(r.Hands)
+
+	best := 0
+	for i, w := range r.Won {
+		res.Points[i] = w - dealt
+		switch {
+		case w > best:
+			best, res.Winners = w, []int{i}
+		case w == best:
+			res.Winners = append(res.Winners, i)
+		}
+	}
+
+	res.Winner = res.Winners[0]
+	res.Reason = fmt.Sprintf("player %d takes %d tiles in %d tricks", res.Winner+1, best, len(r.History))
+	return res
+}
+
+// TienPosition is what a player sees of a Tien Gow round.
+type TienPosition struct {
+	Player  int
+	Hand    []Tile
+	Leading bool
+	Lead    TienLead
+	Won     []int
+	Options [][]Tile
+}
+
+// Position returns what the player on turn sees.
+func (r *TienGowRound) Position() TienPosition {
+	return TienPosition{
+		Player:  r.Turn,
+		Hand:    append([]Tile(nil), r.Hands[r.Turn]...),
+		Leading: r.Leading(),
+		Lead:    r.best,
+		Won:     append([]int(nil), r.Won...),
+		Options: r.Options(),
+	}
+}
+
+// Run plays the hand to the end with one strategy per seat. Strategies
+// that do not implement TienStrategy play like the balanced one.
+func (r *TienGowRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		if err := r.Play(ChooseTien(strategies[r.Turn], r.Position())); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// TienStrategy is implemented by strategies that know how to play Tien
+// Gow.
+type TienStrategy interface {
+	ChooseTien(pos TienPosition) []Tile
+}
+
+// ChooseTien asks the strategy what to play, falling back to the balanced
+// one.
+func ChooseTien(s Strategy, pos TienPosition) []Tile {
+	if ts, ok := s.(TienStrategy); ok {
+		return ts.ChooseTien(pos)
+	}
+
+	return balancedStrategy{}.ChooseTien(pos)
+}
+
+// tienWorth rates tiles for keeping them, lower being better: the sum of
+// their ranks in the Chinese set.
+func tienWorth(tiles []Tile) int {
+	worth := 0
+	for _, t := range tiles {
+		c, _ := Chinese(t)
+		worth += c.Rank
+	}
+
+	return worth
+}
+
+// beating returns the options that take the trick, or every option when
+// leading.
+func (pos TienPosition) beating() [][]Tile {
+	if pos.Leading {
+		return pos.Options
+	}
+
+	beat := [][]Tile{}
+	for _, o := range pos.Options {
+		if lead, ok := ClassifyTien(o); ok && lead.Beats(pos.Lead) {
+			beat = append(beat, o)
+		}
+	}
+
+	return beat
+}
+
+// cheapest returns the option giving up the least, by tienWorth.
+func cheapest(options [][]Tile) []Tile {
+	best := options[0]
+	for _, o := range options[1:] {
+		if tienWorth(o) > tienWorth(best) {
+			best = o
+		}
+	}
+
+	return best
+}
+
+func (s *randomStrategy) ChooseTien(pos TienPosition) []Tile {
+	return pos.Options[s.rng.Intn(len(pos.Options))]
+}
+
+// heavy leads and beats with as many tiles as it can, the strongest first
+func (heavyStrategy) ChooseTien(pos TienPosition) []Tile {
+	beat := pos.beating()
+	if len(beat) == 0 {
+		return cheapest(pos.Options)
+	}
+
+	best := beat[0]
+	for _, o := range beat[1:] {
+		if len(o) > len(best) || len(o) == len(best) && tienWorth(o) < tienWorth(best) {
+			best = o
+		}
+	}
+
+	return best
+}
+
+// balanced takes a trick as cheaply as it can and otherwise discards its
+// weakest tiles; it leads its strongest single or pair
+func (balancedStrategy) ChooseTien(pos TienPosition) []Tile {
+	beat := pos.beating()
+	if len(beat) == 0 {
+		return cheapest(pos.Options)
+	}
+
+	if !pos.Leading {
+		return cheapest(beat)
+	}
+
+	best := beat[0]
+	for _, o := range beat[1:] {
+		if len(o) <= 2 && (len(best) > 2 || tienWorth(o)*len(best) < tienWorth(best)*len(o)) {
+			best = o
+		}
+	}
+
+	return best
+}
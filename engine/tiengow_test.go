@@ -0,0 +1,97 @@
+package engine
+
+import "testing"
+
+func TestClassifyTien(t *testing.T) {
+	leads := []struct {
+		tiles []Tile
+		kind  TienKind
+		ok    bool
+	}{
+		{[]Tile{{6, 6}}, TienSingle, true},
+		{[]Tile{{6, 6}, {6, 6}}, TienPair, true},
+		{[]Tile{{4, 5}, {3, 6}}, TienPair, true},
+		{[]Tile{{6, 6}, {4, 5}}, TienPair, true},
+		{[]Tile{{6, 6}, {3, 5}}, TienPair, false},
+		{[]Tile{{6, 6}, {6, 6}, {4, 5}}, TienSequence, true},
+		{[]Tile{{1, 1}, {1, 1}, {3, 5}, {2, 6}}, TienSequence, true},
+		{[]Tile{{1, 1}, {1, 1}, {4, 5}}, TienSequence, false},
+	}
+
+	for _, l := range leads {
+		lead, ok := ClassifyTien(l.tiles)
+		if ok != l.ok || ok && lead.Kind != l.kind {
+			t.Errorf("Expecting %v to be a %s lead: %v, but got %s: %v", l.tiles, l.kind, l.ok, lead.Kind, ok)
+		}
+	}
+
+	heaven, _ := ClassifyTien([]Tile{{6, 6}})
+	earth, _ := ClassifyTien([]Tile{{1, 1}})
+	nine, _ := ClassifyTien([]Tile{{4, 5}})
+	eight, _ := ClassifyTien([]Tile{{2, 6}})
+	if !heaven.Beats(earth) || earth.Beats(heaven) || nine.Beats(earth) || !nine.Beats(eight) {
+		t.Fatal("Expecting singles to beat only lower singles of their suit")
+	}
+
+	geeJoon, _ := ClassifyTien([]Tile{{1, 2}, {2, 4}})
+	pair, _ := ClassifyTien([]Tile{{6, 6}, {6, 6}})
+	mixed, _ := ClassifyTien([]Tile{{6, 6}, {3, 6}})
+	if !geeJoon.Beats(pair) || !pair.Beats(mixed) || mixed.Beats(heaven) {
+		t.Fatal("Expecting Gee Joon over the pair of Heaven over Heaven and a nine")
+	}
+}
+
+func TestTienGowTrick(t *testing.T) {
+	r := &TienGowRound{
+		Hands: [][]Tile{
+			{{1, 1}, {5, 5}},
+			{{6, 6}, {2, 2}},
+			{{4, 5}, {3, 3}},
+			{{4, 4}, {1, 6}},
+		},
+		Won: make([]int, 4),
+	}
+
+	if err := r.Play([]Tile{{1, 1}, {2, 2}}); err == nil {
+		t.Fatal("Expecting to only play tiles held")
+	}
+
+	r.Play([]Tile{{1, 1}})
+	if err := r.Play([]Tile{{6, 6}, {2, 2}}); err == nil {
+		t.Fatal("Expecting to follow with one tile")
+	}
+
+	r.Play([]Tile{{6, 6}})
+	r.Play([]Tile{{4, 5}})
+	r.Play([]Tile{{4, 4}})
+	if r.Turn != 1 || r.Won[1] != 4 || !r.History[0].Plays[2].Discard || !r.History[0].Plays[3].Discard {
+		t.Fatalf("Expecting Heaven to take the trick over the discards but got %+v", r.History[0])
+	}
+
+	for !r.Over() {
+		r.Play(r.Options()[0])
+	}
+
+	// Bench is led, Long beats it and Flower beats Long
+	res := r.Result()
+	if res.Points[0] != 2 || res.Points[1] != 2 || len(res.Winners) != 2 || r.History[1].Winner != 0 {
+		t.Fatalf("Expecting players 1 and 2 to share the tricks but got %+v", res)
+	}
+}
+
+func TestTienGowMatch(t *testing.T) {
+	rules, _ := LookupRuleset("tien-gow")
+	m, err := PlayMatch(5, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, s := range m.Scores {
+		total += s
+	}
+
+	if len(m.Hands) != rules.Hands || total != 0 {
+		t.Fatalf("Expecting %d hands where every tile won was lost by somebody but got %v", rules.Hands, m.Scores)
+	}
+}
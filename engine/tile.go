@@ -0,0 +1,41 @@
+package engine
+
+import "fmt"
+
+// Tile is a single domino identified by its two pip values.
+type Tile struct {
+	A int
+	B int
+}
+
+func (t Tile) IsDouble() bool {
+	return t.A == t.B
+}
+
+// Pips returns the total number of pips on the tile.
+func (t Tile) Pips() int {
+	return t.A + t.B
+}
+
+// Matches reports whether either half of the tile shows n.
+func (t Tile) Matches(n int) bool {
+	return t.A == n || t.B == n
+}
+
+// Other returns the value left open after matching n.
+func (t Tile) Other(n int) int {
+	if t.A == n {
+		return t.B
+	}
+
+	return t.A
+}
+
+// Same reports whether both tiles are the same domino regardless of orientation.
+func (t Tile) Same(o Tile) bool {
+	return (t.A == o.A && t.B == o.B) || (t.A == o.B && t.B == o.A)
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("[%d,%d]", t.A, t.B)
+}
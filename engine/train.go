@@ -0,0 +1,434 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Train is a line of play leaving the station double. Its tiles are
+// oriented so that the first one starts with the station pips and every
+// tile starts with the pips the previous one ends with.
+type Train struct {
+	// Owner is the seat the train belongs to, -1 for the Mexican train
+	Owner int
+	Tiles []Tile
+	// Open is set while the owner's marker is on the train and anybody may
+	// play on it. The Mexican train is always open.
+	Open bool
+}
+
+// Mexican reports whether this is the public train.
+func (t *Train) Mexican() bool {
+	return t.Owner < 0
+}
+
+// TrainMove puts a tile at the end of a train.
+type TrainMove struct {
+	Tile  Tile
+	Train int
+}
+
+// TrainEvent is one action of a train round: a move, a tile drawn from the
+// boneyard (kept in Move.Tile) or a pass that puts a marker on the player's
+// own train.
+type TrainEvent struct {
+	Player int
+	Move   TrainMove
+	Draw   bool
+	Pass   bool
+}
+
+// TrainRound is a hand of Mexican Train. Players add tiles to their own
+// train, to open trains of others and to the Mexican train. A player who
+// can not play draws once and, if that does not help, passes and opens
+// their train. A double has to be satisfied before play goes on anywhere
+// else, by the player who laid it or by whoever comes next.
+type TrainRound struct {
+	Station  Tile
+	Hands    [][]Tile
+	Boneyard []Tile
+	// Trains holds one train per seat and the Mexican train last
+	Trains []*Train
+	Turn   int
+	// Double is the train ending with a double still to be satisfied, or -1
+	Double  int
+	History []TrainEvent
+	drew    bool
+	passes  int
+}
+
+// DealTrains deals a hand of a train game by the ruleset. The station is
+// the double for the given hand number; the player after the dealer starts.
+func DealTrains(rng *rand.Rand, rules Ruleset, players, hand int) *TrainRound {
+	r := &TrainRound{Station: rules.Station(hand), Double: -1, Turn: hand % players}
+	tiles := []Tile{}
+	for _, t := range rules.Set() {
+		if !t.Same(r.Station) {
+			tiles = append(tiles, t)
+		}
+	}
+
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+
+	for i := 0; i < players; i++ {
+		r.Hands = append(r.Hands, append([]Tile(nil), tiles[len(tiles)-rules.HandSize:]...))
+		tiles = tiles[:len(tiles)-rules.HandSize]
+		r.Trains = append(r.Trains, &Train{Owner: i})
+	}
+
+	r.Trains = append(r.Trains, &Train{Owner: -1, Open: true})
+	r.Boneyard = tiles
+	return r
+}
+
+// End returns the pips a tile has to show to be added to the train.
+func (r *TrainRound) End(train int) int {
+	t := r.Trains[train]
+	if len(t.Tiles) == 0 {
+		return r.Station.A
+	}
+
+	return t.Tiles[len(t.Tiles)-1].B
+}
+
+// CanPlay reports whether the player may put the tile on the train.
+func (r *TrainRound) CanPlay(player int, m TrainMove) bool {
+	if m.Train < 0 || m.Train >= len(r.Trains) || !m.Tile.Matches(r.End(m.Train)) {
+		return false
+	}
+
+	if r.Double >= 0 {
+		return m.Train == r.Double
+	}
+
+	t := r.Trains[m.Train]
+	return t.Owner == player || t.Open
+}
+
+// LegalMoves lists every tile of the player's hand that fits a train they
+// may play on.
+func (r *TrainRound) LegalMoves(player int) []TrainMove {
+	moves := []TrainMove{}
+	for _, tile := range r.Hands[player] {
+		for i := range r.Trains {
+			if m := (TrainMove{Tile: tile, Train: i}); r.CanPlay(player, m) {
+				moves = append(moves, m)
+			}
+		}
+	}
+
+	return moves
+}
+
+// Play makes the move for the player on turn. Playing on one's own train
+// takes the marker off it. After a double the same player goes on to
+// satisfy it, otherwise the turn passes.
+func (r *TrainRound) Play(m TrainMove) error {
+	hand := r.Hands[r.Turn]
+	idx := -1
+	for i, t := range hand {
+		if t.Same(m.Tile) {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("%s is not in hand", m.Tile)
+	}
+
+	if !r.CanPlay(r.Turn, m) {
+		return fmt.Errorf("%s can not be played on train %d", m.Tile, m.Train+1)
+	}
+
+	tile := m.Tile
+	if tile.A != r.End(m.Train) {
+		tile = Tile{tile.B, tile.A}
+	}
+
+	train := r.Trains[m.Train]
+	train.Tiles = append(train.Tiles, tile)
+	if train.Owner == r.Turn {
+		train.Open = false
+	}
+
+	r.Hands[r.Turn] = append(hand[:idx:idx], hand[idx+1:]...)
+	r.History = append(r.History, TrainEvent{Player: r.Turn, Move: m})
+	r.passes = 0
+	r.Double = -1
+	if tile.IsDouble() {
+		r.Double = m.Train
+		r.drew = false
+		return nil
+	}
+
+	r.next()
+	return nil
+}
+
+// Draw takes a tile from the boneyard for the player on turn, which is
+// only allowed once a turn and when they have no move.
+func (r *TrainRound) Draw() (Tile, error) {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return Tile{}, fmt.Errorf("player %d has a move", r.Turn+1)
+	case r.drew:
+		return Tile{}, fmt.Errorf("player %d already drew this turn", r.Turn+1)
+	case len(r.Boneyard) == 0:
+		return Tile{}, fmt.Errorf("the boneyard is empty")
+	}
+
+	t := r.Boneyard[len(r.Boneyard)-1]
+	r.Boneyard = r.Boneyard[:len(r.Boneyard)-1]
+	r.Hands[r.Turn] = append(r.Hands[r.Turn], t)
+	r.History = append(r.History, TrainEvent{Player: r.Turn, Move: TrainMove{Tile: t, Train: -1}, Draw: true})
+	r.drew = true
+	return t, nil
+}
+
+// Pass ends the turn of a player who can not play and has drawn, or can
+// not draw, and puts their marker on their own train.
+func (r *TrainRound) Pass() error {
+	switch {
+	case len(r.LegalMoves(r.Turn)) > 0:
+		return fmt.Errorf("player %d has a move", r.Turn+1)
+	case !r.drew && len(r.Boneyard) > 0:
+		return fmt.Errorf("player %d has to draw first", r.Turn+1)
+	}
+
+	r.Trains[r.Turn].Open = true
+	r.History = append(r.History, TrainEvent{Player: r.Turn, Pass: true})
+	r.passes++
+	r.next()
+	return nil
+}
+
+func (r *TrainRound) next() {
+	r.Turn = (r.Turn + 1) % len(r.Hands)
+	r.drew = false
+}
+
+// Over reports whether a player has gone out, or every player passed in a
+// row with the boneyard empty.
+func (r *TrainRound) Over() bool {
+	for _, h := range r.Hands {
+		if len(h) == 0 {
+			return true
+		}
+	}
+
+	return len(r.Boneyard) == 0 && r.passes >= len(r.Hands)
+}
+
+// Result scores the hand by penalty: every player is charged the pips left
+// in their hand. The player who went out wins, a blocked hand is won by the
+// lowest hands.
+func (r *TrainRound) Result() Result {
+	return penaltyResult(r.Hands, Tile.Pips, len(r.History))
+}
+
+// TrainPosition is what a player sees of a train round.
+type TrainPosition struct {
+	Player  int
+	Station Tile
+	Hand    []Tile
+	Trains  []Train
+	Double  int
+	// HandSizes holds the number of tiles every seat holds
+	HandSizes []int
+	Boneyard  int
+	Legal     []TrainMove
+}
+
+// Position returns what the player sees.
+func (r *TrainRound) Position(player int) TrainPosition {
+	pos := TrainPosition{
+		Player:   player,
+		Station:  r.Station,
+		Hand:     append([]Tile(nil), r.Hands[player]...),
+		Double:   r.Double,
+		Boneyard: len(r.Boneyard),
+		Legal:    r.LegalMoves(player),
+	}
+
+	for _, t := range r.Trains {
+		pos.Trains = append(pos.Trains, Train{Owner: t.Owner, Tiles: append([]Tile(nil), t.Tiles...), Open: t.Open})
+	}
+
+	for _, h := range r.Hands {
+		pos.HandSizes = append(pos.HandSizes, len(h))
+	}
+
+	return pos
+}
+
+// Run plays the hand to the end with one strategy per seat. Strategies that
+// do not implement TrainStrategy play the balanced train moves.
+func (r *TrainRound) Run(strategies []Strategy) (Result, error) {
+	for !r.Over() {
+		pos := r.Position(r.Turn)
+		if len(pos.Legal) == 0 {
+			if _, err := r.Draw(); err != nil {
+				r.Pass()
+			}
+			continue
+		}
+
+		m, ok := ChooseTrain(strategies[r.Turn], pos)
+		if !ok {
+			return Result{}, fmt.Errorf("%s found no move for player %d", strategies[r.Turn].Name(), r.Turn)
+		}
+
+		if err := r.Play(m); err != nil {
+			return Result{}, fmt.Errorf("%s: %v", strategies[r.Turn].Name(), err)
+		}
+	}
+
+	return r.Result(), nil
+}
+
+// TrainStrategy is implemented by strategies that know how to play train
+// games.
+type TrainStrategy interface {
+	ChooseTrain(pos TrainPosition) (TrainMove, bool)
+}
+
+// ChooseTrain asks the strategy for a train move, falling back to the top
+// move of EvaluateTrains for strategies that only play the line game.
+func ChooseTrain(s Strategy, pos TrainPosition) (TrainMove, bool) {
+	if ts, ok := s.(TrainStrategy); ok {
+		return ts.ChooseTrain(pos)
+	}
+
+	return balancedStrategy{}.ChooseTrain(pos)
+}
+
+// ScoredTrainMove is a legal train move with its score and explanation.
+type ScoredTrainMove struct {
+	TrainMove
+	Score  float64
+	Reason string
+}
+
+// EvaluateTrains scores every legal move, best first. It sheds heavy tiles,
+// plays doubles it can follow itself, builds its own train and closes it
+// when a marker is on it.
+func EvaluateTrains(pos TrainPosition) []ScoredTrainMove {
+	scored := make([]ScoredTrainMove, 0, len(pos.Legal))
+	for _, m := range pos.Legal {
+		s := ScoredTrainMove{TrainMove: m, Score: float64(m.Tile.Pips())}
+		s.Reason = fmt.Sprintf("gets rid of %d pips", m.Tile.Pips())
+		train := pos.Trains[m.Train]
+		switch {
+		case pos.Double >= 0:
+			s.Reason = "satisfies the open double"
+		case train.Owner == pos.Player && train.Open:
+			s.Score += 8
+			s.Reason = "takes the marker off your train"
+		case train.Owner == pos.Player:
+			s.Score += 3
+			s.Reason = "builds your own train"
+		case train.Mexican():
+			s.Score++
+		}
+
+		if m.Tile.IsDouble() {
+			follow := 0
+			for _, t := range pos.Hand {
+				if !t.Same(m.Tile) && t.Matches(m.Tile.A) {
+					follow++
+				}
+			}
+
+			if follow > 0 {
+				s.Score += 6
+				s.Reason = fmt.Sprintf("plays the double %s and can satisfy it", m.Tile)
+			} else {
+				s.Score -= 6
+			}
+		}
+
+		scored = append(scored, s)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	return scored
+}
+
+func (s *randomStrategy) ChooseTrain(pos TrainPosition) (TrainMove, bool) {
+	if len(pos.Legal) == 0 {
+		return TrainMove{}, false
+	}
+
+	return pos.Legal[s.rng.Intn(len(pos.Legal))], true
+}
+
+func (heavyStrategy) ChooseTrain(pos TrainPosition) (TrainMove, bool) {
+	if len(pos.Legal) == 0 {
+		return TrainMove{}, false
+	}
+
+	best := pos.Legal[0]
+	for _, m := range pos.Legal[1:] {
+		if m.Tile.Pips() > best.Tile.Pips() {
+			best = m
+		}
+	}
+
+	return best, true
+}
+
+func (balancedStrategy) ChooseTrain(pos TrainPosition) (TrainMove, bool) {
+	scored := EvaluateTrains(pos)
+	if len(scored) == 0 {
+		return TrainMove{}, false
+	}
+
+	return scored[0].TrainMove, true
+}
+
+// penaltyResult charges every player the value of the tiles left in their
+// hand and lets the player who went out, or the lowest hands of a blocked
+// round, win.
+func penaltyResult(hands [][]Tile, value func(Tile) int, turns int) Result {
+	res := Result{Winner: -1, Blocked: true, Turns: turns}
+	least := -1
+	for _, h := range hands {
+		pips := 0
+		for _, t := range h {
+			pips += value(t)
+		}
+
+		res.Pips = append(res.Pips, pips)
+		if len(h) == 0 {
+			res.Blocked = false
+		}
+
+		if least < 0 || pips < least {
+			least = pips
+		}
+	}
+
+	// a player who went out wins alone even against a hand counting nothing
+	for i, pips := range res.Pips {
+		if res.Blocked && pips == least || !res.Blocked && len(hands[i]) == 0 {
+			res.Winners = append(res.Winners, i)
+		}
+	}
+
+	res.Winner = res.Winners[0]
+	res.Points = append([]int(nil), res.Pips...)
+	if res.Blocked {
+		res.Reason = fmt.Sprintf("blocked, lowest hand with %d pips", least)
+	} else {
+		res.Reason = fmt.Sprintf("player %d went out", res.Winner+1)
+	}
+
+	return res
+}
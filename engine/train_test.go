@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func trainRound() *TrainRound {
+	r := &TrainRound{Station: Tile{12, 12}, Double: -1}
+	r.Hands = [][]Tile{{{12, 5}, {5, 5}, {3, 4}}, {{12, 1}, {2, 2}}}
+	r.Trains = []*Train{{Owner: 0}, {Owner: 1}, {Owner: -1, Open: true}}
+	r.Boneyard = []Tile{{9, 9}}
+	return r
+}
+
+func TestTrainPlay(t *testing.T) {
+	r := trainRound()
+
+	if r.CanPlay(0, TrainMove{Tile: Tile{12, 5}, Train: 1}) {
+		t.Fatalf("Expecting player 2's train to be closed to player 1")
+	}
+
+	if err := r.Play(TrainMove{Tile: Tile{12, 5}, Train: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Turn != 1 || r.End(0) != 5 {
+		t.Fatalf("Expecting turn to pass and train 1 to end with 5 but got turn %d, end %d", r.Turn, r.End(0))
+	}
+
+	// player 2 opens the Mexican train, then player 1 plays a double
+	if err := r.Play(TrainMove{Tile: Tile{1, 12}, Train: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Play(TrainMove{Tile: Tile{5, 5}, Train: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Turn != 0 || r.Double != 0 {
+		t.Fatalf("Expecting player 1 to satisfy the double on train 1 but got turn %d, double %d", r.Turn, r.Double)
+	}
+
+	// [3,4] does not satisfy the double: draw, then pass and open the train
+	if _, err := r.Draw(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Pass(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Trains[0].Open || r.Turn != 1 {
+		t.Fatalf("Expecting a marker on train 1 and player 2 on turn")
+	}
+
+	if moves := r.LegalMoves(1); len(moves) != 0 {
+		t.Fatalf("Expecting player 2 to be bound to the double but got %v", moves)
+	}
+
+	if err := r.Pass(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Pass()
+	if !r.Over() || r.Result().Winner != 1 {
+		t.Fatalf("Expecting a blocked hand won by player 2 but got %+v", r.Result())
+	}
+}
+
+func TestTrainOutBeatsDoubleBlank(t *testing.T) {
+	r := trainRound()
+	r.Hands = [][]Tile{{{0, 0}}, {}, {{6, 6}}}
+	res := r.Result()
+	if len(res.Winners) != 1 || res.Winner != 1 || res.Points[0] != 0 || res.Reason != "player 2 went out" {
+		t.Fatalf("Expecting player 2 to win alone for going out but got %+v", res)
+	}
+}
+
+func TestTrainMatch(t *testing.T) {
+	rules, _ := LookupRuleset("mexican-train")
+	r := DealTrains(rand.New(rand.NewSource(1)), rules, 4, 1)
+	if r.Station != (Tile{11, 11}) || len(r.Boneyard) != 91-1-4*15 {
+		t.Fatalf("Expecting station [11,11] and %d tiles in the boneyard but got %s and %d", 91-1-60, r.Station, len(r.Boneyard))
+	}
+
+	m, err := PlayMatch(3, rules, []string{"balanced", "heavy", "random", "balanced"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Hands) != 13 || len(m.Winners()) == 0 {
+		t.Fatalf("Expecting 13 hands and a winner but got %d hands", len(m.Hands))
+	}
+
+	for _, w := range m.Winners() {
+		for _, s := range m.Scores {
+			if s < m.Scores[w] {
+				t.Fatalf("Expecting the lowest penalty to win but got %v", m.Scores)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+#!/usr/bin/env python3
+"""Minimal external domino bot: plays the legal tile with the most pips.
+
+Run it from the game with
+    go run ./cmd/standalone -players "human,cpu:balanced,external:python3 examples/bot.py"
+"""
+import sys
+
+
+def pips(tile):
+    a, b = tile.split("-")
+    return int(a) + int(b)
+
+
+def main():
+    legal = []
+    for line in sys.stdin:
+        fields = line.split()
+        if not fields:
+            continue
+
+        if fields[0] == "domino":
+            print("ready heavy.py", flush=True)
+        elif fields[0] == "legal":
+            legal = [m.split(":") for m in fields[1:]]
+        elif fields[0] == "go":
+            tile, end = max(legal, key=lambda m: pips(m[0]))
+            print("move %s %s %s" % (tile, end, fields[2]), flush=True)
+        elif fields[0] == "quit":
+            return
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,138 @@
+package domino
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// FootBoard draws a chicken foot layout as a tree: the centre double at the
+// root and every tile below the one it was played on. Doubles show how many
+// toes they still need and open ends are marked.
+type FootBoard struct {
+	*tview.TreeView
+}
+
+func NewFootBoard() *FootBoard {
+	board := &FootBoard{TreeView: tview.NewTreeView()}
+	board.SetBorder(true).SetTitle("Chicken foot")
+	return board
+}
+
+// Refresh redraws the layout, highlighting the selected node.
+func (b *FootBoard) Refresh(r *engine.FootRound, selected int) {
+	b.SetTitle(fmt.Sprintf("Chicken foot [boneyard %d]", len(r.Boneyard)))
+	ends := map[int]bool{}
+	for _, e := range r.Ends() {
+		ends[e] = true
+	}
+
+	var build func(i int) *tview.TreeNode
+	build = func(i int) *tview.TreeNode {
+		n := r.Nodes[i]
+		text := tview.Escape(n.Tile.String())
+		color := tcell.ColorWhite
+		if n.Tile.IsDouble() {
+			text += fmt.Sprintf(" %d/%d", len(n.Children), r.Capacity(i))
+		}
+
+		switch {
+		case i == r.Pending():
+			color = tcell.ColorRed
+			text += " must be filled"
+		case ends[i]:
+			color = tcell.ColorGreen
+			text += fmt.Sprintf(" open %d", n.Tile.B)
+		}
+
+		node := tview.NewTreeNode(text).SetColor(color).SetSelectable(false)
+		if i == selected {
+			node.SetColor(tcell.ColorYellow)
+			node.SetText("> " + text)
+		}
+
+		for _, c := range n.Children {
+			node.AddChild(build(c))
+		}
+
+		return node
+	}
+
+	b.SetRoot(build(0))
+}
+
+// footLayout plays a FootRound in a BranchGame, the targets are the nodes
+// that take a tile.
+type footLayout struct {
+	*engine.FootRound
+	view  *FootBoard
+	names []string
+}
+
+func newFootLayout(r *engine.FootRound, names []string) *footLayout {
+	return &footLayout{FootRound: r, view: NewFootBoard(), names: names}
+}
+
+func (l *footLayout) turn() int { return l.Turn }
+
+func (l *footLayout) hand(player int) []engine.Tile { return l.Hands[player] }
+
+func (l *footLayout) legal(player int) []placement {
+	moves := []placement{}
+	for _, m := range l.LegalMoves(player) {
+		moves = append(moves, placement{tile: m.Tile, target: m.Node})
+	}
+
+	return moves
+}
+
+func (l *footLayout) play(p placement) error {
+	return l.Play(engine.FootMove{Tile: p.tile, Node: p.target})
+}
+
+func (l *footLayout) targets() []int { return l.Ends() }
+
+func (l *footLayout) targetName(node int) string {
+	if node == 0 {
+		return "the centre"
+	}
+
+	return tview.Escape(l.Nodes[node].Tile.String())
+}
+
+func (l *footLayout) after(p placement) string {
+	if p.tile.IsDouble() && l.Pending() == len(l.Nodes)-1 {
+		return fmt.Sprintf("Chicken foot! %s needs %d tiles before play goes on", tview.Escape(p.tile.String()), engine.FootToes)
+	}
+
+	return ""
+}
+
+func (l *footLayout) hint() (placement, string, bool) {
+	scored := engine.EvaluateFoot(l.Position(l.Turn))
+	if len(scored) == 0 {
+		return placement{}, "", false
+	}
+
+	return placement{tile: scored[0].Tile, target: scored[0].Node}, scored[0].Reason, true
+}
+
+func (l *footLayout) chooser(s engine.Strategy) func() (placement, bool) {
+	pos := l.Position(l.Turn)
+	return func() (placement, bool) {
+		m, ok := engine.ChooseFoot(s, pos)
+		return placement{tile: m.Tile, target: m.Node}, ok
+	}
+}
+
+func (l *footLayout) board() tview.Primitive { return l.view }
+
+func (l *footLayout) refresh(selected int) {
+	l.view.Refresh(l.FootRound, selected)
+}
+
+func (l *footLayout) describe() string {
+	return fmt.Sprintf("around the centre %s", tview.Escape(l.Nodes[0].Tile.String()))
+}
@@ -2,9 +2,13 @@ package domino
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/rating"
 	"github.com/rivo/tview"
 )
 
@@ -13,35 +17,62 @@ type Game struct {
 	App     *tview.Application
 	Players []*Player
 	Deck    *Deck
-
-	head           *Card
-	tail           *Card
-	last           *Card //last played card
-	headView       *tview.Flex
-	tailView       *tview.Flex
-	statusView     *tview.TextView
-	size           int
-	playedCardTail int
-	playedCardHead int
-	currentPlayer  int
-	log            *LogWindow
-	finish         bool
+	// LegalOnlyNavigation restricts card selection to cards that can be played
+	LegalOnlyNavigation bool
+	// Profiles, when set, receives the result of every finished game
+	Profiles *profile.Store
+	// OnEnd, when set, is called with the finished match and Enter then
+	// stops the application so the caller can move on
+	OnEnd func(*engine.Match)
+
+	// rules is the ruleset the game is played by, see SetRules
+	rules engine.Ruleset
+	match *engine.Match
+	// round holds the rules state, the cards below only display it
+	round       *engine.Round
+	head        *Card
+	tail        *Card
+	last        *Card //last played card
+	headView    *tview.Flex
+	tailView    *tview.Flex
+	statusView  *tview.TextView
+	log         *LogWindow
+	finish      bool
+	hint        *engine.ScoredMove
+	pages       *tview.Pages
+	stats       *StatsView
+	leaderboard *LeaderboardView
+	analysis    *AnalysisView
+	hand        int
+	header      *tview.Flex
+	// model is the opponent model panel, shown while showModel is set
+	model     *tview.TextView
+	showModel bool
+	// opening is the round as it stood once opened, the analysis replays
+	// the hand from it
+	opening *engine.Round
+	// puzzle is the puzzle being solved, see LoadPuzzle, and slipped tells
+	// the human has already let its win slip
+	puzzle  *engine.Puzzle
+	slipped bool
 }
 
 func NewGame() *Game {
 
 	game := &Game{
-		Flex:          tview.NewFlex().SetDirection(tview.FlexRow),
-		headView:      tview.NewFlex(),
-		tailView:      tview.NewFlex(),
-		size:          0,
-		currentPlayer: -1,
+		Flex:     tview.NewFlex().SetDirection(tview.FlexRow),
+		rules:    engine.DefaultRuleset,
+		round:    engine.DefaultRuleset.NewRound(),
+		headView: tview.NewFlex(),
+		tailView: tview.NewFlex(),
+		pages:    tview.NewPages(),
 	}
 
 	game.log = NewLogWindow(game)
 
 	// setup UI & layout
 	header := tview.NewFlex()
+	game.header = header
 	header.AddItem(game.headView, 0, 1, false)
 	game.headView.SetBorder(true).SetTitle("Head[First 3 Cards]")
 	header.AddItem(game.tailView, 0, 1, false)
@@ -55,13 +86,111 @@ func NewGame() *Game {
 	logPanel.AddItem(game.log, 0, 1, false)
 	logPanel.AddItem(game.statusView, 1, 1, false)
 	header.AddItem(logPanel, 0, 1, false)
+	game.model = tview.NewTextView().SetDynamicColors(true)
+	game.model.SetBorder(true).SetTitle("Opponent model")
+	header.AddItem(game.model, 0, 0, false)
 
 	// init deck and suffle cards
 	game.Deck = NewDeck(game)
 	game.Deck.Shuffle()
 
+	game.stats = NewStatsView(nil)
+	game.stats.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 's' {
+			game.showPage("game")
+			return nil
+		}
+
+		return event
+	})
+	game.leaderboard = NewLeaderboardView(nil)
+	game.leaderboard.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'r':
+			game.showPage("game")
+			return nil
+		case event.Rune() == 'e' || event.Rune() == 'j':
+			format := "csv"
+			if event.Rune() == 'j' {
+				format = "json"
+			}
+
+			if path, err := game.leaderboard.Export(format); err != nil {
+				game.leaderboard.SetTitle(fmt.Sprintf("Leaderboard [export failed: %v]", err))
+			} else {
+				game.leaderboard.SetTitle(fmt.Sprintf("Leaderboard [exported to %s]", path))
+			}
+			return nil
+		}
+
+		return event
+	})
+	game.analysis = NewAnalysisView()
+	game.analysis.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape || event.Rune() == 'a':
+			game.showPage("game")
+			return nil
+		case event.Rune() == 't' || event.Rune() == 'w':
+			format, dir := "txt", "."
+			if event.Rune() == 'w' {
+				format = "html"
+			}
+			if game.Profiles != nil {
+				dir = game.Profiles.Dir()
+			}
+
+			if path, err := game.analysis.Export(dir, format); err != nil {
+				game.analysis.SetTitle(fmt.Sprintf("Analysis [export failed: %v]", err))
+			} else {
+				game.analysis.SetTitle(fmt.Sprintf("Analysis [exported to %s]", path))
+			}
+			return nil
+		}
+
+		return event
+	})
+	game.pages.AddPage("game", game, true, true)
+	game.pages.AddPage("stats", game.stats, true, false)
+	game.pages.AddPage("leaderboard", game.leaderboard, true, false)
+	game.pages.AddPage("analysis", game.analysis, true, false)
+
 	game.Log("Waiting for players...")
 	game.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 's' {
+			game.showPage("stats")
+			return nil
+		}
+
+		if event.Key() == tcell.KeyRune && event.Rune() == 'r' {
+			game.showPage("leaderboard")
+			return nil
+		}
+
+		if game.finish && game.puzzle == nil && event.Key() == tcell.KeyRune && event.Rune() == 'a' {
+			game.showPage("analysis")
+			return nil
+		}
+
+		if game.puzzle != nil && event.Key() == tcell.KeyRune && event.Rune() == 'n' {
+			game.LoadPuzzle(*game.puzzle, game.Players[game.puzzle.Turn].name)
+			return nil
+		}
+
+		if game.finish && game.OnEnd != nil && game.match.Over() && event.Key() == tcell.KeyEnter {
+			game.App.Stop()
+			return nil
+		}
+
+		if game.finish && (game.OnEnd == nil || !game.match.Over()) && event.Key() == tcell.KeyRune && event.Rune() == 'n' {
+			if game.match.Over() {
+				game.newMatch()
+			}
+
+			game.nextHand()
+			return nil
+		}
+
 		if game.CurrentPlayer() == nil || game.finish {
 			return event
 		}
@@ -78,6 +207,20 @@ func NewGame() *Game {
 			player.selectCard(true)
 		case tcell.KeyEnter:
 			game.update()
+		case tcell.KeyUp:
+			game.updateOn(engine.Head)
+		case tcell.KeyDown:
+			game.updateOn(engine.Tail)
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'h':
+				game.showHint()
+			case 'l':
+				game.LegalOnlyNavigation = !game.LegalOnlyNavigation
+				game.Log(fmt.Sprintf("Legal-only navigation: %t", game.LegalOnlyNavigation))
+			case 'o':
+				game.toggleModel()
+			}
 		}
 
 		return event
@@ -91,118 +234,283 @@ func (g *Game) updateStatusView() {
 		return
 	}
 
-	g.statusView.SetText(fmt.Sprintf("[black::b][CURRENT_PLAYER:[%s]%s][black::b] [HEAD:%d] [TAIL:%d]", g.CurrentPlayer().color, g.CurrentPlayer().name, g.playedCardHead, g.playedCardTail))
+	g.statusView.SetText(fmt.Sprintf("[black::b][CURRENT_PLAYER:[%s]%s][black::b] [HEAD:%d] [TAIL:%d]", g.CurrentPlayer().color, g.CurrentPlayer().name, g.round.Head(), g.round.Tail()))
+	g.refreshModel()
 }
 
-func (g *Game) Join(playerName string, isCpu bool) {
-	if len(g.Players) >= 3 {
-		g.Log(fmt.Sprintf("Can't join player: %s to game. Players already full ", playerName))
+// toggleModel shows or hides the opponent model panel.
+func (g *Game) toggleModel() {
+	g.showModel = !g.showModel
+	proportion := 0
+	if g.showModel {
+		proportion = 1
+	}
+
+	g.header.ResizeItem(g.model, 0, proportion)
+	g.refreshModel()
+}
+
+// refreshModel tells the first human player what the others have shown to
+// lack and which unseen tiles each of them may still hold.
+func (g *Game) refreshModel() {
+	if !g.showModel {
 		return
 	}
 
-	player := NewPlayer(g, playerName, isCpu)
-	player.isCpu = isCpu
-	if player.isCpu {
-		player.SetFocusFunc(func() {
-			if !player.HasPlayableCards() {
-				return
+	viewer := -1
+	for i, p := range g.Players {
+		if !p.isCpu {
+			viewer = i
+			break
+		}
+	}
+
+	g.model.Clear()
+	if viewer < 0 {
+		g.model.SetText("No human player to infer for")
+		return
+	}
+
+	in := g.round.Infer(viewer, g.rules.Set())
+	text := fmt.Sprintf("%d tiles unseen by %s\n", len(in.Unseen), g.Players[viewer].name)
+	for i, p := range g.Players {
+		if i == viewer {
+			continue
+		}
+
+		lacks := "nothing shown"
+		if len(in.Lacks[i]) > 0 {
+			lacks = fmt.Sprintf("lacks %v", in.Lacks[i])
+		}
+
+		text += fmt.Sprintf("\n[%s]%s[white]: %d tiles, %s\n", p.color, p.name, len(g.round.Hands[i]), lacks)
+		if len(in.Lacks[i]) > 0 {
+			may := ""
+			for _, t := range in.Possible(i) {
+				may += fmt.Sprintf("%d-%d ", t.A, t.B)
 			}
+			text += fmt.Sprintf("  may hold %s\n", may)
+		}
+	}
 
-			go func() {
-				ch := make(chan struct{})
-				for {
-					select {
-					case <-ch:
-						goto finish
-					default:
-						g.App.QueueUpdateDraw(func() {
-
-							selected := player.selectCard(false)
-							time.Sleep(1000 * time.Millisecond)
-							if g.validCard(selected) {
-								g.update()
-								close(ch)
-							}
-						})
-					}
-				finish:
-				}
-			}()
+	g.model.SetText(text)
+}
 
-		})
+// SetRules changes the ruleset the game is played by and shuffles a deck of
+// its set. It has to be called before the first player joins.
+func (g *Game) SetRules(rules engine.Ruleset) {
+	g.rules = rules
+	g.round = rules.NewRound()
+	g.Deck = NewDeck(g)
+	g.Deck.Shuffle()
+}
 
-		player.SetBlurFunc(func() {
-			time.Sleep(1 * time.Second)
-		})
+// Rules returns the ruleset the game is played by.
+func (g *Game) Rules() engine.Ruleset {
+	return g.rules
+}
+
+func (g *Game) Join(playerName string, isCpu bool) {
+	g.join(NewPlayer(g, playerName, isCpu))
+}
+
+// JoinCpu adds a CPU player using the named strategy.
+func (g *Game) JoinCpu(playerName string, strategy string) error {
+	s, err := engine.NewStrategy(strategy)
+	if err != nil {
+		return err
 	}
 
-	player.AssignCards(g.Deck.PopCards(5))
+	player := NewPlayer(g, playerName, true)
+	player.strategy = s
+	g.join(player)
+	return nil
+}
+
+// JoinExternal adds a CPU player driven by an external bot program, see
+// engine.ExternalStrategy for the protocol it has to speak.
+func (g *Game) JoinExternal(playerName string, command string) error {
+	return g.JoinCpu(playerName, engine.ExternalPrefix+command)
+}
+
+func (g *Game) join(player *Player) {
+	if len(g.Players) >= g.rules.Players {
+		g.Log(fmt.Sprintf("Can't join player: %s to game. Players already full ", player.name))
+		return
+	}
+
+	if player.isCpu {
+		g.cpuFocus(player)
+	}
+
+	player.AssignCards(g.Deck.PopCards(g.rules.HandSize))
+	player.seat = g.round.AddHand(player.tiles())
 	g.Players = append(g.Players, player)
-	g.Log(fmt.Sprintf("%s joined", playerName))
+	g.Log(fmt.Sprintf("%s joined", player.name))
 
 	g.AddItem(player, 0, 1, false)
 
 	// players acquired, start game
-	if len(g.Players) == 3 {
+	if len(g.Players) == g.rules.Players {
 		g.start()
 	}
 }
 
-func (g *Game) start() {
-	g.App = tview.NewApplication()
-	var firstCard []*Card
-	for firstCard = g.Deck.PopCards(1); firstCard != nil; firstCard = g.Deck.PopCards(1) {
-		playable := 0
+// cpuFocus makes the CPU player choose and play its move whenever it gets
+// the focus.
+func (g *Game) cpuFocus(player *Player) {
+	player.SetFocusFunc(func() {
+		if !player.HasPlayableCards() {
+			return
+		}
 
-		for _, p := range g.Players {
-			b := p.IsPlayableFor(firstCard[0])
-			if b {
-				playable++
+		// the strategy may be an external bot taking its time, so it
+		// thinks outside the UI thread on a copy of the position, or of
+		// the whole round when it peeks
+		pos := g.position(player)
+		if p, ok := player.strategy.(engine.Peeker); ok {
+			p.Peek(g.round.Clone())
+		}
+		ext, _ := player.strategy.(*engine.ExternalStrategy)
+		go func() {
+			start, errs := time.Now(), 0
+			if ext != nil {
+				errs = len(ext.Errors)
 			}
 
-		}
+			move, ok := player.strategy.Choose(pos)
+			time.Sleep(1000*time.Millisecond - time.Since(start))
+			g.App.QueueUpdateDraw(func() {
+				if name, _ := g.pages.GetFrontPage(); name != "game" {
+					// resumed when the game page gets the focus back
+					return
+				}
 
-		if playable == len(g.Players) {
-			g.playCard(firstCard[0])
-			g.nextPlayer()
-			g.Log(fmt.Sprintf("Game Initiated with card [%d,%d]", firstCard[0].X, firstCard[0].Y))
-			g.updateStatusView()
-			return
+				if !ok || g.CurrentPlayer() != player || g.finish {
+					return
+				}
+
+				if ext != nil && len(ext.Errors) > errs {
+					player.Log(fmt.Sprintf("bot error: %v, playing %s instead", ext.Errors[len(ext.Errors)-1], move))
+				}
+
+				player.selectedCard = player.cardIndex(move.Tile)
+				g.updateOn(move.End)
+			})
+		}()
+	})
+}
+
+// Started reports whether the game could be opened with a first card.
+func (g *Game) Started() bool {
+	return g.round.Started()
+}
+
+func (g *Game) start() {
+	g.App = tview.NewApplication()
+	g.match = engine.NewMatch(g.rules, len(g.Players))
+	g.open()
+}
+
+// open plays the opening of a freshly dealt hand according to the opening rule.
+func (g *Game) open() {
+	g.hand++
+	g.buyIn()
+	g.round.Boneyard = g.Deck.Tiles()
+	if !g.round.Open(g.rules.Opening, g.match.LastWinner()) {
+		g.finish = true
+		g.Log("Can not start game. Could not initiate playable card")
+		return
+	}
+	g.opening = g.round.Clone()
+
+	// keep the deck in step with the tiles turned for the opening, the
+	// ones drawn by players are handed out after the opening is shown
+	drawn := 0
+	for _, e := range g.round.History {
+		if e.Draw {
+			drawn++
 		}
 	}
+	g.Deck.PopCards(g.Deck.GetNum() - len(g.round.Boneyard) - drawn)
+	if g.round.Started() {
+		first := g.round.Line[0]
+		if len(g.round.History) > 0 {
+			leader := g.Players[g.round.History[0].Player]
+			leader.selectedCard = leader.cardIndex(first)
+			leader.PlayCard()
+			g.Log(fmt.Sprintf("Hand %d opened by %s with %s", g.hand, leader.name, first))
+		} else {
+			g.Log(fmt.Sprintf("Hand %d initiated with card %s", g.hand, first))
+		}
 
-	g.finish = true
-	g.Log("Can not start game. Could not initiate playable card")
+		g.showPlayed(NewCard(first.A, first.B), engine.Head)
+	} else {
+		g.Log(fmt.Sprintf("Hand %d: %s leads with any card", g.hand, g.CurrentPlayer().name))
+	}
+
+	g.logTurns(0)
+	g.Log("Keys: Left/Right select, Enter play, Up/Down play on head/tail, h hint, l legal-only navigation, o opponent model, s statistics, r leaderboard")
+	if g.isFinish() {
+		// nobody holds a tile for the opening one
+		g.end()
+	} else {
+		g.startTurn(nil)
+	}
+	g.updateStatusView()
 }
 
-func (g *Game) playCard(card *Card) bool {
-	isAppend := false
-	switch {
-	case g.size == 0:
-		g.playedCardHead = card.X
-		g.playedCardTail = card.Y
+// nextHand deals a new hand to the same players.
+func (g *Game) nextHand() {
+	g.Deck = NewDeck(g)
+	g.Deck.Shuffle()
+	g.round = g.rules.NewRound()
+	g.head, g.tail, g.last, g.hint = nil, nil, nil, nil
+	g.headView.Clear()
+	g.tailView.Clear()
+	g.finish = false
 
-	case g.size >= 1:
-		if g.playedCardHead == card.X {
-			g.playedCardHead = card.Y
-			card.Flip()
-		} else if g.playedCardHead == card.Y {
-			g.playedCardHead = card.X
-		} else if g.playedCardTail == card.X {
-			g.playedCardTail = card.Y
-			isAppend = true
-		} else if g.playedCardTail == card.Y {
-			g.playedCardTail = card.X
-			isAppend = true
-			card.Flip()
-		}
+	for _, p := range g.Players {
+		p.AssignCards(g.Deck.PopCards(g.rules.HandSize))
+		p.seat = g.round.AddHand(p.tiles())
+		p.selectedCard = -1
+		p.SetBorderColor(tcell.ColorWhite)
+	}
 
+	g.open()
+}
+
+// newMatch resets the scores for a new match with the same players.
+func (g *Game) newMatch() {
+	g.match = engine.NewMatch(g.rules, len(g.Players))
+	g.Log(fmt.Sprintf("New match of %s", g.rules.Name))
+}
+
+// playCard places the card on the head when it fits there, otherwise on the tail.
+func (g *Game) playCard(card *Card) bool {
+	if g.round.Started() && !g.canPlayOn(card, engine.Head) {
+		return g.playCardOn(card, engine.Tail)
 	}
 
+	return g.playCardOn(card, engine.Head)
+}
+
+// playCardOn puts a card on the line for nobody and reports whether the
+// game is finished.
+func (g *Game) playCardOn(card *Card, end engine.End) bool {
+	placed := g.round.Place(card.Tile(), end)
+	card.X, card.Y = placed.A, placed.B
+	g.showPlayed(card, end)
+	return g.isFinish()
+}
+
+// showPlayed adds a card already placed in the round to the head and tail views.
+func (g *Game) showPlayed(card *Card, end engine.End) {
+	card.SetTitle(fmt.Sprintf("[%d,%d]", card.X, card.Y))
 	if g.head == nil {
 		g.head, g.tail = card, card
 	} else {
-		if isAppend {
+		if end == engine.Tail {
 			card.prev = g.tail
 			g.tail.next = card
 			g.tail = card
@@ -214,7 +522,6 @@ func (g *Game) playCard(card *Card) bool {
 		}
 	}
 
-	g.size++
 	g.headView.Clear()
 	next := g.head
 	for i := 0; next != nil && i < 3; i++ {
@@ -239,78 +546,158 @@ func (g *Game) playCard(card *Card) bool {
 
 	g.last = card
 	g.last.Highlight()
-
-	return g.isFinish()
 }
 
 func (g *Game) validCard(card *Card) bool {
+	return g.canPlayOn(card, engine.Head) || g.canPlayOn(card, engine.Tail)
+}
 
-	switch {
-	case card.Played:
-		return false
-	case g.size == 0:
-		return true
-	case g.size >= 1:
-		if g.playedCardHead == card.X {
-			return true
-		} else if g.playedCardHead == card.Y {
-			return true
-		} else if g.playedCardTail == card.X {
-			return true
-		} else if g.playedCardTail == card.Y {
-			return true
-		} else {
-			return false
+func (g *Game) canPlayOn(card *Card, end engine.End) bool {
+	return card != nil && !card.Played && g.round.Position(-1).CanPlay(card.Tile(), end)
+}
+
+// position builds the view of the table for the given player. A nil player
+// yields the line of play only.
+func (g *Game) position(p *Player) engine.Position {
+	if p == nil {
+		return g.round.Position(-1)
+	}
+
+	return g.round.Position(p.seat)
+}
+
+// showHint highlights the move the CPU evaluation would pick for the current
+// player and explains why in the log.
+func (g *Game) showHint() {
+	player := g.CurrentPlayer()
+	g.clearHint()
+
+	scored := engine.Evaluate(g.position(player))
+	if len(scored) == 0 {
+		player.Log("No playable card this turn")
+		return
+	}
+
+	best := scored[0]
+	idx := player.cardIndex(best.Tile)
+	card := player.cards[idx]
+	card.Highlight()
+	g.App.SetFocus(card)
+	player.selectedCard = idx
+	g.hint = &best
+
+	g.Log(fmt.Sprintf("Hint for %s: play %s on the %s, %s", player.name, best.Tile, best.End, best.Reason))
+}
+
+func (g *Game) clearHint() {
+	if g.hint == nil {
+		return
+	}
+
+	for _, p := range g.Players {
+		for _, c := range p.cards {
+			if c.higlighted {
+				c.ClearHighlight()
+			}
 		}
 	}
 
-	return false
+	g.hint = nil
 }
 
 func (g *Game) Run() {
 	g.log.SetDynamicColors(true)
-	if err := g.App.SetRoot(g, true).Run(); err != nil {
+	defer func() {
+		for _, p := range g.Players {
+			if c, ok := p.strategy.(io.Closer); ok {
+				c.Close()
+			}
+		}
+	}()
+
+	if err := g.App.SetRoot(g.pages, true).Run(); err != nil {
 		panic(err)
 	}
 }
 
+// showPage switches between the "game", "stats" and "leaderboard" screens.
+func (g *Game) showPage(name string) {
+	g.pages.SwitchToPage(name)
+	switch name {
+	case "stats":
+		g.stats.store = g.Profiles
+		g.stats.Refresh()
+		g.App.SetFocus(g.stats)
+		return
+	case "leaderboard":
+		g.leaderboard.store = g.Profiles
+		g.leaderboard.SetTitle("Leaderboard [e export CSV, j export JSON, Esc close]")
+		g.leaderboard.Refresh()
+		g.App.SetFocus(g.leaderboard)
+		return
+	case "analysis":
+		g.analyze()
+		g.App.SetFocus(g.analysis)
+		return
+	}
+
+	if g.CurrentPlayer() != nil {
+		g.App.SetFocus(g.CurrentPlayer())
+	} else {
+		g.App.SetFocus(g)
+	}
+}
+
 func (g *Game) Log(s string) {
 	s = fmt.Sprintf("[violet::r][sys[]:%s\n[white::-]", s)
 	g.log.Write([]byte(s))
 }
 
 func (g *Game) CurrentPlayer() *Player {
-	if g.currentPlayer < 0 {
+	if g.round.Turn < 0 || g.round.Turn >= len(g.Players) {
 		return nil
 	}
 
-	return g.Players[g.currentPlayer]
+	return g.Players[g.round.Turn]
 }
 
 func (g *Game) SelectedCard() *Card {
-	if g.currentPlayer < 0 || g.CurrentPlayer().selectedCard < 0 {
+	if g.CurrentPlayer() == nil || g.CurrentPlayer().selectedCard < 0 {
 		return nil
 	}
 
 	return g.CurrentPlayer().cards[g.CurrentPlayer().selectedCard]
 }
 
+// update plays the selected card, following the hint when it points at the
+// same card and otherwise preferring the head.
 func (g *Game) update() {
-	// check game is started when there's player playing
-	// if g.CurrentPlayer() == nil {
-	// 	return
-	// }
+	card := g.SelectedCard()
+	if card == nil {
+		return
+	}
 
-	// if !g.CurrentPlayer().HasPlayableCards() {
-	// 	g.Log(fmt.Sprintf("%s not have playable card. Skipping turn...", g.CurrentPlayer().id))
-	// 	g.nextPlayer()
-	// 	return
-	// }
+	if g.hint != nil && g.hint.Tile.Same(card.Tile()) && g.canPlayOn(card, g.hint.End) {
+		g.updateOn(g.hint.End)
+		return
+	}
+
+	end, _ := g.position(nil).EndFor(card.Tile())
+	g.updateOn(end)
+}
+
+// updateOn plays the selected card on the given end.
+func (g *Game) updateOn(end engine.End) {
+	if g.SelectedCard() == nil {
+		return
+	}
 
 	// check selected card is valid card
-	if !g.validCard(g.SelectedCard()) {
+	if !g.canPlayOn(g.SelectedCard(), end) {
 		if g.SelectedCard().Played {
 			g.CurrentPlayer().Log(fmt.Sprintf("Card [%d,%d] already played. Please select another ", g.SelectedCard().X, g.SelectedCard().Y))
+		} else if g.validCard(g.SelectedCard()) {
+			g.CurrentPlayer().Log(fmt.Sprintf("Card [%d,%d] does not fit on the %s. Please select another ", g.SelectedCard().X, g.SelectedCard().Y, end))
 		} else {
 			g.CurrentPlayer().Log(fmt.Sprintf("Card [%d,%d] not playable. Please select another ", g.SelectedCard().X, g.SelectedCard().Y))
 		}
@@ -318,74 +705,299 @@ func (g *Game) update() {
 		return
 	}
 
-	// get current player played card and clone to playedcards array
-	playedCard := g.CurrentPlayer().PlayCard()
-	if g.playCard(NewCard(playedCard.X, playedCard.Y)) {
+	g.clearHint()
+
+	player := g.CurrentPlayer()
+	move := engine.Move{Tile: g.SelectedCard().Tile(), End: end}
+	var winning []engine.Move
+	if g.puzzle != nil && !player.isCpu {
+		winning = engine.WinningMoves(g.round, player.seat)
+	}
+
+	before := len(g.round.History)
+	placed, err := g.round.Play(move)
+	if err != nil {
+		player.Log(err.Error())
+		return
+	}
+
+	if g.puzzle != nil && !player.isCpu {
+		g.judgeMove(move, winning)
+	}
+
+	// mark the hand card as played and show a copy of it on the line
+	player.PlayCard()
+	g.showPlayed(NewCard(placed.A, placed.B), end)
+	if score := g.round.History[before].Score; score > 0 {
+		g.Log(fmt.Sprintf("%s scores %d for heads on %d", player.name, score, g.round.Head()))
+	}
+	g.logTurns(before + 1)
+	if g.isFinish() {
 		g.end()
 	} else {
-		g.nextPlayer()
+		g.startTurn(player)
 	}
 
 	g.updateStatusView()
 }
 
-func (g *Game) nextPlayer() {
-	if g.CurrentPlayer() != nil {
-		g.CurrentPlayer().selectedCard = -1
-		g.CurrentPlayer().SetBorderColor(tcell.ColorWhite)
+// logTurns reports the passes recorded in the round's history from the
+// given event on and hands the cards drawn from the boneyard to their
+// players.
+func (g *Game) logTurns(from int) {
+	for _, e := range g.round.History[from:] {
+		switch {
+		case e.Pass:
+			g.Log(fmt.Sprintf("%s not have playable card. Skipping turn...", g.Players[e.Player].id))
+		case e.Draw:
+			player := g.Players[e.Player]
+			player.DrawCard(g.Deck.PopCards(1)[0])
+			player.Log(fmt.Sprintf("Drew a card, %d left in the boneyard", g.Deck.GetNum()))
+		}
 	}
+}
 
-	g.currentPlayer++
-	if g.currentPlayer >= len(g.Players) {
-		g.currentPlayer = 0
+// startTurn hands the focus from the previous player to the one the round
+// has put on turn.
+func (g *Game) startTurn(previous *Player) {
+	if previous != nil {
+		previous.selectedCard = -1
+		previous.SetBorderColor(tcell.ColorWhite)
+		previous.clearMarks()
 	}
 
 	g.App.SetFocus(g.CurrentPlayer())
 	g.CurrentPlayer().SetBorderColor(tcell.ColorBlue)
-
-	if !g.CurrentPlayer().HasPlayableCards() {
-		g.Log(fmt.Sprintf("%s not have playable card. Skipping turn...", g.CurrentPlayer().id))
-		g.nextPlayer()
+	if !g.CurrentPlayer().isCpu {
+		g.CurrentPlayer().markCards()
 	}
 }
 
+// game is finished when either a player has played all his cards or no
+// player has any playable card
 func (g *Game) isFinish() bool {
-	finish := false
+	return g.round.Over()
+}
+
+// ratingID returns the id the player is rated under: the profile name for
+// humans and the strategy for CPU players.
+func (p *Player) ratingID() string {
+	if p.isCpu {
+		return rating.BotID(p.strategy.Name())
+	}
+
+	return p.name
+}
+
+// rateResult updates the ratings of every player, see rateSeats.
+func (g *Game) rateResult(result engine.Result) {
+	ids := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		ids[i] = p.ratingID()
+	}
+
+	rateSeats(g.Profiles, g.rules, ids, result, g.Log)
+}
+
+// rateSeats updates the ratings of the seats, ids holding the id each seat
+// is rated under. Winners rank first and the others follow by the pips left
+// in their hand, partners being rated as a team. Seats sharing an id, such
+// as CPU players of the same strategy, are rated once at the best rank
+// among them. Every new rating is logged.
+func rateSeats(store *profile.Store, rules engine.Ruleset, ids []string, result engine.Result, log func(string)) {
+	entrants := make([]rating.Entrant, 0, len(ids))
+	seated := map[string]int{}
+	for i, id := range ids {
+		rank := 1
+		if !result.IsWinner(i) {
+			rank = len(result.Winners) + 1
+			for j, pips := range result.Pips {
+				if !result.IsWinner(j) && pips < result.Pips[i] {
+					rank++
+				}
+			}
+		}
 
-	// game is finished when either
-	// 1. a player has played all his cards
-	for _, player := range g.Players {
-		if player.RemainingCardCount() == 0 {
-			finish = true
+		if j, ok := seated[id]; ok {
+			if rank < entrants[j].Rank {
+				entrants[j].Rank = rank
+			}
+			continue
+		}
+
+		seated[id] = len(entrants)
+		entrants = append(entrants, rating.Entrant{ID: id, Team: rules.Team(i), Rank: rank})
+	}
+
+	for id, delta := range store.Ratings.Update(entrants, rating.DefaultK) {
+		log(fmt.Sprintf("Rating %s: %.0f (%+.1f)", id, store.Ratings.Get(id).Value, delta))
+	}
+}
+
+func (g *Game) end() {
+	result := g.round.Result()
+	if g.puzzle != nil {
+		g.endPuzzle(result)
+		return
+	}
+
+	g.match.Add(result)
+	g.finish = true
+	g.showSummary(result)
+	g.settle(result)
+	g.recordResult(result)
+	for _, p := range g.Players {
+		if !p.isCpu {
+			g.Log("Press a for the analysis of your moves")
 			break
 		}
 	}
+	switch {
+	case !g.match.Over() && g.rules.Hands > 0:
+		g.Log(fmt.Sprintf("Playing %d hands. Press n to deal the next hand", g.rules.Hands))
+	case !g.match.Over():
+		g.Log(fmt.Sprintf("Playing to %d points. Press n to deal the next hand", g.rules.TargetScore))
+	case g.OnEnd != nil:
+		g.showMatch()
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	default:
+		g.showMatch()
+		g.Log("Press n to start a new match")
+	}
+}
 
-	// or
-	// 2 all player not has any playable card
-	if !finish {
-		finish = true
-		for _, player := range g.Players {
-			if player.HasPlayableCards() {
-				finish = false
-				break
+// buyIn gives the players without chips their stack when the game is
+// played for antes, a human bringing their bankroll from the profiles.
+func (g *Game) buyIn() {
+	if g.rules.Ante <= 0 {
+		return
+	}
+
+	for _, p := range g.Players {
+		if p.chips > 0 {
+			continue
+		}
+
+		p.chips = g.rules.Chips
+		if !p.isCpu && g.Profiles != nil {
+			p.chips = g.Profiles.Bankroll(p.name, g.rules.Chips)
+		}
+		g.Log(fmt.Sprintf("%s buys in for %d chips", p.name, p.chips))
+	}
+
+	g.Log(fmt.Sprintf("Everybody antes %d, the winner takes the pot", g.rules.Ante))
+}
+
+// settle pays the antes of the hand to its winners.
+func (g *Game) settle(result engine.Result) {
+	if g.rules.Ante <= 0 {
+		return
+	}
+
+	stacks := []int{}
+	for _, p := range g.Players {
+		stacks = append(stacks, p.chips)
+	}
+
+	for i, delta := range engine.AntePayout(stacks, g.rules.Ante, result) {
+		p := g.Players[i]
+		p.chips += delta
+		p.Log(fmt.Sprintf("%+d chips, %d left", delta, p.chips))
+	}
+}
+
+// showMatch logs the winners of a match played to a target score.
+func (g *Game) showMatch() {
+	if g.rules.TargetScore <= 0 {
+		return
+	}
+
+	for _, w := range g.match.Winners() {
+		g.Log(fmt.Sprintf("[::bl]MATCH WON by [%s]%s[white] with %d points", g.Players[w].color, g.Players[w].name, g.match.Scores[w]))
+	}
+}
+
+// showSummary reveals every hand and logs how the hand was decided and
+// scored.
+func (g *Game) showSummary(result engine.Result) {
+	if len(result.Winners) == 0 {
+		g.Log(fmt.Sprintf("[::bl]GAME FINISHED. No winner: %s", result.Reason))
+	} else {
+		names := ""
+		for i, w := range result.Winners {
+			if i > 0 {
+				names += " and "
 			}
+			names += fmt.Sprintf("[%s]%s[white]", g.Players[w].color, g.Players[w].name)
 		}
+		g.Log(fmt.Sprintf("[::bl]GAME FINISHED. Winner is %s: %s", names, result.Reason))
 	}
 
-	return finish
+	for i, p := range g.Players {
+		hand := ""
+		for _, c := range p.cards {
+			if !c.Played {
+				c.hideNotPlayedCard = false
+				hand += fmt.Sprintf("[%d,%d[] ", c.X, c.Y)
+			}
+		}
+
+		if hand == "" {
+			hand = "(out) "
+		}
+		p.Log(fmt.Sprintf("%s%d pips, %+d points, total %d", hand, result.Pips[i], result.Points[i], g.match.Scores[i]))
+	}
 }
 
-func (g *Game) end() {
-	min := 1000
-	var winner *Player
-	for _, k := range g.Players {
-		if k.RemainingCardValue() < min {
-			min = k.RemainingCardValue()
-			winner = k
+// recordResult stores the finished game in the human players' profiles.
+func (g *Game) recordResult(result engine.Result) {
+	if g.Profiles == nil {
+		return
+	}
+
+	record := profile.Result{Variant: g.rules.Name, Blocked: result.Blocked}
+	for i, p := range g.Players {
+		if p.isCpu {
+			continue
+		}
+
+		record.Seats = append(record.Seats, profile.Seat{
+			Name:          p.name,
+			Winner:        result.IsWinner(i),
+			WentOut:       p.RemainingCardCount() == 0,
+			RemainingPips: result.Pips[i],
+		})
+
+		if g.rules.Ante > 0 {
+			g.Profiles.SetBankroll(p.name, p.chips)
 		}
 	}
-	g.finish = true
-	g.Log(fmt.Sprintf("[::bl]GAME FINISHED. Winner is [%s]%s", winner.color, winner.name))
 
+	g.Profiles.Record(record)
+	g.rateResult(result)
+	if err := g.Profiles.Save(); err != nil {
+		g.Log(fmt.Sprintf("Could not save profiles: %v", err))
+	}
+
+}
+
+// analyze grades the moves the humans made in the hand just finished.
+func (g *Game) analyze() {
+	seats, names := []int{}, []string{}
+	for i, p := range g.Players {
+		names = append(names, p.name)
+		if !p.isCpu {
+			seats = append(seats, i)
+		}
+	}
+
+	g.analysis.SetTitle("Analysis [t export text, w export HTML, Esc close]")
+	a, err := engine.Analyze(g.opening, g.round.History[len(g.opening.History):], seats)
+	if err != nil {
+		g.analysis.Fail(err)
+		return
+	}
+
+	g.analysis.Show(a, names)
 }
@@ -1,9 +1,15 @@
 package domino
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/rating"
+	"github.com/rivo/tview"
 )
 
 func TestGameInit(t *testing.T) {
@@ -24,7 +30,7 @@ func TestValidCard(t *testing.T) {
 	card := NewCard(1, 1)
 
 	// test first played card is always valid
-	if game.size == 0 && !game.validCard(card) {
+	if !game.round.Started() && !game.validCard(card) {
 		t.Fatalf("Expecting card : %+v to be valid but not", card)
 	}
 
@@ -69,3 +75,201 @@ func TestValidCard(t *testing.T) {
 		t.Fatalf("Expecting [3,3] to be invalid")
 	}
 }
+
+func TestPlayCardOn(t *testing.T) {
+	game := NewGame()
+	game.playCard(NewCard(3, 4))
+
+	if game.canPlayOn(NewCard(3, 5), engine.Tail) {
+		t.Fatalf("Expecting [3,5] not to fit on tail 4")
+	}
+
+	// [4,3] fits both ends, place it explicitly on the tail
+	game.playCardOn(NewCard(4, 3), engine.Tail)
+	if game.round.Head() != 3 || game.round.Tail() != 3 {
+		t.Fatalf("Expecting ends 3/3 but got %d/%d", game.round.Head(), game.round.Tail())
+	}
+
+	pos := game.position(nil)
+	if len(pos.Played) != 2 || !pos.Started {
+		t.Fatalf("Expecting 2 played tiles but got %v", pos.Played)
+	}
+}
+
+func TestSelectCardSkipsUnplayable(t *testing.T) {
+	game := NewGame()
+	game.App = tview.NewApplication()
+	game.playCard(NewCard(3, 4))
+
+	// [1,2] and [2,2] fit neither end of the line
+	player := NewPlayer(game, "player1", false)
+	player.AssignCards([]*Card{NewCard(3, 5), NewCard(1, 2), NewCard(4, 6), NewCard(2, 2), NewCard(0, 3)})
+	player.seat = game.round.AddHand(player.tiles())
+	game.Players = append(game.Players, player)
+	game.round.Turn = player.seat
+	game.startTurn(nil)
+
+	played := player.cards[0]
+	played.Play()
+	for i := 0; i < len(player.cards)*2; i++ {
+		if card := player.selectCard(i%2 == 0); card == played {
+			t.Fatalf("Expecting played card [%d,%d] to be skipped", card.X, card.Y)
+		}
+	}
+
+	played.Played = false
+	game.LegalOnlyNavigation = true
+	for i := 0; i < len(player.cards)*2; i++ {
+		card := player.selectCard(i%3 == 0)
+		if card == nil {
+			t.Fatalf("Expecting a selectable card for %v", player.cards)
+		}
+
+		if card.Played || !game.validCard(card) {
+			t.Fatalf("Expecting only playable cards to be selected but got [%d,%d]", card.X, card.Y)
+		}
+	}
+
+	for _, c := range player.cards {
+		if c.playable == (c.Y == 2) {
+			t.Fatalf("Expecting only the cards fitting 3 or 4 marked playable but got [%d,%d]", c.X, c.Y)
+		}
+
+		if !c.Played && c.playable != game.validCard(c) {
+			t.Fatalf("Expecting card [%d,%d] marked playable=%t", c.X, c.Y, game.validCard(c))
+		}
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	rules, _ := engine.LookupRuleset("block")
+	game := NewGame()
+	game.SetRules(rules)
+	if game.Deck.GetNum() != 28 {
+		t.Fatalf("Expecting a double-six deck of 28 cards but got %d", game.Deck.GetNum())
+	}
+
+	game.Join("player1", false)
+	game.Join("player2", false)
+	if len(game.Players[0].cards) != 7 || len(game.Players[1].cards) != 7 {
+		t.Fatalf("Expecting 7 cards per hand")
+	}
+
+	if !game.Started() {
+		t.Fatalf("Expecting the game to start with the second player")
+	}
+
+	if game.Deck.GetNum() != len(game.round.Boneyard) {
+		t.Fatalf("Expecting deck and boneyard in step but got %d and %d", game.Deck.GetNum(), len(game.round.Boneyard))
+	}
+}
+
+func TestGameAntes(t *testing.T) {
+	rules, _ := engine.LookupRuleset("gaple-money")
+	game := NewGame()
+	game.SetRules(rules)
+	for _, name := range []string{"p1", "p2", "p3", "p4"} {
+		game.Join(name, false)
+	}
+
+	game.settle(engine.Result{Winner: 1, Winners: []int{1}})
+	for i, p := range game.Players {
+		want := rules.Chips - rules.Ante
+		if i == 1 {
+			want = rules.Chips + 3*rules.Ante
+		}
+
+		if p.chips != want {
+			t.Fatalf("Expecting %s to hold %d chips but got %d", p.name, want, p.chips)
+		}
+	}
+}
+
+func TestGameAnalysis(t *testing.T) {
+	rules, _ := engine.LookupRuleset("block")
+	game := NewGame()
+	game.SetRules(rules)
+	game.Join("player1", false)
+	game.Join("player2", true)
+
+	for !game.finish {
+		player := game.CurrentPlayer()
+		move := game.position(player).LegalMoves()[0]
+		player.selectedCard = player.cardIndex(move.Tile)
+		game.updateOn(move.End)
+	}
+
+	game.analyze()
+	for _, rev := range game.analysis.analysis.Reviews {
+		if rev.Player != 0 || !rev.Solved || rev.Verdict == "" {
+			t.Fatalf("Expecting the human moves judged by the solver but got %+v", rev)
+		}
+	}
+
+	path, err := game.analysis.Export(t.TempDir(), "html")
+	if err != nil || !strings.HasSuffix(path, "analysis.html") {
+		t.Fatalf("Expecting the analysis exported but got %s, %v", path, err)
+	}
+}
+
+func TestGameAnalysisFails(t *testing.T) {
+	rules, _ := engine.LookupRuleset("block")
+	game := NewGame()
+	game.SetRules(rules)
+	game.Join("player1", false)
+	game.Join("player2", true)
+
+	// a move out of turn can not be replayed
+	other := (game.round.Turn + 1) % 2
+	move := engine.Move{Tile: game.Players[other].tiles()[0]}
+	game.round.History = append(game.round.History, engine.Event{Player: other, Move: move})
+	game.analyze()
+	if len(game.analysis.analysis.Reviews) != 0 || !strings.Contains(game.analysis.GetCell(0, 0).Text, "out of turn") {
+		t.Fatalf("Expecting the failure shown instead of the reviews but got %q", game.analysis.GetCell(0, 0).Text)
+	}
+}
+
+func TestOpponentModel(t *testing.T) {
+	rules, _ := engine.LookupRuleset("block")
+	game := NewGame()
+	game.SetRules(rules)
+	game.Join("player1", false)
+	game.Join("player2", true)
+
+	game.toggleModel()
+	for !game.finish && !strings.Contains(game.model.GetText(true), "lacks") {
+		player := game.CurrentPlayer()
+		move := game.position(player).LegalMoves()[0]
+		player.selectedCard = player.cardIndex(move.Tile)
+		game.updateOn(move.End)
+	}
+
+	passed := false
+	for _, e := range game.round.History {
+		passed = passed || e.Pass && e.Player == 1
+	}
+
+	text := game.model.GetText(true)
+	if !strings.Contains(text, "unseen by player1") || passed != strings.Contains(text, "lacks") {
+		t.Fatalf("Expecting the panel to show what player2 lacks after a pass but got:\n%s", text)
+	}
+}
+
+func TestRatePartners(t *testing.T) {
+	rules, _ := engine.LookupRuleset("partnership")
+	store, _ := profile.Load(filepath.Join(t.TempDir(), "profiles.json"))
+	game := NewGame()
+	game.SetRules(rules)
+	game.Profiles = store
+	game.Join("ana", false)
+	game.JoinCpu("cpu1", "balanced")
+	game.Join("cid", false)
+	game.JoinCpu("cpu2", "balanced")
+
+	// ana went out and takes their partner cid along
+	game.rateResult(engine.Result{Winner: 0, Winners: []int{0}, Pips: []int{0, 10, 20, 30}})
+	ana, cid, bot := store.Ratings.Get("ana"), store.Ratings.Get("cid"), store.Ratings.Get(rating.BotID("balanced"))
+	if ana.Value <= rating.Initial || ana.Value != cid.Value || bot.Value >= rating.Initial || bot.Games != 1 {
+		t.Fatalf("Expecting the partners to gain together against the bot rated once but got %+v, %+v, %+v", ana, cid, bot)
+	}
+}
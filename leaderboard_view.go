@@ -0,0 +1,82 @@
+package domino
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/rating"
+	"github.com/rivo/tview"
+)
+
+// LeaderboardView ranks human profiles and CPU strategies by rating.
+type LeaderboardView struct {
+	*tview.Table
+	store *profile.Store
+}
+
+func NewLeaderboardView(store *profile.Store) *LeaderboardView {
+	view := &LeaderboardView{
+		Table: tview.NewTable().SetFixed(1, 0),
+		store: store,
+	}
+
+	view.SetBorder(true).SetTitle("Leaderboard [e export CSV, j export JSON, Esc close]")
+	return view
+}
+
+// Refresh reloads the table from the store.
+func (v *LeaderboardView) Refresh() {
+	v.Clear()
+	for col, h := range []string{"#", "Player", "Rating", "Peak", "Games"} {
+		v.SetCell(0, col, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	if v.store == nil {
+		v.SetCell(1, 1, tview.NewTableCell("No profile store configured"))
+		return
+	}
+
+	for i, e := range v.store.Ratings.Leaderboard() {
+		color := tcell.ColorWhite
+		if rating.IsBot(e.ID) {
+			color = tcell.ColorAqua
+		}
+
+		cells := []string{
+			fmt.Sprint(i + 1),
+			e.ID,
+			fmt.Sprintf("%.0f", e.Value),
+			fmt.Sprintf("%.0f", e.Peak),
+			fmt.Sprint(e.Games),
+		}
+		for col, text := range cells {
+			v.SetCell(i+1, col, tview.NewTableCell(text).SetTextColor(color))
+		}
+	}
+}
+
+// Export writes the leaderboard next to the profile store and returns the
+// file name. Format is either "csv" or "json".
+func (v *LeaderboardView) Export(format string) (string, error) {
+	if v.store == nil {
+		return "", fmt.Errorf("no profile store configured")
+	}
+
+	path := filepath.Join(v.store.Dir(), "leaderboard."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if format == "json" {
+		err = v.store.Ratings.WriteJSON(f)
+	} else {
+		err = v.store.Ratings.WriteCSV(f)
+	}
+
+	return path, err
+}
@@ -0,0 +1,259 @@
+package domino
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// PaiGowGame is the terminal screen of Pai Gow. The table has a row per
+// seat with its chips and its Chinese tiles, set into the high pair on the
+// left and the low pair on the right. The hands of the others stay hidden
+// until every hand is set. The rules live in engine.PaiGowRound.
+type PaiGowGame struct {
+	*tableGame
+
+	round *engine.PaiGowRound
+	table *tview.Grid
+	// partner is how the human on turn is about to set their hand
+	partner int
+}
+
+// NewPaiGowGame returns the screen for a Pai Gow ruleset.
+func NewPaiGowGame(rules engine.Ruleset) *PaiGowGame {
+	game := &PaiGowGame{tableGame: newTableGame(rules), table: tview.NewGrid()}
+	game.wager, game.variant = true, game
+
+	game.table.SetBorder(true).SetTitle("Pai Gow")
+
+	top := tview.NewFlex()
+	top.AddItem(game.table, 0, 2, false)
+	top.AddItem(game.log, 0, 1, false)
+	game.AddItem(top, 0, 1, false)
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	game.Log(fmt.Sprintf("Waiting for players of %s...", rules.Name))
+	return game
+}
+
+func (g *PaiGowGame) seated(seat int) string {
+	return fmt.Sprintf("with %d chips", g.seats[seat].chips)
+}
+
+func (g *PaiGowGame) wentOut(seat int) bool {
+	return false
+}
+
+// deal starts the next hand of the match, players who lost all their
+// chips buy in again.
+func (g *PaiGowGame) deal() {
+	g.finish = false
+	for _, s := range g.seats {
+		if s.chips <= 0 {
+			s.buyIn(g.rules, g.Profiles)
+			g.Log(fmt.Sprintf("%s buys in again for %d", s.name, s.chips))
+		}
+	}
+
+	hand := len(g.match.Hands)
+	g.round = engine.DealPaiGow(g.rng, g.rules, len(g.seats), hand)
+	g.Log(fmt.Sprintf("Hand %d: %s deals, everybody else bets %d", hand+1, g.seats[g.round.Dealer].name, g.rules.Ante))
+	g.Log("Keys: a pair your first tile with another, Enter set the hand, h hint")
+	g.startTurn()
+}
+
+func (g *PaiGowGame) current() *tableSeat {
+	return g.seats[g.round.Turn]
+}
+
+// startTurn refreshes the table for the seat on turn and lets a CPU player
+// think outside the UI thread.
+func (g *PaiGowGame) startTurn() {
+	if g.round.Over() {
+		g.end()
+		return
+	}
+
+	g.partner = engine.HouseWay(g.round.Hands[g.round.Turn])
+	g.refresh()
+	seat := g.current()
+	if seat.strategy == nil {
+		return
+	}
+
+	pos := g.round.Position(g.round.Turn)
+	go func() {
+		partner := engine.ChoosePaiGow(seat.strategy, pos)
+		time.Sleep(700 * time.Millisecond)
+		g.App.QueueUpdateDraw(func() {
+			if g.finish || g.current() != seat {
+				return
+			}
+
+			g.set(partner)
+		})
+	}()
+}
+
+// visible reports whether the seat's tiles are shown: once every hand is
+// set, and otherwise only to a human, the one on turn when several share
+// the screen.
+func (g *PaiGowGame) visible(seat int) bool {
+	humans := 0
+	for _, s := range g.seats {
+		if s.strategy == nil {
+			humans++
+		}
+	}
+
+	switch {
+	case g.finish:
+		return true
+	case g.seats[seat].strategy != nil:
+		return false
+	}
+
+	return humans == 1 || seat == g.round.Turn
+}
+
+// hand returns the seat's hand as it is shown: set, or as the human on
+// turn is about to set it.
+func (g *PaiGowGame) hand(seat int) engine.PaiGowHand {
+	if !g.finish && seat == g.round.Turn {
+		return engine.SetPaiGow(g.round.Hands[seat], g.partner)
+	}
+
+	return g.round.Hand(seat)
+}
+
+func (g *PaiGowGame) refresh() {
+	g.table.Clear()
+	rows := make([]int, len(g.seats))
+	for i := range rows {
+		rows[i] = 10
+	}
+	g.table.SetRows(rows...).SetColumns(24, 10, 10, 10, 10)
+
+	for i, s := range g.seats {
+		info := tview.NewTextView().SetDynamicColors(true)
+		fmt.Fprintf(info, "%s\n%d chips\n", s.name, s.chips)
+		switch {
+		case i == g.round.Dealer:
+			fmt.Fprint(info, "[yellow]dealer[white]\n")
+		case g.finish:
+			fmt.Fprintf(info, "%+d\n", g.round.Settle(i)*g.round.Bet)
+		}
+
+		if !g.finish && i == g.round.Turn {
+			fmt.Fprint(info, "[yellow]setting[white]\n")
+		}
+
+		tiles := g.round.Hands[i]
+		if g.visible(i) {
+			h := g.hand(i)
+			fmt.Fprintf(info, "%s\n%s", tview.Escape(h.High.String()), tview.Escape(h.Low.String()))
+			tiles = []engine.Tile{h.High.Tiles[0], h.High.Tiles[1], h.Low.Tiles[0], h.Low.Tiles[1]}
+		}
+		g.table.AddItem(info, i, 0, 1, 1, 0, 0, false)
+
+		for j, t := range tiles {
+			card := NewChineseCard(t.A, t.B)
+			card.hideNotPlayedCard = !g.visible(i)
+			if j < 2 && !card.hideNotPlayedCard {
+				card.MarkPlayable(true)
+			}
+			g.table.AddItem(card, i, j+1, 1, 1, 0, 0, false)
+		}
+	}
+
+	status := fmt.Sprintf("[black::b]%s [HAND:%d/%d] [DEALER:%s]", g.rules.Name, len(g.match.Hands)+1, g.rules.Hands, g.seats[g.round.Dealer].name)
+	if !g.finish {
+		status += fmt.Sprintf(" [ON TURN:%s]", g.current().name)
+	}
+	g.status.SetText(status)
+}
+
+func (g *PaiGowGame) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.round == nil {
+		return event
+	}
+
+	if g.finish {
+		switch {
+		case g.match.Over() && g.OnEnd != nil && event.Key() == tcell.KeyEnter:
+			g.App.Stop()
+		case event.Rune() == 'n':
+			if g.match.Over() {
+				g.match = engine.NewMatch(g.rules, len(g.seats))
+			}
+			g.deal()
+		}
+		return nil
+	}
+
+	if g.current().strategy != nil {
+		return event
+	}
+
+	switch {
+	case event.Key() == tcell.KeyEnter:
+		g.set(g.partner)
+	case event.Rune() == 'a':
+		g.partner = g.partner%(engine.PaiGowTiles-1) + 1
+		g.Log(fmt.Sprintf("Set: %s", g.hand(g.round.Turn)))
+		g.refresh()
+	case event.Rune() == 'h':
+		hand := g.round.Hands[g.round.Turn]
+		g.Log(fmt.Sprintf("Hint: the house way is %s", engine.SetPaiGow(hand, engine.HouseWay(hand))))
+	}
+
+	return nil
+}
+
+// set sets the hand of the seat on turn, keeping how from the others.
+func (g *PaiGowGame) set(partner int) {
+	seat := g.current()
+	if err := g.round.Set(partner); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s sets their hand", seat.name))
+	g.startTurn()
+}
+
+func (g *PaiGowGame) end() {
+	result := g.round.Result()
+	g.match.Add(result)
+	g.finish = true
+	for i, s := range g.seats {
+		s.chips += result.Points[i]
+	}
+
+	g.refresh()
+	g.Log(fmt.Sprintf("[::b]HAND FINISHED: %s", result.Reason))
+	for i, s := range g.seats {
+		g.Log(fmt.Sprintf("%s: %s, %+d, %d chips left", s.name, g.round.Hand(i), result.Points[i], s.chips))
+	}
+
+	g.recordResult(result)
+	if !g.match.Over() {
+		g.Log("Press n to deal the next hand")
+		return
+	}
+
+	for _, w := range g.match.Winners() {
+		g.Log(fmt.Sprintf("[::b]MATCH WON by %s with %+d chips", g.seats[w].name, g.match.Scores[w]))
+	}
+
+	if g.OnEnd != nil {
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	} else {
+		g.Log("Press n to start a new match")
+	}
+}
@@ -0,0 +1,40 @@
+package domino
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestPaiGowGame(t *testing.T) {
+	rules, _ := engine.LookupRuleset("pai-gow")
+	game := NewPaiGowGame(rules)
+	for i := 0; i < rules.Players; i++ {
+		game.Join(fmt.Sprintf("p%d", i+1), false)
+	}
+
+	if !game.Started() || game.round.Dealer != 0 || game.round.Turn != 1 {
+		t.Fatal("Expecting a dealt hand with the first seat dealing")
+	}
+
+	for i := 0; i < rules.Players; i++ {
+		game.set(game.partner)
+	}
+
+	if !game.finish || len(game.match.Hands) != 1 {
+		t.Fatal("Expecting the hand settled once every seat set their tiles")
+	}
+
+	chips := 0
+	for i, s := range game.seats {
+		chips += s.chips
+		if i != game.round.Dealer && s.chips != rules.Chips+game.round.Settle(i)*rules.Ante {
+			t.Fatalf("Expecting %s settled with the dealer but got %d chips", s.name, s.chips)
+		}
+	}
+
+	if chips != rules.Players*rules.Chips {
+		t.Fatalf("Expecting the dealer to pay what the players won but the seats hold %d chips", chips)
+	}
+}
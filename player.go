@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
 	"github.com/rivo/tview"
 )
 
@@ -54,6 +55,10 @@ type Player struct {
 	id            string
 	remainingCard int
 	isCpu         bool
+	strategy      engine.Strategy
+	seat          int
+	// chips is the stack of a game played for antes
+	chips int
 }
 
 func NewPlayer(game *Game, name string, isCpu bool) *Player {
@@ -84,7 +89,7 @@ func NewPlayer(game *Game, name string, isCpu bool) *Player {
 	} else {
 		cpuCounter++
 		player.id = fmt.Sprintf("CPU-%d", cpuCounter)
-
+		player.strategy, _ = engine.NewStrategy(engine.DefaultStrategy)
 	}
 
 	player.SetBorder(true).SetTitle(fmt.Sprintf("%s[%s]", player.name, player.id))
@@ -103,6 +108,30 @@ func (p *Player) AssignCards(cards []*Card) {
 	p.refresh()
 }
 
+// DrawCard adds a card drawn from the boneyard to the hand.
+func (p *Player) DrawCard(card *Card) {
+	if p.isCpu {
+		card.hideNotPlayedCard = true
+		card.SetTitle("[?,?]")
+	}
+
+	p.cards = append(p.cards, card)
+	p.remainingCard++
+	p.AddItem(card, 10, 1, false)
+}
+
+// tiles returns the unplayed cards as tiles.
+func (p *Player) tiles() []engine.Tile {
+	tiles := []engine.Tile{}
+	for _, c := range p.cards {
+		if !c.Played {
+			tiles = append(tiles, c.Tile())
+		}
+	}
+
+	return tiles
+}
+
 func (p *Player) PrintCard() {
 	for _, c := range p.cards {
 		fmt.Printf("%v\n", c)
@@ -147,6 +176,17 @@ func (p *Player) GetFirstPlayableCard() (int, *Card) {
 	return -1, nil
 }
 
+// cardIndex returns the index of the unplayed card showing the tile, or -1.
+func (p *Player) cardIndex(t engine.Tile) int {
+	for i, c := range p.cards {
+		if !c.Played && c.Tile().Same(t) {
+			return i
+		}
+	}
+
+	return -1
+}
+
 func (p *Player) HasPlayableCards() bool {
 	valid := false
 	for _, c := range p.cards {
@@ -198,30 +238,62 @@ func (p *Player) RemainingCardCount() int {
 	return p.remainingCard
 }
 
+// selectCard moves the selection to the next card in the given direction,
+// skipping played cards and, when the game restricts navigation, cards that
+// do not fit the line of play. It returns nil when there is nothing to select.
 func (p *Player) selectCard(reverse bool) *Card {
-	var focusIdx int = 0
+	current := p.selectedCard
 	for i, card := range p.cards {
+		if card.HasFocus() {
+			current = i
+		}
+	}
 
-		if !card.HasFocus() {
-			continue
+	step := 1
+	if reverse {
+		step = -1
+	}
+
+	if current < 0 {
+		current = 0
+		if !reverse {
+			current = -1
 		}
-		if reverse {
-			i = i - 1
-			if i < 0 {
-				i = len(p.cards) - 1
-			}
-		} else {
-			i = i + 1
-			i = i % len(p.cards)
+	}
+
+	for n := 1; n <= len(p.cards); n++ {
+		i := (current + n*step + n*len(p.cards)) % len(p.cards)
+		if !p.selectable(p.cards[i]) {
+			continue
 		}
 
-		focusIdx = i
+		p.game.App.SetFocus(p.cards[i])
+		p.selectedCard = i
+		return p.cards[i]
 	}
 
-	//if focusIdx < len(p.cards) {
-	p.game.App.SetFocus(p.cards[focusIdx])
-	p.selectedCard = focusIdx
-	//}
+	return nil
+}
+
+func (p *Player) selectable(card *Card) bool {
+	if card.Played {
+		return false
+	}
 
-	return p.cards[focusIdx]
+	return !p.game.LegalOnlyNavigation || p.game.validCard(card)
+}
+
+// markCards marks every unplayed card as playable or dimmed for this turn.
+func (p *Player) markCards() {
+	for _, c := range p.cards {
+		if !c.Played {
+			c.MarkPlayable(p.game.validCard(c))
+		}
+	}
+}
+
+func (p *Player) clearMarks() {
+	for _, c := range p.cards {
+		c.ClearMark()
+	}
 }
@@ -0,0 +1,215 @@
+// Package profile keeps named player profiles and their results in a local
+// JSON file.
+package profile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gusti-andika/domino/rating"
+)
+
+// Record holds the accumulated results of a player.
+type Record struct {
+	Played        int `json:"played"`
+	Wins          int `json:"wins"`
+	Dominoes      int `json:"dominoes"`
+	Blocked       int `json:"blocked"`
+	RemainingPips int `json:"remainingPips"`
+}
+
+// AveragePips returns the average pips left in hand at the end of a game.
+func (r Record) AveragePips() float64 {
+	if r.Played == 0 {
+		return 0
+	}
+
+	return float64(r.RemainingPips) / float64(r.Played)
+}
+
+// WinRate returns the fraction of games won.
+func (r Record) WinRate() float64 {
+	if r.Played == 0 {
+		return 0
+	}
+
+	return float64(r.Wins) / float64(r.Played)
+}
+
+func (r *Record) add(seat Seat, blocked bool) {
+	r.Played++
+	r.RemainingPips += seat.RemainingPips
+	if seat.Winner {
+		r.Wins++
+	}
+
+	if seat.WentOut {
+		r.Dominoes++
+	}
+
+	if blocked {
+		r.Blocked++
+	}
+}
+
+// Profile is a named player and their overall and per-variant records.
+type Profile struct {
+	Name string `json:"name"`
+	Record
+	Variants map[string]*Record `json:"variants,omitempty"`
+}
+
+// Seat is one player's outcome in a finished game.
+type Seat struct {
+	Name          string
+	Winner        bool
+	WentOut       bool
+	RemainingPips int
+}
+
+// Result is a finished game as seen by the profile store.
+type Result struct {
+	Variant string
+	Blocked bool
+	Seats   []Seat
+}
+
+// Store is the set of profiles backed by a file.
+type Store struct {
+	path     string
+	Profiles map[string]*Profile `json:"profiles"`
+	// Ratings holds the Elo rating of every human profile and CPU strategy
+	Ratings rating.Table `json:"ratings"`
+	// Bankrolls holds the chips every human player has left for the
+	// wagering games
+	Bankrolls map[string]int `json:"bankrolls"`
+}
+
+// DefaultPath returns the profile file in the user's config directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "card-domino", "profiles.json")
+}
+
+// Load reads the store from path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Profiles: map[string]*Profile{}, Ratings: rating.Table{}, Bankrolls: map[string]int{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	if s.Profiles == nil {
+		s.Profiles = map[string]*Profile{}
+	}
+
+	if s.Ratings == nil {
+		s.Ratings = rating.Table{}
+	}
+
+	if s.Bankrolls == nil {
+		s.Bankrolls = map[string]int{}
+	}
+
+	return s, nil
+}
+
+// Dir returns the directory holding the store file.
+func (s *Store) Dir() string {
+	return filepath.Dir(s.path)
+}
+
+// Save writes the store back to its file.
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, data, 0644)
+}
+
+// Profile returns the named profile, creating it when needed.
+func (s *Store) Profile(name string) *Profile {
+	p, ok := s.Profiles[name]
+	if !ok {
+		p = &Profile{Name: name}
+		s.Profiles[name] = p
+	}
+
+	if p.Variants == nil {
+		p.Variants = map[string]*Record{}
+	}
+
+	return p
+}
+
+// Record adds a finished game to the profile of every seat in it.
+func (s *Store) Record(r Result) {
+	for _, seat := range r.Seats {
+		p := s.Profile(seat.Name)
+		p.add(seat, r.Blocked)
+
+		v, ok := p.Variants[r.Variant]
+		if !ok {
+			v = &Record{}
+			p.Variants[r.Variant] = v
+		}
+		v.add(seat, r.Blocked)
+	}
+}
+
+// Bankroll returns the chips the player brings to a table. A new player,
+// or one who lost everything, buys in again for start.
+func (s *Store) Bankroll(name string, start int) int {
+	if chips := s.Bankrolls[name]; chips > 0 {
+		return chips
+	}
+
+	return start
+}
+
+// SetBankroll keeps the chips the player has left.
+func (s *Store) SetBankroll(name string, chips int) {
+	s.Bankrolls[name] = chips
+}
+
+// Sorted returns the profiles ordered by wins, then win rate, then name.
+func (s *Store) Sorted() []*Profile {
+	list := make([]*Profile, 0, len(s.Profiles))
+	for _, p := range s.Profiles {
+		list = append(list, p)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+
+		if a.WinRate() != b.WinRate() {
+			return a.WinRate() > b.WinRate()
+		}
+
+		return a.Name < b.Name
+	})
+
+	return list
+}
@@ -0,0 +1,90 @@
+package profile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "profiles.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Record(Result{Variant: "block", Seats: []Seat{
+		{Name: "ana", Winner: true, WentOut: true},
+		{Name: "budi", RemainingPips: 12},
+	}})
+	s.Record(Result{Variant: "draw", Blocked: true, Seats: []Seat{
+		{Name: "ana", RemainingPips: 8},
+		{Name: "budi", Winner: true, RemainingPips: 4},
+	}})
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ana := s.Profile("ana")
+	if ana.Played != 2 || ana.Wins != 1 || ana.Dominoes != 1 || ana.Blocked != 1 {
+		t.Fatalf("Unexpected record for ana: %+v", ana.Record)
+	}
+
+	if ana.AveragePips() != 4 {
+		t.Fatalf("Expecting ana average pips 4 but got %f", ana.AveragePips())
+	}
+
+	if ana.Variants["block"].Wins != 1 || ana.Variants["draw"].Wins != 0 {
+		t.Fatalf("Unexpected variant records for ana: %+v %+v", ana.Variants["block"], ana.Variants["draw"])
+	}
+
+	if sorted := s.Sorted(); len(sorted) != 2 || sorted[0].Name != "ana" {
+		t.Fatalf("Expecting ana first in %v", sorted)
+	}
+}
+
+func TestBankroll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "profiles.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chips := s.Bankroll("ana", 1000); chips != 1000 {
+		t.Fatalf("Expecting a new player to buy in for 1000 but got %d", chips)
+	}
+
+	s.SetBankroll("ana", 1250)
+	s.SetBankroll("budi", 0)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ana, budi := s.Bankroll("ana", 1000), s.Bankroll("budi", 1000); ana != 1250 || budi != 1000 {
+		t.Fatalf("Expecting ana to keep 1250 and budi to buy in again but got %d and %d", ana, budi)
+	}
+}
@@ -0,0 +1,130 @@
+package domino
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// LoadPuzzle sets the game up at the puzzle's position: the human sits in
+// the seat on turn and the other seats are CPU defenders seeing every hand.
+// Every hand is shown face up and each move of the human is checked by the
+// solver. It replaces any players who joined before.
+func (g *Game) LoadPuzzle(p engine.Puzzle, playerName string) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	round, _ := p.Round()
+
+	for _, player := range g.Players {
+		g.RemoveItem(player)
+	}
+
+	g.SetRules(p.Ruleset())
+	g.puzzle, g.round, g.Players = &p, round, nil
+	g.head, g.tail, g.last, g.hint = nil, nil, nil, nil
+	g.headView.Clear()
+	g.tailView.Clear()
+	g.finish, g.slipped = false, false
+
+	for i, hand := range p.Hands {
+		name, isCpu := fmt.Sprintf("Player %d", i+1), i != p.Turn
+		if !isCpu {
+			name = playerName
+		}
+
+		player := NewPlayer(g, name, isCpu)
+		if isCpu {
+			player.strategy = &engine.Defender{Round: round.Clone(), Hero: p.Turn}
+			g.cpuFocus(player)
+		}
+
+		cards := []*Card{}
+		for _, t := range hand {
+			cards = append(cards, NewCard(t.A, t.B))
+		}
+		player.AssignCards(cards)
+		for _, c := range cards {
+			c.hideNotPlayedCard = false
+			c.SetTitle(fmt.Sprintf("[%d,%d]", c.X, c.Y))
+		}
+
+		player.seat = i
+		g.Players = append(g.Players, player)
+		g.AddItem(player, 0, 1, false)
+	}
+
+	for _, t := range p.Line {
+		g.showPlayed(NewCard(t.A, t.B), engine.Tail)
+	}
+
+	if g.App == nil {
+		g.App = tview.NewApplication()
+	}
+	g.match = engine.NewMatch(g.rules, len(g.Players))
+	g.hand = 1
+
+	g.Log(fmt.Sprintf("Puzzle %s", p.Name))
+	if p.Description != "" {
+		g.Log(p.Description)
+	}
+	g.Log(fmt.Sprintf("%s to play and win against any defence", playerName))
+	g.startTurn(nil)
+	g.Log("Keys: Left/Right select, Enter play, Up/Down play on head/tail, n start over")
+	g.updateStatusView()
+	return nil
+}
+
+// judgeMove tells the human whether the move they are about to make keeps
+// the puzzle won, the solver having found the winning moves beforehand.
+func (g *Game) judgeMove(move engine.Move, winning []engine.Move) {
+	if g.slipped {
+		return
+	}
+
+	for _, m := range winning {
+		if m.Tile.Same(move.Tile) && m.End == move.End {
+			g.Log(fmt.Sprintf("%s on the %s keeps the win", move.Tile, move.End))
+			return
+		}
+	}
+
+	g.slipped = true
+	g.Log(fmt.Sprintf("%s on the %s lets the win slip, the winning moves were %s", move.Tile, move.End, movesText(winning)))
+}
+
+// endPuzzle reports whether the human solved the puzzle.
+func (g *Game) endPuzzle(result engine.Result) {
+	g.finish = true
+	g.Log(result.Reason)
+	if result.IsWinner(g.puzzle.Turn) {
+		g.Log(fmt.Sprintf("[::b]Puzzle %s solved", g.puzzle.Name))
+	} else {
+		g.Log(fmt.Sprintf("[::b]Puzzle %s not solved, the solution starts with %s", g.puzzle.Name, movesText(g.puzzle.Solution())))
+	}
+
+	g.Log("Press n to start over")
+	g.App.SetFocus(g)
+	for _, p := range g.Players {
+		p.SetBorderColor(tcell.ColorWhite)
+	}
+}
+
+func movesText(moves []engine.Move) string {
+	s := ""
+	for i, m := range moves {
+		if i > 0 {
+			s += " or "
+		}
+		s += fmt.Sprintf("%s on the %s", m.Tile, m.End)
+	}
+
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
@@ -0,0 +1,55 @@
+package domino
+
+import (
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestPuzzleGame(t *testing.T) {
+	p, _ := engine.FindPuzzle("two-handed")
+	game := NewGame()
+	game.Join("early", false)
+	if err := game.LoadPuzzle(p, "solver"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(game.Players) != 2 || game.CurrentPlayer().name != "solver" || game.Players[1].cards[0].hideNotPlayedCard {
+		t.Fatal("Expecting the human on turn against a defender showing its hand")
+	}
+
+	if game.head.Tile() != p.Line[0] || game.tail.Tile() != p.Line[len(p.Line)-1] {
+		t.Fatal("Expecting the line of the puzzle on the table")
+	}
+
+	// play the solution, the defender answering every move
+	for !game.finish {
+		player := game.CurrentPlayer()
+		move := engine.WinningMoves(game.round, p.Turn)[0]
+		if player.isCpu {
+			move, _ = player.strategy.Choose(game.position(player))
+		}
+
+		player.selectedCard = player.cardIndex(move.Tile)
+		game.updateOn(move.End)
+	}
+
+	if game.slipped || !game.round.Result().IsWinner(p.Turn) {
+		t.Fatal("Expecting the puzzle solved")
+	}
+
+	// starting over brings the position back, a wrong first move slips
+	game.LoadPuzzle(*game.puzzle, "solver")
+	player := game.CurrentPlayer()
+	for _, m := range game.round.Position(p.Turn).LegalMoves() {
+		if m != p.Solution()[0] {
+			player.selectedCard = player.cardIndex(m.Tile)
+			game.updateOn(m.End)
+			break
+		}
+	}
+
+	if !game.slipped || len(game.round.Line) != len(p.Line)+1 {
+		t.Fatal("Expecting the wrong move played and flagged")
+	}
+}
@@ -0,0 +1,326 @@
+package domino
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// QiuGame is the terminal screen of QiuQiu. The table has a row per seat
+// with its chips and its tiles drawn as cards, the opponents' tiles stay
+// hidden until the showdown. The chips view beside it shows the pots and
+// the bets open to the player on turn. Like the other screens it is only a
+// view, the rules live in engine.QiuRound.
+type QiuGame struct {
+	*tableGame
+
+	round *engine.QiuRound
+	table *tview.Grid
+	chips *BettingView
+	// raise is what the human on turn raises by
+	raise int
+}
+
+// NewQiuGame returns the screen for a QiuQiu ruleset.
+func NewQiuGame(rules engine.Ruleset) *QiuGame {
+	game := &QiuGame{
+		tableGame: newTableGame(rules),
+		table:     tview.NewGrid(),
+		chips:     NewBettingView(),
+	}
+	game.wager, game.variant = true, game
+
+	game.table.SetBorder(true).SetTitle("QiuQiu")
+
+	top := tview.NewFlex()
+	top.AddItem(game.table, 0, 2, false)
+	side := tview.NewFlex().SetDirection(tview.FlexRow)
+	side.AddItem(game.chips, 0, 1, false)
+	side.AddItem(game.log, 0, 2, false)
+	top.AddItem(side, 0, 1, false)
+	game.AddItem(top, 0, 1, false)
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	game.Log(fmt.Sprintf("Waiting for players of %s...", rules.Name))
+	return game
+}
+
+func (g *QiuGame) seated(seat int) string {
+	return fmt.Sprintf("with %d chips", g.seats[seat].chips)
+}
+
+func (g *QiuGame) wentOut(seat int) bool {
+	return false
+}
+
+// deal starts the next hand of the match, players who lost all their
+// chips buy in again.
+func (g *QiuGame) deal() {
+	g.finish = false
+	stacks := []int{}
+	for _, s := range g.seats {
+		if s.chips <= 0 {
+			s.buyIn(g.rules, g.Profiles)
+			g.Log(fmt.Sprintf("%s buys in again for %d", s.name, s.chips))
+		}
+		stacks = append(stacks, s.chips)
+	}
+
+	hand := len(g.match.Hands)
+	g.round = engine.DealQiuStacks(g.rng, g.rules, hand, stacks)
+	g.Log(fmt.Sprintf("Hand %d: everybody antes %d", hand+1, g.rules.Ante))
+	g.Log("Keys: c check or call, r raise, +/- change the raise, f fold, a pair your first tile with another, h hint")
+	g.startTurn()
+}
+
+func (g *QiuGame) current() *tableSeat {
+	return g.seats[g.round.Turn]
+}
+
+func (g *QiuGame) names() []string {
+	names := []string{}
+	for _, s := range g.seats {
+		names = append(names, s.name)
+	}
+
+	return names
+}
+
+// startTurn refreshes the table for the player on turn and lets a CPU
+// player think outside the UI thread.
+func (g *QiuGame) startTurn() {
+	if g.round.Over() {
+		g.end()
+		return
+	}
+
+	g.raise = g.round.MinRaise
+	g.refresh()
+	seat := g.current()
+	if seat.strategy == nil {
+		return
+	}
+
+	pos := g.round.Position(g.round.Turn)
+	go func() {
+		action := engine.ChooseQiu(seat.strategy, pos)
+		time.Sleep(700 * time.Millisecond)
+		g.App.QueueUpdateDraw(func() {
+			if g.finish || g.current() != seat {
+				return
+			}
+
+			g.act(action)
+		})
+	}()
+}
+
+// visible reports whether the seat's tiles are shown: at the showdown, and
+// otherwise only to a human, the one on turn when several share the screen.
+func (g *QiuGame) visible(seat int) bool {
+	humans := 0
+	for _, s := range g.seats {
+		if s.strategy == nil {
+			humans++
+		}
+	}
+
+	switch {
+	case g.finish:
+		return !g.round.Folded[seat] || g.seats[seat].strategy == nil
+	case g.seats[seat].strategy != nil:
+		return false
+	}
+
+	return humans == 1 || seat == g.round.Turn
+}
+
+func (g *QiuGame) refresh() {
+	g.table.Clear()
+	g.table.SetTitle(fmt.Sprintf("QiuQiu [pot %d]", g.round.Total()))
+	g.chips.Refresh(g.round.Betting, g.names(), g.raise)
+	rows := make([]int, len(g.seats))
+	for i := range rows {
+		rows[i] = 10
+	}
+	g.table.SetRows(rows...).SetColumns(24, 10, 10, 10, 10)
+
+	for i, s := range g.seats {
+		info := tview.NewTextView().SetDynamicColors(true)
+		fmt.Fprintf(info, "%s\n%d chips, %d in\n", s.name, g.round.Stacks[i], g.round.Committed[i])
+		switch {
+		case g.round.Folded[i]:
+			fmt.Fprint(info, "[gray]folded[white]\n")
+		case !g.finish && i == g.round.Turn:
+			fmt.Fprint(info, "[yellow]on turn[white]\n")
+		}
+
+		hand := g.round.Hands[i]
+		if g.visible(i) && len(hand) == engine.QiuTiles {
+			fmt.Fprintf(info, "%s", tview.Escape(g.round.Hand(i).String()))
+		}
+		g.table.AddItem(info, i, 0, 1, 1, 0, 0, false)
+
+		// a full hand is shown split into its pairs, the higher one first
+		tiles := hand
+		if len(hand) == engine.QiuTiles {
+			split := g.round.Hand(i).Tiles
+			tiles = split[:]
+		}
+
+		for j, t := range tiles {
+			card := NewCard(t.A, t.B)
+			card.hideNotPlayedCard = !g.visible(i)
+			if g.round.Folded[i] {
+				card.MarkPlayable(false)
+			} else if j < 2 && len(tiles) == engine.QiuTiles && !card.hideNotPlayedCard {
+				card.MarkPlayable(true)
+			}
+			g.table.AddItem(card, i, j+1, 1, 1, 0, 0, false)
+		}
+	}
+
+	status := fmt.Sprintf("[black::b]%s [HAND:%d/%d]", g.rules.Name, len(g.match.Hands)+1, g.rules.Hands)
+	if !g.round.Over() {
+		status += fmt.Sprintf(" [ON TURN:%s]", g.current().name)
+	}
+	g.status.SetText(status)
+}
+
+func (g *QiuGame) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.round == nil {
+		return event
+	}
+
+	if g.finish {
+		switch {
+		case g.match.Over() && g.OnEnd != nil && event.Key() == tcell.KeyEnter:
+			g.App.Stop()
+		case event.Rune() == 'n':
+			if g.match.Over() {
+				g.match = engine.NewMatch(g.rules, len(g.seats))
+			}
+			g.deal()
+		}
+		return nil
+	}
+
+	if g.current().strategy != nil {
+		return event
+	}
+
+	switch event.Rune() {
+	case 'c':
+		if g.round.ToCall(g.round.Turn) > 0 {
+			g.act(engine.Bet{Kind: engine.BetCall})
+		} else {
+			g.act(engine.Bet{Kind: engine.BetCheck})
+		}
+	case 'r':
+		g.act(engine.Bet{Kind: engine.BetRaise, Amount: g.raise})
+	case '+':
+		g.raise += g.round.MinRaise
+		g.refresh()
+	case '-':
+		if g.raise > g.round.MinRaise {
+			g.raise -= g.round.MinRaise
+		}
+		g.refresh()
+	case 'f':
+		g.act(engine.Bet{Kind: engine.BetFold})
+	case 'a':
+		g.arrange()
+	case 'h':
+		g.showHint()
+	}
+
+	return nil
+}
+
+// showHint logs what the default strategy would do and why.
+func (g *QiuGame) showHint() {
+	s, _ := engine.NewStrategy(engine.DefaultStrategy)
+	action := engine.ChooseQiu(s, g.round.Position(g.round.Turn))
+	_, reason := engine.EvaluateQiu(g.round.Hands[g.round.Turn])
+	g.Log(fmt.Sprintf("Hint: %s, your %s", action, reason))
+}
+
+// arrange pairs the first tile of the human on turn with the next tile.
+func (g *QiuGame) arrange() {
+	turn := g.round.Turn
+	partner := g.round.Partners[turn] + 1
+	if partner >= engine.QiuTiles {
+		partner = 0
+	}
+
+	if err := g.round.Arrange(turn, partner); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	if partner == 0 {
+		g.Log(fmt.Sprintf("Best split: %s", g.round.Hand(turn)))
+	} else {
+		g.Log(fmt.Sprintf("Split: %s", g.round.Hand(turn)))
+	}
+	g.refresh()
+}
+
+// act makes the betting action for the player on turn and logs it.
+func (g *QiuGame) act(bet engine.Bet) {
+	seat, street := g.current(), g.round.Street
+	if err := g.round.Act(bet); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	event := g.round.History[len(g.round.History)-1]
+	switch {
+	case bet.Kind == engine.BetRaise && g.round.AllIn(event.Player):
+		g.Log(fmt.Sprintf("%s goes all in with %d", seat.name, event.Chips))
+	case bet.Kind == engine.BetRaise:
+		g.Log(fmt.Sprintf("%s raises to %d", seat.name, g.round.Current))
+	case bet.Kind == engine.BetCall:
+		g.Log(fmt.Sprintf("%s calls %d", seat.name, event.Chips))
+	default:
+		g.Log(fmt.Sprintf("%s %ss", seat.name, bet.Kind))
+	}
+
+	if g.round.Street != street && !g.round.Over() {
+		g.Log(fmt.Sprintf("Fourth tiles dealt, %s bets first", g.current().name))
+	}
+	g.startTurn()
+}
+
+func (g *QiuGame) end() {
+	result := g.round.Result()
+	g.match.Add(result)
+	g.finish = true
+	g.refresh()
+	g.Log(fmt.Sprintf("[::b]HAND FINISHED: %s", result.Reason))
+	for i, s := range g.seats {
+		s.chips += result.Points[i]
+		g.Log(fmt.Sprintf("%s: %+d, %d chips left", s.name, result.Points[i], s.chips))
+	}
+
+	g.recordResult(result)
+	if !g.match.Over() {
+		g.Log("Press n to deal the next hand")
+		return
+	}
+
+	for _, w := range g.match.Winners() {
+		g.Log(fmt.Sprintf("[::b]MATCH WON by %s with %+d chips", g.seats[w].name, g.match.Scores[w]))
+	}
+
+	if g.OnEnd != nil {
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	} else {
+		g.Log("Press n to start a new match")
+	}
+}
@@ -0,0 +1,48 @@
+package domino
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestQiuGame(t *testing.T) {
+	rules, _ := engine.LookupRuleset("qiuqiu")
+	game := NewQiuGame(rules)
+	for i := 0; i < rules.Players; i++ {
+		game.Join(fmt.Sprintf("p%d", i+1), false)
+	}
+
+	if !game.Started() {
+		t.Fatal("Expecting a dealt hand")
+	}
+
+	game.act(engine.Bet{Kind: engine.BetRaise, Amount: rules.MinBet})
+	for i := 1; i < rules.Players; i++ {
+		game.act(engine.Bet{Kind: engine.BetCall})
+	}
+
+	if game.round.Street != 1 || len(game.round.Hands[0]) != engine.QiuTiles {
+		t.Fatalf("Expecting the fourth tiles after everybody called but got street %d", game.round.Street)
+	}
+
+	game.arrange()
+	for i := 0; i < rules.Players; i++ {
+		game.act(engine.Bet{Kind: engine.BetCheck})
+	}
+
+	pot := rules.Players * (rules.Ante + rules.MinBet)
+	if !game.finish || len(game.match.Hands) != 1 || game.round.Total() != pot {
+		t.Fatalf("Expecting a showdown for a pot of %d but got %d", pot, game.round.Total())
+	}
+
+	chips := 0
+	for _, s := range game.seats {
+		chips += s.chips
+	}
+
+	if chips != rules.Players*rules.Chips {
+		t.Fatalf("Expecting the pot paid back to the table but the seats hold %d chips", chips)
+	}
+}
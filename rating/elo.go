@@ -0,0 +1,194 @@
+// Package rating implements Elo ratings for free-for-all and partnership
+// results.
+package rating
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// Initial is the rating given to a new player or bot.
+	Initial = 1500.0
+	// DefaultK is the maximum rating change of a two player game.
+	DefaultK  = 32.0
+	botPrefix = "cpu:"
+)
+
+// Rating is the current rating of a player or bot.
+type Rating struct {
+	Value float64 `json:"value"`
+	Games int     `json:"games"`
+	Peak  float64 `json:"peak"`
+}
+
+// Entrant is one seat in a finished hand or match. Lower Rank is better and
+// equal ranks are ties. Entrants sharing a Team are partners.
+type Entrant struct {
+	ID   string
+	Team int
+	Rank int
+}
+
+// BotID returns the rating id used for a CPU strategy.
+func BotID(strategy string) string {
+	return botPrefix + strategy
+}
+
+// IsBot reports whether the id belongs to a CPU strategy.
+func IsBot(id string) bool {
+	return strings.HasPrefix(id, botPrefix)
+}
+
+// Table holds the ratings by id.
+type Table map[string]*Rating
+
+// Get returns the rating for id, creating it at the initial value.
+func (t Table) Get(id string) *Rating {
+	r, ok := t[id]
+	if !ok {
+		r = &Rating{Value: Initial, Peak: Initial}
+		t[id] = r
+	}
+
+	return r
+}
+
+// Expected returns the expected score of a rating against another.
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Update applies a result and returns the rating change per id. Every team
+// is compared with every other team as if they played a two player game,
+// using the average rating of the partners, and the change is scaled by the
+// number of opponents so a four player hand moves ratings about as much as a
+// head to head one. An id seated more than once accumulates the changes.
+func (t Table) Update(entrants []Entrant, k float64) map[string]float64 {
+	type team struct {
+		rank    int
+		members []string
+	}
+
+	teams := map[int]*team{}
+	order := []int{}
+	for _, e := range entrants {
+		tm, ok := teams[e.Team]
+		if !ok {
+			tm = &team{rank: e.Rank}
+			teams[e.Team] = tm
+			order = append(order, e.Team)
+		}
+
+		if e.Rank < tm.rank {
+			tm.rank = e.Rank
+		}
+		tm.members = append(tm.members, e.ID)
+	}
+
+	deltas := map[string]float64{}
+	if len(order) < 2 {
+		return deltas
+	}
+
+	avg := func(tm *team) float64 {
+		total := 0.0
+		for _, id := range tm.members {
+			total += t.Get(id).Value
+		}
+		return total / float64(len(tm.members))
+	}
+
+	ratings := map[int]float64{}
+	for _, id := range order {
+		ratings[id] = avg(teams[id])
+	}
+
+	scale := k / float64(len(order)-1)
+	for _, a := range order {
+		change := 0.0
+		for _, b := range order {
+			if a == b {
+				continue
+			}
+
+			score := 0.5
+			if teams[a].rank < teams[b].rank {
+				score = 1
+			} else if teams[a].rank > teams[b].rank {
+				score = 0
+			}
+
+			change += scale * (score - Expected(ratings[a], ratings[b]))
+		}
+
+		for _, id := range teams[a].members {
+			deltas[id] += change
+		}
+	}
+
+	for id, d := range deltas {
+		r := t.Get(id)
+		r.Value += d
+		r.Games++
+		if r.Value > r.Peak {
+			r.Peak = r.Value
+		}
+	}
+
+	return deltas
+}
+
+// Entry is a row of the leaderboard.
+type Entry struct {
+	ID string `json:"id"`
+	Rating
+}
+
+// Leaderboard returns every rating, highest first.
+func (t Table) Leaderboard() []Entry {
+	list := make([]Entry, 0, len(t))
+	for id, r := range t {
+		list = append(list, Entry{ID: id, Rating: *r})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Value != list[j].Value {
+			return list[i].Value > list[j].Value
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	return list
+}
+
+// WriteCSV exports the leaderboard as CSV.
+func (t Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "id", "bot", "rating", "peak", "games"})
+	for i, e := range t.Leaderboard() {
+		cw.Write([]string{
+			fmt.Sprint(i + 1),
+			e.ID,
+			fmt.Sprint(IsBot(e.ID)),
+			fmt.Sprintf("%.1f", e.Value),
+			fmt.Sprintf("%.1f", e.Peak),
+			fmt.Sprint(e.Games),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON exports the leaderboard as a JSON array.
+func (t Table) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.Leaderboard())
+}
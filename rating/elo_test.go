@@ -0,0 +1,72 @@
+package rating
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHeadToHead(t *testing.T) {
+	table := Table{}
+	deltas := table.Update([]Entrant{{ID: "ana", Team: 0, Rank: 1}, {ID: "budi", Team: 1, Rank: 2}}, DefaultK)
+
+	if deltas["ana"] != 16 || deltas["budi"] != -16 {
+		t.Fatalf("Expecting +16/-16 between equal ratings but got %v", deltas)
+	}
+
+	if table.Get("ana").Games != 1 || table.Get("ana").Peak != 1516 {
+		t.Fatalf("Unexpected rating for ana: %+v", table.Get("ana"))
+	}
+}
+
+func TestFreeForAllAndTies(t *testing.T) {
+	table := Table{}
+	deltas := table.Update([]Entrant{
+		{ID: "ana", Team: 0, Rank: 1},
+		{ID: "budi", Team: 1, Rank: 2},
+		{ID: BotID("heavy"), Team: 2, Rank: 2},
+	}, DefaultK)
+
+	total := 0.0
+	for _, d := range deltas {
+		total += d
+	}
+
+	if math.Abs(total) > 1e-9 {
+		t.Fatalf("Expecting rating changes to sum to zero but got %f", total)
+	}
+
+	if deltas["budi"] != deltas[BotID("heavy")] {
+		t.Fatalf("Expecting tied players to move equally but got %v", deltas)
+	}
+}
+
+func TestPartnership(t *testing.T) {
+	table := Table{}
+	deltas := table.Update([]Entrant{
+		{ID: "ana", Team: 0, Rank: 1},
+		{ID: "cici", Team: 0, Rank: 1},
+		{ID: "budi", Team: 1, Rank: 2},
+		{ID: "dodi", Team: 1, Rank: 2},
+	}, DefaultK)
+
+	if deltas["ana"] != 16 || deltas["cici"] != 16 || deltas["dodi"] != -16 {
+		t.Fatalf("Expecting partners to share the team change but got %v", deltas)
+	}
+}
+
+func TestExport(t *testing.T) {
+	table := Table{}
+	table.Update([]Entrant{{ID: "ana", Team: 0, Rank: 1}, {ID: BotID("random"), Team: 1, Rank: 2}}, DefaultK)
+
+	var buf bytes.Buffer
+	if err := table.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1,ana,false,1516.0") || !strings.Contains(lines[2], "cpu:random,true") {
+		t.Fatalf("Unexpected CSV export:\n%s", buf.String())
+	}
+}
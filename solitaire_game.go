@@ -0,0 +1,375 @@
+package domino
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/rivo/tview"
+)
+
+// solitaireRules is the double-six set every solitaire is dealt from.
+var solitaireRules = engine.Ruleset{Name: "solitaire", MinPip: 0, MaxPip: 6}
+
+// SolitaireGame is the terminal screen of the solitaire games. The layout
+// is drawn with the cards of a seeded Deck, free tiles marked, and Five-Up
+// shows its line of play below. Nobody takes turns; the rules live in
+// engine.Solitaire.
+type SolitaireGame struct {
+	*tview.Flex
+	App *tview.Application
+	// Profiles, when set, receives the result of every finished deal under
+	// the Player's name
+	Profiles *profile.Store
+	Player   string
+
+	kind   engine.SolitaireKind
+	game   *engine.Solitaire
+	cards  []*Card
+	daily  bool
+	board  *tview.Grid
+	line   *tview.Flex
+	log    *tview.TextView
+	status *tview.TextView
+	finish bool
+	// cursor is the slot under the cursor, picked the slots about to be
+	// removed together
+	cursor int
+	picked map[int]bool
+}
+
+// NewSolitaireGame returns the screen of the solitaire game.
+func NewSolitaireGame(kind engine.SolitaireKind) *SolitaireGame {
+	game := &SolitaireGame{
+		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
+		App:    tview.NewApplication(),
+		Player: "Player 1",
+		kind:   kind,
+		board:  tview.NewGrid(),
+		line:   tview.NewFlex(),
+		log:    tview.NewTextView().SetDynamicColors(true),
+		status: tview.NewTextView().SetDynamicColors(true),
+		picked: map[int]bool{},
+	}
+
+	game.board.SetBorder(true).SetTitle(fmt.Sprintf("Solitaire: %s", kind))
+	game.line.SetBorder(true).SetTitle("Line")
+	game.log.SetBorder(true).SetTitle("Log")
+	game.status.SetBackgroundColor(tcell.ColorYellow)
+
+	top := tview.NewFlex()
+	top.AddItem(game.board, 0, 3, false)
+	top.AddItem(game.log, 0, 1, false)
+	game.AddItem(top, 0, 1, false)
+	if kind == engine.FiveUp {
+		game.AddItem(game.line, 12, 0, false)
+	}
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	return game
+}
+
+// Deal lays out the deal of the seed.
+func (g *SolitaireGame) Deal(seed int64) error {
+	deck := NewRulesDeck(solitaireRules)
+	deck.ShuffleSeed(seed)
+	game, err := engine.NewSolitaire(g.kind, deck.Tiles())
+	if err != nil {
+		return err
+	}
+
+	// the slots take the cards off the deck in the order they were laid
+	g.cards = nil
+	for range game.Slots {
+		g.cards = append(g.cards, deck.PopCards(1)[0])
+	}
+
+	game.Seed = seed
+	g.game, g.daily, g.finish = game, false, false
+	g.cursor, g.picked = g.firstFree(), map[int]bool{}
+	g.Log(fmt.Sprintf("Deal %d of %s", seed, g.kind))
+	g.Log(g.help())
+	g.refresh()
+	return nil
+}
+
+// DealDaily lays out the day's challenge, the same deal for everybody.
+func (g *SolitaireGame) DealDaily(day time.Time) error {
+	if err := g.Deal(engine.DailySeed(day)); err != nil {
+		return err
+	}
+
+	g.daily = true
+	g.Log(fmt.Sprintf("Daily challenge of %s", day.Format("2006-01-02")))
+	g.refresh()
+	return nil
+}
+
+func (g *SolitaireGame) help() string {
+	if g.kind == engine.FiveUp {
+		return "Keys: Left/Right select a free tile, Enter play it, Up/Down play on head/tail, h hint, n new deal, d daily deal"
+	}
+
+	return fmt.Sprintf("Keys: Left/Right select a free tile, Space pick it, Enter remove the tiles of %d pips, h hint, n new deal, d daily deal", engine.SolitairePips)
+}
+
+func (g *SolitaireGame) Run() {
+	if err := g.App.SetRoot(g, true).SetFocus(g).Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (g *SolitaireGame) Log(s string) {
+	fmt.Fprintf(g.log, "[violet::r][sys[]:%s\n[white::-]", s)
+}
+
+// free returns the free slots in layout order.
+func (g *SolitaireGame) free() []int {
+	free := []int{}
+	for i := range g.game.Slots {
+		if g.game.Free(i) {
+			free = append(free, i)
+		}
+	}
+
+	return free
+}
+
+func (g *SolitaireGame) firstFree() int {
+	if free := g.free(); len(free) > 0 {
+		return free[0]
+	}
+
+	return -1
+}
+
+func (g *SolitaireGame) refresh() {
+	g.refreshBoard()
+	g.refreshLine()
+
+	status := fmt.Sprintf("[black::b]%s [SEED:%d] [LEFT:%d] [MOVES:%d]", g.kind, g.game.Seed, g.game.Left(), g.game.Moves)
+	if g.daily {
+		status += " [DAILY]"
+	}
+	if g.kind == engine.FiveUp {
+		status += fmt.Sprintf(" [SCORE:%d]", g.game.Score)
+	}
+	switch {
+	case g.game.Won():
+		status += " [WON]"
+	case g.game.Stuck():
+		status += " [STUCK]"
+	}
+	g.status.SetText(status)
+}
+
+// refreshBoard draws the tiles still laid out, a pyramid centred over its
+// bottom row and the other layouts in columns.
+func (g *SolitaireGame) refreshBoard() {
+	g.board.Clear()
+	rows, cols, span := []int{}, []int{}, 1
+	for _, slot := range g.game.Slots {
+		for len(rows) <= slot.Row {
+			rows = append(rows, 6)
+		}
+		for len(cols) <= slot.Col {
+			cols = append(cols, 10)
+		}
+	}
+
+	if g.kind == engine.Jubilee {
+		cols, span = append(cols, cols...), 2
+		for i := range cols {
+			cols[i] = 5
+		}
+	}
+	g.board.SetRows(rows...).SetColumns(cols...)
+
+	for i, slot := range g.game.Slots {
+		if slot.Gone {
+			continue
+		}
+
+		card := g.cards[i]
+		card.ClearHighlight()
+		card.MarkPlayable(g.game.Free(i))
+		if g.picked[i] {
+			card.SetTitle(fmt.Sprintf("+[%d,%d]", card.X, card.Y))
+		}
+		if i == g.cursor {
+			card.Highlight()
+		}
+
+		col := slot.Col
+		if g.kind == engine.Jubilee {
+			col = 6 - slot.Row + 2*slot.Col
+		}
+		g.board.AddItem(card, slot.Row, col, 1, span, 0, 0, false)
+	}
+}
+
+// refreshLine shows both ends of the line of Five-Up.
+func (g *SolitaireGame) refreshLine() {
+	if g.kind != engine.FiveUp {
+		return
+	}
+
+	g.line.Clear()
+	line := g.game.Line.Line
+	g.line.SetTitle(fmt.Sprintf("Line [%d tiles, head %d, tail %d]", len(line), g.game.Line.Head(), g.game.Line.Tail()))
+	for i, t := range line {
+		if len(line) > 6 && i == 3 {
+			g.line.AddItem(tview.NewTextView().SetText(" ..."), 5, 0, false)
+		}
+		if len(line) > 6 && i >= 3 && i < len(line)-3 {
+			continue
+		}
+		g.line.AddItem(NewCard(t.A, t.B), 10, 0, false)
+	}
+}
+
+func (g *SolitaireGame) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.game == nil {
+		return event
+	}
+
+	switch {
+	case event.Rune() == 'n':
+		g.Deal(time.Now().UnixNano() % 1000000)
+		return nil
+	case event.Rune() == 'd':
+		g.DealDaily(time.Now())
+		return nil
+	case g.finish:
+		return nil
+	}
+
+	free := g.free()
+	at := 0
+	for i, slot := range free {
+		if slot == g.cursor {
+			at = i
+		}
+	}
+
+	switch {
+	case event.Key() == tcell.KeyLeft && len(free) > 0:
+		g.cursor = free[(at+len(free)-1)%len(free)]
+	case event.Key() == tcell.KeyRight && len(free) > 0:
+		g.cursor = free[(at+1)%len(free)]
+	case event.Rune() == ' ' && g.kind != engine.FiveUp:
+		g.picked[g.cursor] = !g.picked[g.cursor]
+	case event.Key() == tcell.KeyEnter:
+		g.move(g.pickedMove())
+		return nil
+	case event.Key() == tcell.KeyUp && g.kind == engine.FiveUp:
+		g.move(engine.SolitaireMove{Slots: []int{g.cursor}, End: engine.Head})
+		return nil
+	case event.Key() == tcell.KeyDown && g.kind == engine.FiveUp:
+		g.move(engine.SolitaireMove{Slots: []int{g.cursor}, End: engine.Tail})
+		return nil
+	case event.Rune() == 'h':
+		g.hint()
+	}
+
+	g.refresh()
+	return nil
+}
+
+// pickedMove returns the move of the picked tiles, the one under the
+// cursor when none is, played on the end it fits in Five-Up.
+func (g *SolitaireGame) pickedMove() engine.SolitaireMove {
+	m := engine.SolitaireMove{}
+	for i := range g.game.Slots {
+		if g.picked[i] {
+			m.Slots = append(m.Slots, i)
+		}
+	}
+
+	if len(m.Slots) == 0 {
+		m.Slots = []int{g.cursor}
+	}
+
+	if g.kind == engine.FiveUp && g.cursor >= 0 {
+		m.End, _ = g.game.Line.Position(-1).EndFor(g.game.Slots[g.cursor].Tile)
+	}
+
+	return m
+}
+
+// hint moves the cursor to a worthwhile move and tells it.
+func (g *SolitaireGame) hint() {
+	m, ok := g.game.Hint()
+	if !ok {
+		g.Log("Hint: nothing can be moved")
+		return
+	}
+
+	tiles := []engine.Tile{}
+	for _, slot := range m.Slots {
+		tiles = append(tiles, g.game.Slots[slot].Tile)
+	}
+
+	g.cursor = m.Slots[0]
+	if g.kind == engine.FiveUp {
+		g.Log(fmt.Sprintf("Hint: play %s on the %s", tiles[0], m.End))
+	} else {
+		g.Log(fmt.Sprintf("Hint: remove %v", tiles))
+	}
+}
+
+// move makes the move and checks whether the deal is won or lost.
+func (g *SolitaireGame) move(m engine.SolitaireMove) {
+	if err := g.game.Play(m); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.picked = map[int]bool{}
+	g.cursor = g.firstFree()
+	if g.game.Won() || g.game.Stuck() {
+		g.end()
+	}
+
+	g.refresh()
+}
+
+func (g *SolitaireGame) end() {
+	g.finish = true
+	if g.game.Won() {
+		g.Log(fmt.Sprintf("[::b]WON in %d moves", g.game.Moves))
+	} else {
+		g.Log(fmt.Sprintf("[::b]STUCK with %d tiles left", g.game.Left()))
+	}
+	if g.kind == engine.FiveUp {
+		g.Log(fmt.Sprintf("Score: %d", g.game.Score))
+	}
+
+	g.recordResult()
+	g.Log("Press n for a new deal or d for the daily one")
+}
+
+// recordResult stores the finished deal in the player's profile.
+func (g *SolitaireGame) recordResult() {
+	if g.Profiles == nil {
+		return
+	}
+
+	pips := 0
+	for _, slot := range g.game.Slots {
+		if !slot.Gone {
+			pips += slot.Tile.Pips()
+		}
+	}
+
+	g.Profiles.Record(profile.Result{
+		Variant: string(g.kind),
+		Seats:   []profile.Seat{{Name: g.Player, Winner: g.game.Won(), WentOut: g.game.Won(), RemainingPips: pips}},
+	})
+	if err := g.Profiles.Save(); err != nil {
+		g.Log(fmt.Sprintf("Could not save profiles: %v", err))
+	}
+}
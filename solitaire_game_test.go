@@ -0,0 +1,38 @@
+package domino
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestSolitaireGame(t *testing.T) {
+	for _, kind := range engine.SolitaireKinds() {
+		game := NewSolitaireGame(kind)
+		if err := game.DealDaily(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatal(err)
+		}
+
+		dealt, _ := engine.DealSolitaire(kind, 20261019)
+		if !reflect.DeepEqual(game.game.Slots, dealt.Slots) || !game.daily {
+			t.Fatalf("Expecting the deck to lay out the daily deal of %s", kind)
+		}
+
+		for i, c := range game.cards {
+			if c.Tile() != game.game.Slots[i].Tile {
+				t.Fatalf("Expecting the card of slot %d to show %s but got %s", i, game.game.Slots[i].Tile, c.Tile())
+			}
+		}
+
+		for moves := 0; !game.finish; moves++ {
+			if moves > 28 {
+				t.Fatalf("Expecting %s over once no tile can move", kind)
+			}
+
+			m, _ := game.game.Hint()
+			game.move(m)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package domino
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// armTiles is how many tiles of an arm the board shows, the far ones are
+// summarised by their count.
+const armTiles = 6
+
+// armTile is a tile of an arm as seen from the spinner: near is the half
+// pointing at it.
+type armTile struct {
+	node      int
+	near, far int
+}
+
+// SpinnerBoard draws an all fives layout as a cross: the spinner in the
+// middle with its sides running left and right and its arms up and down.
+// Arms leaving later spinners are listed below the cross.
+type SpinnerBoard struct {
+	*tview.Flex
+	cross    *tview.Table
+	branches *tview.TextView
+}
+
+func NewSpinnerBoard() *SpinnerBoard {
+	board := &SpinnerBoard{
+		Flex:     tview.NewFlex().SetDirection(tview.FlexRow),
+		cross:    tview.NewTable(),
+		branches: tview.NewTextView().SetDynamicColors(true),
+	}
+
+	board.SetBorder(true).SetTitle("All fives")
+	board.AddItem(board.cross, 0, 1, false)
+	board.AddItem(board.branches, 0, 0, false)
+	return board
+}
+
+// Refresh redraws the layout. names holds the seat names, open the nodes
+// taking a tile and selected the node the player on turn aims at, -1 for
+// none.
+func (b *SpinnerBoard) Refresh(r *engine.SpinnerRound, names []string, open map[int]bool, selected int) {
+	scores := []string{}
+	for i, s := range r.Scores {
+		scores = append(scores, fmt.Sprintf("%s %d", names[i], s))
+	}
+
+	b.SetTitle(fmt.Sprintf("All fives [count %d, boneyard %d] %s", r.Layout.Count(), len(r.Boneyard), strings.Join(scores, ", ")))
+	b.cross.Clear()
+	b.branches.Clear()
+	l := r.Layout
+	if !l.Started() {
+		b.cross.SetCell(0, 0, tview.NewTableCell("lead any tile").SetTextColor(tcell.ColorGreen))
+		return
+	}
+
+	cell := func(text string, node int) *tview.TableCell {
+		c := tview.NewTableCell(tview.Escape(text)).SetAlign(tview.AlignCenter)
+		switch {
+		case node == selected:
+			c.SetTextColor(tcell.ColorYellow).SetAttributes(tcell.AttrReverse)
+		case open[node]:
+			c.SetTextColor(tcell.ColorGreen)
+		}
+
+		return c
+	}
+
+	centre := l.Spinner()
+	if centre < 0 {
+		centre = 0
+	}
+
+	var branches []string
+	arms := make([][]armTile, 4)
+	for i, n := range neighbours(l, centre) {
+		if i < len(arms) {
+			arms[i] = walkArm(l, centre, n, &branches)
+		}
+	}
+
+	// the arms are trimmed to the tiles nearest the spinner
+	hidden := make([]int, 4)
+	for i, arm := range arms {
+		if len(arm) > armTiles {
+			hidden[i] = len(arm) - armTiles
+			arms[i] = arm[:armTiles]
+		}
+	}
+
+	extra := func(i int) int {
+		if hidden[i] > 0 {
+			return 1
+		}
+
+		return 0
+	}
+
+	west, north := len(arms[0])+extra(0), len(arms[2])+extra(2)
+	b.cross.SetCell(north, west, cell(l.Nodes[centre].Tile.String(), centre))
+	for k, t := range arms[0] {
+		b.cross.SetCell(north, west-1-k, cell(fmt.Sprintf("%d|%d", t.far, t.near), t.node))
+	}
+
+	for k, t := range arms[1] {
+		b.cross.SetCell(north, west+1+k, cell(fmt.Sprintf("%d|%d", t.near, t.far), t.node))
+	}
+
+	for k, t := range arms[2] {
+		b.cross.SetCell(north-1-k, west, cell(fmt.Sprintf("%d|%d", t.far, t.near), t.node))
+	}
+
+	for k, t := range arms[3] {
+		b.cross.SetCell(north+1+k, west, cell(fmt.Sprintf("%d|%d", t.near, t.far), t.node))
+	}
+
+	more := func(n int) *tview.TableCell {
+		return tview.NewTableCell(fmt.Sprintf("+%d", n)).SetTextColor(tcell.ColorDarkGray).SetAlign(tview.AlignCenter)
+	}
+
+	if hidden[0] > 0 {
+		b.cross.SetCell(north, 0, more(hidden[0]))
+	}
+
+	if hidden[1] > 0 {
+		b.cross.SetCell(north, west+1+len(arms[1]), more(hidden[1]))
+	}
+
+	if hidden[2] > 0 {
+		b.cross.SetCell(0, west, more(hidden[2]))
+	}
+
+	if hidden[3] > 0 {
+		b.cross.SetCell(north+1+len(arms[3]), west, more(hidden[3]))
+	}
+
+	b.ResizeItem(b.branches, len(branches), 0)
+	fmt.Fprint(b.branches, strings.Join(branches, "\n"))
+}
+
+// neighbours returns the nodes touching the node: the one it was played on
+// first, then what was played on it. The first tile lists the tile on its A
+// half first.
+func neighbours(l engine.Layout, node int) []int {
+	n := l.Nodes[node]
+	if n.Parent >= 0 {
+		return append([]int{n.Parent}, n.Children...)
+	}
+
+	kids := append([]int(nil), n.Children...)
+	if !n.Tile.IsDouble() && len(kids) == 2 && l.Nodes[kids[0]].Tile.A != n.Tile.A {
+		kids[0], kids[1] = kids[1], kids[0]
+	}
+
+	return kids
+}
+
+// walkArm follows the layout from the node away from the one it came from,
+// going straight on through spinners. The arms across later spinners are
+// added to branches as text.
+func walkArm(l engine.Layout, from, node int, branches *[]string) []armTile {
+	arm := []armTile{}
+	for node >= 0 {
+		n := l.Nodes[node]
+		t := armTile{node: node, near: n.Tile.A, far: n.Tile.B}
+		switch {
+		case n.Parent != from && n.Parent < 0:
+			// the first tile, reached from one of its sides
+			t.near = l.Nodes[from].Tile.A
+			t.far = n.Tile.Other(t.near)
+		case n.Parent != from:
+			t.near, t.far = n.Tile.B, n.Tile.A
+		}
+		arm = append(arm, t)
+
+		next := -1
+		for _, o := range neighbours(l, node) {
+			switch {
+			case o == from:
+			case next < 0:
+				next = o
+			default:
+				side := walkArm(l, node, o, branches)
+				tiles := []string{}
+				for _, s := range side {
+					tiles = append(tiles, fmt.Sprintf("%d|%d", s.near, s.far))
+				}
+				*branches = append(*branches, tview.Escape(fmt.Sprintf("from %s: %s", n.Tile, strings.Join(tiles, " "))))
+			}
+		}
+
+		from, node = node, next
+	}
+
+	return arm
+}
+
+// spinnerLayout plays a SpinnerRound in a BranchGame. Targets encode a
+// LayoutEnd as 2*(node+1)+side, side telling the two halves of a first
+// tile that is not a double apart.
+type spinnerLayout struct {
+	*engine.SpinnerRound
+	view  *SpinnerBoard
+	names []string
+}
+
+func newSpinnerLayout(r *engine.SpinnerRound, names []string) *spinnerLayout {
+	return &spinnerLayout{SpinnerRound: r, view: NewSpinnerBoard(), names: names}
+}
+
+// encodeEnd returns the target of the end.
+func encodeEnd(layout engine.Layout, e engine.LayoutEnd) int {
+	side := 0
+	if e.Node >= 0 {
+		t := layout.Nodes[e.Node].Tile
+		if layout.Nodes[e.Node].Parent < 0 && !t.IsDouble() && e.Pip == t.B {
+			side = 1
+		}
+	}
+
+	return 2*(e.Node+1) + side
+}
+
+func (l *spinnerLayout) decode(target int) engine.LayoutEnd {
+	node := target/2 - 1
+	if node < 0 {
+		return engine.LayoutEnd{Node: -1}
+	}
+
+	n := l.Layout.Nodes[node]
+	if n.Parent < 0 && target%2 == 0 {
+		return engine.LayoutEnd{Node: node, Pip: n.Tile.A}
+	}
+
+	return engine.LayoutEnd{Node: node, Pip: n.Tile.B}
+}
+
+func (l *spinnerLayout) turn() int { return l.Turn }
+
+func (l *spinnerLayout) hand(player int) []engine.Tile { return l.Hands[player] }
+
+func (l *spinnerLayout) legal(player int) []placement {
+	moves := []placement{}
+	for _, m := range l.LegalMoves(player) {
+		moves = append(moves, placement{tile: m.Tile, target: encodeEnd(l.Layout, m.End)})
+	}
+
+	return moves
+}
+
+func (l *spinnerLayout) play(p placement) error {
+	return l.Play(engine.SpinnerMove{Tile: p.tile, End: l.decode(p.target)})
+}
+
+func (l *spinnerLayout) targets() []int {
+	targets := []int{}
+	for _, e := range l.Layout.Ends() {
+		targets = append(targets, encodeEnd(l.Layout, e))
+	}
+
+	return targets
+}
+
+func (l *spinnerLayout) targetName(target int) string {
+	e := l.decode(target)
+	switch {
+	case e.Node < 0:
+		return "the table"
+	case e.Node == l.Layout.Spinner():
+		return fmt.Sprintf("the spinner %s", tview.Escape(l.Layout.Nodes[e.Node].Tile.String()))
+	}
+
+	return fmt.Sprintf("the %d of %s", e.Pip, tview.Escape(l.Layout.Nodes[e.Node].Tile.String()))
+}
+
+func (l *spinnerLayout) after(p placement) string {
+	last := l.History[len(l.History)-1]
+	switch {
+	case last.Points > 0:
+		return fmt.Sprintf("%s scores %d, the ends count %d", l.names[last.Player], last.Points, l.Layout.Count())
+	case p.tile.IsDouble() && l.Layout.Spinner() == len(l.Layout.Nodes)-1:
+		return fmt.Sprintf("%s is the spinner", tview.Escape(p.tile.String()))
+	}
+
+	return ""
+}
+
+func (l *spinnerLayout) hint() (placement, string, bool) {
+	scored := engine.EvaluateSpinner(l.Position(l.Turn))
+	if len(scored) == 0 {
+		return placement{}, "", false
+	}
+
+	return placement{tile: scored[0].Tile, target: encodeEnd(l.Layout, scored[0].End)}, scored[0].Reason, true
+}
+
+func (l *spinnerLayout) chooser(s engine.Strategy) func() (placement, bool) {
+	pos := l.Position(l.Turn)
+	return func() (placement, bool) {
+		m, ok := engine.ChooseSpinner(s, pos)
+		return placement{tile: m.Tile, target: encodeEnd(pos.Layout, m.End)}, ok
+	}
+}
+
+func (l *spinnerLayout) board() tview.Primitive { return l.view }
+
+func (l *spinnerLayout) refresh(selected int) {
+	open := map[int]bool{}
+	for _, e := range l.Layout.Ends() {
+		open[e.Node] = true
+	}
+
+	node := -1
+	if selected >= 0 {
+		node = selected/2 - 1
+	}
+
+	l.view.Refresh(l.SpinnerRound, l.names, open, node)
+}
+
+func (l *spinnerLayout) describe() string {
+	if len(l.History) == 0 {
+		return fmt.Sprintf("led by %s", l.names[l.Turn])
+	}
+
+	first := l.History[0]
+	return fmt.Sprintf("opened by %s with %s for %d", l.names[first.Player], tview.Escape(first.Move.Tile.String()), first.Points)
+}
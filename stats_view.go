@@ -0,0 +1,76 @@
+package domino
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/rivo/tview"
+)
+
+// StatsView lists every stored player profile with a row per variant.
+type StatsView struct {
+	*tview.Table
+	store *profile.Store
+}
+
+func NewStatsView(store *profile.Store) *StatsView {
+	view := &StatsView{
+		Table: tview.NewTable().SetFixed(1, 0),
+		store: store,
+	}
+
+	view.SetBorder(true).SetTitle("Player Statistics [Esc to close]")
+	return view
+}
+
+// Refresh reloads the table from the store.
+func (v *StatsView) Refresh() {
+	v.Clear()
+	for col, h := range []string{"Player", "Games", "Wins", "Win %", "Avg pips", "Dominoes", "Blocked"} {
+		v.SetCell(0, col, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	if v.store == nil {
+		v.SetCell(1, 0, tview.NewTableCell("No profile store configured"))
+		return
+	}
+
+	row := 1
+	for _, p := range v.store.Sorted() {
+		v.setRecord(row, p.Name, p.Record, tcell.ColorWhite)
+		row++
+
+		for _, name := range sortedKeys(p.Variants) {
+			v.setRecord(row, "  "+name, *p.Variants[name], tcell.ColorGray)
+			row++
+		}
+	}
+}
+
+func (v *StatsView) setRecord(row int, name string, r profile.Record, color tcell.Color) {
+	cells := []string{
+		name,
+		fmt.Sprint(r.Played),
+		fmt.Sprint(r.Wins),
+		fmt.Sprintf("%.0f", r.WinRate()*100),
+		fmt.Sprintf("%.1f", r.AveragePips()),
+		fmt.Sprint(r.Dominoes),
+		fmt.Sprint(r.Blocked),
+	}
+
+	for col, text := range cells {
+		v.SetCell(row, col, tview.NewTableCell(text).SetTextColor(color))
+	}
+}
+
+func sortedKeys(m map[string]*profile.Record) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
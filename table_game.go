@@ -0,0 +1,205 @@
+package domino
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/rating"
+	"github.com/rivo/tview"
+)
+
+// tableSeat is a player of a table or branch game, strategy is nil for
+// humans.
+type tableSeat struct {
+	name     string
+	strategy engine.Strategy
+	chips    int
+}
+
+// buyIn gives the seat its stack: a human brings their bankroll from the
+// profiles, everybody else the ruleset's chips.
+func (s *tableSeat) buyIn(rules engine.Ruleset, profiles *profile.Store) {
+	s.chips = rules.Chips
+	if s.strategy == nil && profiles != nil {
+		s.chips = profiles.Bankroll(s.name, rules.Chips)
+	}
+}
+
+// ratingID returns the id the seat is rated under: the profile name for
+// humans and the strategy for CPU players.
+func (s *tableSeat) ratingID() string {
+	if s.strategy != nil {
+		return rating.BotID(s.strategy.Name())
+	}
+
+	return s.name
+}
+
+// tableRound adapts a game dealt to a table of seats, QiuQiu, Pai Gow,
+// Tien Gow, Texas 42 or a branch game, to tableGame.
+type tableRound interface {
+	// deal starts the next hand of the match
+	deal()
+	// seated tells where the seat sat down, empty when there is nothing
+	// to tell
+	seated(seat int) string
+	// wentOut reports whether the seat played out its hand in the hand
+	// just finished, always false in games nobody goes out in
+	wentOut(seat int) bool
+}
+
+// tableGame is what the screens of the table and branch games share: the
+// seats, the match and the log. Each screen embeds it and lays its own
+// table out.
+type tableGame struct {
+	*tview.Flex
+	App *tview.Application
+	// Profiles, when set, receives the result of every finished hand and
+	// keeps the human players' chips when the game is played for chips
+	Profiles *profile.Store
+	// OnEnd, when set, is called with the finished match and Enter then
+	// stops the application so the caller can move on
+	OnEnd func(*engine.Match)
+
+	rules  engine.Ruleset
+	match  *engine.Match
+	seats  []*tableSeat
+	log    *tview.TextView
+	status *tview.TextView
+	rng    *rand.Rand
+	finish bool
+	// wager tells the seats play for chips, bought in as they join
+	wager   bool
+	variant tableRound
+}
+
+func newTableGame(rules engine.Ruleset) *tableGame {
+	game := &tableGame{
+		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
+		rules:  rules,
+		log:    tview.NewTextView().SetDynamicColors(true),
+		status: tview.NewTextView().SetDynamicColors(true),
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	game.log.SetBorder(true).SetTitle("Log")
+	game.status.SetBackgroundColor(tcell.ColorYellow)
+	return game
+}
+
+// Join adds a player, a CPU one plays the default strategy.
+func (g *tableGame) Join(playerName string, isCpu bool) {
+	if !isCpu {
+		g.join(&tableSeat{name: playerName})
+		return
+	}
+
+	s, _ := engine.NewStrategy(engine.DefaultStrategy)
+	g.join(&tableSeat{name: playerName, strategy: s})
+}
+
+// JoinCpu adds a CPU player using the named strategy. Strategies that do
+// not know the game play it like the balanced one, external bots are
+// refused.
+func (g *tableGame) JoinCpu(playerName string, strategy string) error {
+	if err := g.rules.CheckStrategy(strategy); err != nil {
+		return err
+	}
+
+	s, err := engine.NewStrategy(strategy)
+	if err != nil {
+		return err
+	}
+
+	g.join(&tableSeat{name: playerName, strategy: s})
+	return nil
+}
+
+func (g *tableGame) join(seat *tableSeat) {
+	if len(g.seats) >= g.rules.Players {
+		g.Log(fmt.Sprintf("Can't join player: %s to game. Players already full ", seat.name))
+		return
+	}
+
+	g.seats = append(g.seats, seat)
+	if g.wager {
+		seat.buyIn(g.rules, g.Profiles)
+	}
+
+	joined := fmt.Sprintf("%s joined", seat.name)
+	if where := g.variant.seated(len(g.seats) - 1); where != "" {
+		joined += " " + where
+	}
+	g.Log(joined)
+
+	if len(g.seats) == g.rules.Players {
+		g.App = tview.NewApplication()
+		g.match = engine.NewMatch(g.rules, len(g.seats))
+		g.variant.deal()
+	}
+}
+
+// Started reports whether every seat is taken and a hand is dealt.
+func (g *tableGame) Started() bool {
+	return g.match != nil
+}
+
+func (g *tableGame) Run() {
+	defer func() {
+		for _, s := range g.seats {
+			if c, ok := s.strategy.(io.Closer); ok {
+				c.Close()
+			}
+		}
+	}()
+
+	if err := g.App.SetRoot(g.Flex, true).SetFocus(g.Flex).Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (g *tableGame) Log(s string) {
+	fmt.Fprintf(g.log, "[violet::r][sys[]:%s\n[white::-]", s)
+}
+
+// recordResult stores the finished hand in the human players' profiles,
+// with the chips they have left when they play for chips, and rates every
+// seat.
+func (g *tableGame) recordResult(result engine.Result) {
+	if g.Profiles == nil {
+		return
+	}
+
+	record := profile.Result{Variant: g.rules.Name, Blocked: result.Blocked}
+	for i, s := range g.seats {
+		if s.strategy != nil {
+			continue
+		}
+
+		record.Seats = append(record.Seats, profile.Seat{
+			Name:          s.name,
+			Winner:        result.IsWinner(i),
+			WentOut:       g.variant.wentOut(i),
+			RemainingPips: result.Pips[i],
+		})
+		if g.wager {
+			g.Profiles.SetBankroll(s.name, s.chips)
+		}
+	}
+
+	g.Profiles.Record(record)
+	ids := make([]string, len(g.seats))
+	for i, s := range g.seats {
+		ids[i] = s.ratingID()
+	}
+
+	rateSeats(g.Profiles, g.rules, ids, result, g.Log)
+	if err := g.Profiles.Save(); err != nil {
+		g.Log(fmt.Sprintf("Could not save profiles: %v", err))
+	}
+}
@@ -0,0 +1,461 @@
+package domino
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// Texas42Game is the terminal screen of Texas 42. The table has a row per
+// seat with its bid and the tile it put on the trick, the scoreboard keeps
+// the marks of both partnerships and the hand of the human is below. The
+// tiles come off a shuffled 28-tile Deck; the rules live in
+// engine.TexasRound.
+type Texas42Game struct {
+	*tableGame
+
+	round *engine.TexasRound
+	table *tview.Grid
+	score *tview.TextView
+	hand  *tview.Flex
+	// cursor is the tile of the human's hand under the cursor, bid the bid
+	// they are about to make
+	cursor int
+	bid    int
+}
+
+// NewTexas42Game returns the screen for a Texas 42 ruleset.
+func NewTexas42Game(rules engine.Ruleset) *Texas42Game {
+	game := &Texas42Game{
+		tableGame: newTableGame(rules),
+		table:     tview.NewGrid(),
+		score:     tview.NewTextView().SetDynamicColors(true),
+		hand:      tview.NewFlex(),
+	}
+	game.variant = game
+
+	game.table.SetBorder(true).SetTitle("Texas 42")
+	game.score.SetBorder(true).SetTitle("Marks")
+	game.hand.SetBorder(true).SetTitle("Hand")
+
+	side := tview.NewFlex().SetDirection(tview.FlexRow)
+	side.AddItem(game.score, 10, 0, false)
+	side.AddItem(game.log, 0, 1, false)
+
+	top := tview.NewFlex()
+	top.AddItem(game.table, 0, 2, false)
+	top.AddItem(side, 0, 1, false)
+	game.AddItem(top, 0, 2, false)
+	game.AddItem(game.hand, 12, 0, false)
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	game.Log(fmt.Sprintf("Waiting for players of %s...", rules.Name))
+	return game
+}
+
+func (g *Texas42Game) seated(seat int) string {
+	return g.teamName(engine.Team(seat))
+}
+
+func (g *Texas42Game) wentOut(seat int) bool {
+	return false
+}
+
+// teamName names a partnership after its players.
+func (g *Texas42Game) teamName(team int) string {
+	names := []string{}
+	for i, s := range g.seats {
+		if engine.Team(i) == team {
+			names = append(names, s.name)
+		}
+	}
+
+	return fmt.Sprintf("team %d (%s)", team+1, strings.Join(names, " & "))
+}
+
+// deal shuffles the deck and starts the bidding of the next hand.
+func (g *Texas42Game) deal() {
+	g.finish = false
+	deck := NewRulesDeck(g.rules)
+	deck.Shuffle()
+
+	hands := [][]engine.Tile{}
+	for range g.seats {
+		tiles := []engine.Tile{}
+		for _, c := range deck.PopCards(g.rules.HandSize) {
+			tiles = append(tiles, c.Tile())
+		}
+		hands = append(hands, tiles)
+	}
+
+	hand := len(g.match.Hands)
+	g.round = engine.NewTexasRound(hands, hand%len(g.seats))
+	g.Log(fmt.Sprintf("Hand %d: %s shakes, %s bids first", hand+1, g.seats[g.round.Dealer].name, g.current().name))
+	g.Log("Keys: Up/Down change the bid, Enter bid, p pass, 0-6 name trumps, Left/Right select, Enter play, h hint")
+	g.startTurn()
+}
+
+func (g *Texas42Game) current() *tableSeat {
+	return g.seats[g.round.Turn]
+}
+
+// startTurn refreshes the table for the player on turn and lets a CPU
+// player think outside the UI thread.
+func (g *Texas42Game) startTurn() {
+	if g.round.Over() {
+		g.end()
+		return
+	}
+
+	g.cursor, g.bid = 0, g.round.MinNextBid()
+	g.refresh()
+	seat := g.current()
+	if seat.strategy == nil {
+		return
+	}
+
+	pos := g.round.Position()
+	bidding, naming := g.round.Bidding(), g.round.Trump < 0
+	go func() {
+		var move func()
+		switch {
+		case bidding:
+			bid := engine.BidTexas(seat.strategy, pos)
+			move = func() { g.makeBid(bid) }
+		case naming:
+			suit := engine.TrumpTexas(seat.strategy, pos)
+			move = func() { g.nameTrump(suit) }
+		default:
+			t := engine.ChooseTexas(seat.strategy, pos)
+			move = func() { g.play(t) }
+		}
+
+		time.Sleep(700 * time.Millisecond)
+		g.App.QueueUpdateDraw(func() {
+			if g.finish || g.current() != seat {
+				return
+			}
+
+			move()
+		})
+	}()
+}
+
+// viewer returns the human whose hand is shown: the only one, or the one
+// on turn when several share the screen. It is -1 when there is none.
+func (g *Texas42Game) viewer() int {
+	humans := []int{}
+	for i, s := range g.seats {
+		if s.strategy == nil {
+			humans = append(humans, i)
+		}
+	}
+
+	switch {
+	case len(humans) == 1:
+		return humans[0]
+	case !g.finish && g.current().strategy == nil:
+		return g.round.Turn
+	}
+
+	return -1
+}
+
+// bidName tells a bid, TexasPass or the bid for two marks.
+func bidName(bid int) string {
+	switch bid {
+	case engine.TexasPass:
+		return "pass"
+	case engine.MarksBid:
+		return "2 marks"
+	}
+
+	return fmt.Sprint(bid)
+}
+
+// tally draws marks the way they are kept on a 42 scoreboard, in groups
+// of five.
+func tally(marks int) string {
+	groups := []string{}
+	for ; marks > 5; marks -= 5 {
+		groups = append(groups, "|||||")
+	}
+
+	return strings.Join(append(groups, strings.Repeat("|", marks)), " ")
+}
+
+func (g *Texas42Game) refresh() {
+	g.refreshTable()
+	g.refreshScore()
+	g.refreshHand()
+
+	status := fmt.Sprintf("[black::b]%s [HAND:%d]", g.rules.Name, len(g.match.Hands)+1)
+	if g.round.Bidder >= 0 {
+		status += fmt.Sprintf(" [BID:%s by %s]", bidName(g.round.Bid), g.seats[g.round.Bidder].name)
+	}
+	if g.round.Trump >= 0 {
+		status += fmt.Sprintf(" [TRUMPS:%ds]", g.round.Trump)
+	}
+	if !g.finish {
+		status += fmt.Sprintf(" [ON TURN:%s]", g.current().name)
+		switch {
+		case g.current().strategy != nil:
+		case g.round.Bidding():
+			status += fmt.Sprintf(" [YOUR BID:%s]", bidName(g.bid))
+		case g.round.Trump < 0:
+			status += " [NAME TRUMPS 0-6]"
+		}
+	}
+	g.status.SetText(status)
+}
+
+// refreshTable shows the bids and the trick being played, or the last one
+// while the next is not led yet.
+func (g *Texas42Game) refreshTable() {
+	g.table.Clear()
+	plays := g.round.Trick
+	g.table.SetTitle("Texas 42")
+	if len(plays) == 0 && len(g.round.History) > 0 {
+		last := g.round.History[len(g.round.History)-1]
+		plays = last.Plays
+		g.table.SetTitle(fmt.Sprintf("Texas 42 [last trick, %d points to %s]", last.Points, g.seats[last.Winner].name))
+	}
+
+	rows := make([]int, len(g.seats))
+	for i := range rows {
+		rows[i] = 10
+	}
+	g.table.SetRows(rows...).SetColumns(24, 10)
+
+	for i, s := range g.seats {
+		info := tview.NewTextView().SetDynamicColors(true)
+		fmt.Fprintf(info, "%s\nteam %d\n", s.name, engine.Team(i)+1)
+		if i == g.round.Dealer {
+			fmt.Fprint(info, "dealer\n")
+		}
+
+		bid := (i - g.round.Dealer - 1 + len(g.seats)) % len(g.seats)
+		if bid < len(g.round.Bids) {
+			fmt.Fprintf(info, "bid %s\n", bidName(g.round.Bids[bid]))
+		}
+
+		if !g.finish && i == g.round.Turn {
+			fmt.Fprint(info, "[yellow]on turn[white]\n")
+		}
+		g.table.AddItem(info, i, 0, 1, 1, 0, 0, false)
+	}
+
+	for _, p := range plays {
+		card := NewCard(p.Tile.A, p.Tile.B)
+		if len(g.round.Trick) > 0 && p == g.round.Winning() {
+			card.MarkPlayable(true)
+		}
+		g.table.AddItem(card, p.Player, 1, 1, 1, 0, 0, false)
+	}
+}
+
+// refreshScore shows the marks of both teams, what they took in this hand
+// and what the bidders need.
+func (g *Texas42Game) refreshScore() {
+	g.score.Clear()
+	// both partners score the marks, seats 0 and 1 keep them for the teams
+	for team := 0; team < 2; team++ {
+		fmt.Fprintf(g.score, "[::b]%s[::-]\n", tview.Escape(g.teamName(team)))
+		fmt.Fprintf(g.score, "%d marks %s\n", g.match.Scores[team], tally(g.match.Scores[team]))
+		fmt.Fprintf(g.score, "%d points this hand\n", g.round.Points[team])
+	}
+
+	if g.round.Bidder >= 0 && !g.round.Bidding() {
+		fmt.Fprintf(g.score, "team %d needs %d of 42", engine.Team(g.round.Bidder)+1, g.round.Need())
+	}
+	fmt.Fprintf(g.score, "\nfirst to %d marks", g.rules.TargetScore)
+}
+
+// refreshHand shows the viewer's tiles, the playable ones marked while
+// they are on turn.
+func (g *Texas42Game) refreshHand() {
+	g.hand.Clear()
+	viewer := g.viewer()
+	if viewer < 0 {
+		g.hand.SetTitle("Hand")
+		return
+	}
+
+	g.hand.SetTitle(fmt.Sprintf("Hand of %s", g.seats[viewer].name))
+	playing := !g.finish && viewer == g.round.Turn && !g.round.Bidding() && g.round.Trump >= 0
+	legal := []engine.Tile{}
+	if playing {
+		legal = g.round.LegalMoves()
+	}
+
+	for i, t := range g.round.Hands[viewer] {
+		card := NewCard(t.A, t.B)
+		for _, l := range legal {
+			if l.Same(t) {
+				card.MarkPlayable(true)
+			}
+		}
+		if playing && i == g.cursor {
+			card.Highlight()
+		}
+		g.hand.AddItem(card, 10, 0, false)
+	}
+}
+
+func (g *Texas42Game) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.round == nil {
+		return event
+	}
+
+	if g.finish {
+		switch {
+		case g.match.Over() && g.OnEnd != nil && event.Key() == tcell.KeyEnter:
+			g.App.Stop()
+		case event.Rune() == 'n':
+			if g.match.Over() {
+				g.match = engine.NewMatch(g.rules, len(g.seats))
+			}
+			g.deal()
+		}
+		return nil
+	}
+
+	if g.current().strategy != nil {
+		return event
+	}
+
+	switch {
+	case g.round.Bidding():
+		g.inputBid(event)
+	case g.round.Trump < 0:
+		if r := event.Rune(); r >= '0' && r <= '6' {
+			g.nameTrump(int(r - '0'))
+			return nil
+		}
+	default:
+		size := len(g.round.Hands[g.round.Turn])
+		switch event.Key() {
+		case tcell.KeyLeft:
+			g.cursor = (g.cursor + size - 1) % size
+		case tcell.KeyRight:
+			g.cursor = (g.cursor + 1) % size
+		case tcell.KeyEnter:
+			g.play(g.round.Hands[g.round.Turn][g.cursor])
+			return nil
+		}
+	}
+
+	if event.Rune() == 'h' {
+		g.hint()
+	}
+
+	g.refresh()
+	return nil
+}
+
+// inputBid moves the bid of the human on turn between the lowest one
+// topping the others and the bid for two marks. After 2 marks only a pass
+// is left.
+func (g *Texas42Game) inputBid(event *tcell.EventKey) {
+	switch {
+	case (event.Key() == tcell.KeyUp || event.Key() == tcell.KeyDown) && g.bid == engine.TexasPass:
+	case event.Key() == tcell.KeyUp && g.bid == engine.AllBid:
+		g.bid = engine.MarksBid
+	case event.Key() == tcell.KeyUp && g.bid < engine.AllBid:
+		g.bid++
+	case event.Key() == tcell.KeyDown && g.bid == engine.MarksBid && g.round.MinNextBid() <= engine.AllBid:
+		g.bid = engine.AllBid
+	case event.Key() == tcell.KeyDown && g.bid > g.round.MinNextBid() && g.bid != engine.MarksBid:
+		g.bid--
+	case event.Key() == tcell.KeyEnter:
+		g.makeBid(g.bid)
+	case event.Rune() == 'p':
+		g.makeBid(engine.TexasPass)
+	}
+}
+
+// hint logs what the balanced strategy would do in the human's place.
+func (g *Texas42Game) hint() {
+	s, _ := engine.NewStrategy(engine.DefaultStrategy)
+	pos := g.round.Position()
+	switch {
+	case g.round.Bidding():
+		g.Log(fmt.Sprintf("Hint: bid %s", bidName(engine.BidTexas(s, pos))))
+	case g.round.Trump < 0:
+		g.Log(fmt.Sprintf("Hint: name %ds trumps", engine.TrumpTexas(s, pos)))
+	default:
+		g.Log(fmt.Sprintf("Hint: play %s", engine.ChooseTexas(s, pos)))
+	}
+}
+
+// makeBid bids for the player on turn and logs it.
+func (g *Texas42Game) makeBid(bid int) {
+	seat := g.current()
+	if err := g.round.MakeBid(bid); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s bids %s", seat.name, bidName(bid)))
+	if !g.round.Bidding() {
+		g.Log(fmt.Sprintf("%s wins the bid at %s", g.current().name, bidName(g.round.Bid)))
+	}
+	g.startTurn()
+}
+
+// nameTrump names trumps for the bidder and logs it.
+func (g *Texas42Game) nameTrump(suit int) {
+	if err := g.round.NameTrump(suit); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s names %ds trumps and leads", g.current().name, suit))
+	g.startTurn()
+}
+
+// play puts the tile of the player on turn on the trick and logs it.
+func (g *Texas42Game) play(t engine.Tile) {
+	seat, tricks := g.current(), len(g.round.History)
+	if err := g.round.Play(t); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	g.Log(fmt.Sprintf("%s plays %s", seat.name, t))
+	if len(g.round.History) > tricks {
+		trick := g.round.History[tricks]
+		g.Log(fmt.Sprintf("%s takes the trick for %d points", g.seats[trick.Winner].name, trick.Points))
+	}
+	g.startTurn()
+}
+
+func (g *Texas42Game) end() {
+	result := g.round.Result()
+	g.match.Add(result)
+	g.finish = true
+	g.refresh()
+	g.Log(fmt.Sprintf("[::b]HAND FINISHED: %s", result.Reason))
+	for team := 0; team < 2; team++ {
+		g.Log(fmt.Sprintf("%s: %d points, %d marks", g.teamName(team), g.round.Points[team], g.match.Scores[team]))
+	}
+
+	g.recordResult(result)
+	if !g.match.Over() {
+		g.Log("Press n to shake the next hand")
+		return
+	}
+
+	g.Log(fmt.Sprintf("[::b]MATCH WON by %s with %d marks", g.teamName(engine.Team(g.match.Winners()[0])), g.match.Scores[g.match.Winners()[0]]))
+	if g.OnEnd != nil {
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	} else {
+		g.Log("Press n to start a new match")
+	}
+}
@@ -0,0 +1,79 @@
+package domino
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+	"github.com/gusti-andika/domino/profile"
+	"github.com/gusti-andika/domino/rating"
+)
+
+func TestTexas42Game(t *testing.T) {
+	rules, _ := engine.LookupRuleset("texas-42")
+	game := NewTexas42Game(rules)
+	for i := 0; i < rules.Players; i++ {
+		game.Join(fmt.Sprintf("p%d", i+1), false)
+	}
+
+	if !game.Started() || game.viewer() != game.round.Turn || game.bid != engine.MinBid {
+		t.Fatal("Expecting a dealt hand waiting for the first bid")
+	}
+
+	tiles := map[engine.Tile]bool{}
+	for _, h := range game.round.Hands {
+		for _, t := range h {
+			tiles[t] = true
+		}
+	}
+
+	if len(tiles) != 28 {
+		t.Fatalf("Expecting the whole double-six deck dealt but got %d tiles", len(tiles))
+	}
+
+	game.makeBid(engine.TexasPass)
+	game.makeBid(34)
+	game.makeBid(engine.TexasPass)
+	game.makeBid(engine.TexasPass)
+	game.nameTrump(6)
+	if game.round.Bidder != 2 || game.round.Trump != 6 {
+		t.Fatalf("Expecting p3 to play 34 with sixes but got %s from %d", bidName(game.round.Bid), game.round.Bidder)
+	}
+
+	for turns := 0; !game.finish; turns++ {
+		if turns > 28 {
+			t.Fatal("Expecting the hand over once every tile is played")
+		}
+		game.play(game.round.LegalMoves()[0])
+	}
+
+	if len(game.match.Hands) != 1 || game.round.Points[0]+game.round.Points[1] != engine.AllBid {
+		t.Fatalf("Expecting the 42 points shared out in one hand but got %v", game.round.Points)
+	}
+
+	if tally(7) != "||||| ||" {
+		t.Fatalf("Expecting marks tallied by five but got %q", tally(7))
+	}
+}
+
+func TestTexas42Ratings(t *testing.T) {
+	rules, _ := engine.LookupRuleset("texas-42")
+	store, _ := profile.Load(filepath.Join(t.TempDir(), "profiles.json"))
+	game := NewTexas42Game(rules)
+	game.Profiles = store
+	for _, name := range []string{"ana", "cpu1", "cid", "cpu2"} {
+		game.Join(name, false)
+	}
+
+	// the opponents of ana and cid play the same strategy
+	for _, i := range []int{1, 3} {
+		game.seats[i].strategy, _ = engine.NewStrategy("balanced")
+	}
+
+	game.recordResult(engine.Result{Winner: 0, Winners: []int{0, 2}, Pips: make([]int, 4), Points: make([]int, 4)})
+	ana, cid, bot := store.Ratings.Get("ana"), store.Ratings.Get("cid"), store.Ratings.Get(rating.BotID("balanced"))
+	if ana.Value <= rating.Initial || ana.Value != cid.Value || bot.Value >= rating.Initial || bot.Games != 1 {
+		t.Fatalf("Expecting the partners to gain together against the bot rated once but got %+v, %+v, %+v", ana, cid, bot)
+	}
+}
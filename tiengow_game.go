@@ -0,0 +1,315 @@
+package domino
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// TienGowGame is the terminal screen of Tien Gow. The table has a row per
+// seat with the tiles it put on the trick, discards face down, and the
+// hand of the human below it. Unlike the line games nothing is matched end
+// to end; the rules live in engine.TienGowRound.
+type TienGowGame struct {
+	*tableGame
+
+	round *engine.TienGowRound
+	table *tview.Grid
+	hand  *tview.Flex
+	// cursor is the tile of the human's hand under the cursor, picked the
+	// tiles they are about to play
+	cursor int
+	picked map[int]bool
+}
+
+// NewTienGowGame returns the screen for a Tien Gow ruleset.
+func NewTienGowGame(rules engine.Ruleset) *TienGowGame {
+	game := &TienGowGame{
+		tableGame: newTableGame(rules),
+		table:     tview.NewGrid(),
+		hand:      tview.NewFlex(),
+		picked:    map[int]bool{},
+	}
+	game.variant = game
+
+	game.table.SetBorder(true).SetTitle("Tien Gow")
+	game.hand.SetBorder(true).SetTitle("Hand")
+
+	top := tview.NewFlex()
+	top.AddItem(game.table, 0, 2, false)
+	top.AddItem(game.log, 0, 1, false)
+	game.AddItem(top, 0, 2, false)
+	game.AddItem(game.hand, 12, 0, false)
+	game.AddItem(game.status, 1, 0, false)
+
+	game.SetInputCapture(game.input)
+	game.Log(fmt.Sprintf("Waiting for players of %s...", rules.Name))
+	return game
+}
+
+func (g *TienGowGame) seated(seat int) string {
+	return ""
+}
+
+func (g *TienGowGame) wentOut(seat int) bool {
+	return false
+}
+
+// deal starts the next hand of the match.
+func (g *TienGowGame) deal() {
+	g.finish = false
+	hand := len(g.match.Hands)
+	g.round = engine.DealTienGow(g.rng, g.rules, len(g.seats), hand)
+	g.Log(fmt.Sprintf("Hand %d: %s deals and leads", hand+1, g.current().name))
+	g.Log("Keys: Left/Right select, Space pick a tile, Enter play the picked tiles, h hint")
+	g.startTurn()
+}
+
+func (g *TienGowGame) current() *tableSeat {
+	return g.seats[g.round.Turn]
+}
+
+// startTurn refreshes the table for the player on turn and lets a CPU
+// player think outside the UI thread.
+func (g *TienGowGame) startTurn() {
+	if g.round.Over() {
+		g.end()
+		return
+	}
+
+	g.cursor, g.picked = 0, map[int]bool{}
+	g.refresh()
+	seat := g.current()
+	if seat.strategy == nil {
+		return
+	}
+
+	pos := g.round.Position()
+	go func() {
+		tiles := engine.ChooseTien(seat.strategy, pos)
+		time.Sleep(700 * time.Millisecond)
+		g.App.QueueUpdateDraw(func() {
+			if g.finish || g.current() != seat {
+				return
+			}
+
+			g.play(tiles)
+		})
+	}()
+}
+
+// viewer returns the human whose hand is shown: the only one, or the one
+// on turn when several share the screen. It is -1 when there is none.
+func (g *TienGowGame) viewer() int {
+	humans := []int{}
+	for i, s := range g.seats {
+		if s.strategy == nil {
+			humans = append(humans, i)
+		}
+	}
+
+	switch {
+	case len(humans) == 1:
+		return humans[0]
+	case !g.finish && g.current().strategy == nil:
+		return g.round.Turn
+	}
+
+	return -1
+}
+
+func (g *TienGowGame) refresh() {
+	g.refreshTable()
+	g.refreshHand()
+
+	status := fmt.Sprintf("[black::b]%s [HAND:%d/%d]", g.rules.Name, len(g.match.Hands)+1, g.rules.Hands)
+	if !g.finish {
+		status += fmt.Sprintf(" [ON TURN:%s]", g.current().name)
+		if !g.round.Leading() {
+			status += fmt.Sprintf(" [BEAT:%s]", g.round.Lead())
+		}
+	}
+	g.status.SetText(status)
+}
+
+// refreshTable shows the trick being played, or the last one while the
+// next is not led yet.
+func (g *TienGowGame) refreshTable() {
+	g.table.Clear()
+	plays, best := g.round.Trick, g.round.Best
+	g.table.SetTitle("Tien Gow")
+	if len(plays) == 0 && len(g.round.History) > 0 {
+		last := g.round.History[len(g.round.History)-1]
+		plays, best = last.Plays, -1
+		g.table.SetTitle(fmt.Sprintf("Tien Gow [last trick to %s]", g.seats[last.Winner].name))
+	}
+
+	rows := make([]int, len(g.seats))
+	for i := range rows {
+		rows[i] = 10
+	}
+	g.table.SetRows(rows...).SetColumns(24, 10, 10, 10, 10)
+
+	for i, s := range g.seats {
+		info := tview.NewTextView().SetDynamicColors(true)
+		fmt.Fprintf(info, "%s\n%d tiles won\n%d in hand\n", s.name, g.round.Won[i], len(g.round.Hands[i]))
+		if !g.finish && i == g.round.Turn {
+			fmt.Fprint(info, "[yellow]on turn[white]\n")
+		}
+		g.table.AddItem(info, i, 0, 1, 1, 0, 0, false)
+	}
+
+	for j, p := range plays {
+		for k, t := range p.Tiles {
+			card := NewChineseCard(t.A, t.B)
+			card.hideNotPlayedCard = p.Discard
+			if j == best {
+				card.MarkPlayable(true)
+			}
+			g.table.AddItem(card, p.Player, k+1, 1, 1, 0, 0, false)
+		}
+	}
+}
+
+// refreshHand shows the viewer's tiles, the picked ones marked.
+func (g *TienGowGame) refreshHand() {
+	g.hand.Clear()
+	viewer := g.viewer()
+	if viewer < 0 {
+		g.hand.SetTitle("Hand")
+		return
+	}
+
+	g.hand.SetTitle(fmt.Sprintf("Hand of %s", g.seats[viewer].name))
+	for i, t := range g.round.Hands[viewer] {
+		card := NewChineseCard(t.A, t.B)
+		if g.picked[i] {
+			card.MarkPlayable(true)
+		}
+		if viewer == g.round.Turn && i == g.cursor {
+			card.Highlight()
+		}
+		g.hand.AddItem(card, 10, 0, false)
+	}
+}
+
+// pickedTiles returns the picked tiles, the one under the cursor when none
+// is.
+func (g *TienGowGame) pickedTiles() []engine.Tile {
+	hand := g.round.Hands[g.round.Turn]
+	tiles := []engine.Tile{}
+	for i, t := range hand {
+		if g.picked[i] {
+			tiles = append(tiles, t)
+		}
+	}
+
+	if len(tiles) == 0 && g.cursor < len(hand) {
+		tiles = append(tiles, hand[g.cursor])
+	}
+
+	return tiles
+}
+
+func (g *TienGowGame) input(event *tcell.EventKey) *tcell.EventKey {
+	if g.round == nil {
+		return event
+	}
+
+	if g.finish {
+		switch {
+		case g.match.Over() && g.OnEnd != nil && event.Key() == tcell.KeyEnter:
+			g.App.Stop()
+		case event.Rune() == 'n':
+			if g.match.Over() {
+				g.match = engine.NewMatch(g.rules, len(g.seats))
+			}
+			g.deal()
+		}
+		return nil
+	}
+
+	if g.current().strategy != nil {
+		return event
+	}
+
+	size := len(g.round.Hands[g.round.Turn])
+	switch {
+	case event.Key() == tcell.KeyLeft:
+		g.cursor = (g.cursor + size - 1) % size
+	case event.Key() == tcell.KeyRight:
+		g.cursor = (g.cursor + 1) % size
+	case event.Rune() == ' ':
+		g.picked[g.cursor] = !g.picked[g.cursor]
+	case event.Key() == tcell.KeyEnter:
+		g.play(g.pickedTiles())
+		return nil
+	case event.Rune() == 'h':
+		s, _ := engine.NewStrategy(engine.DefaultStrategy)
+		g.Log(fmt.Sprintf("Hint: play %v", engine.ChooseTien(s, g.round.Position())))
+	}
+
+	g.refresh()
+	return nil
+}
+
+// play puts the tiles of the player on turn on the trick and logs it,
+// discards without showing them.
+func (g *TienGowGame) play(tiles []engine.Tile) {
+	seat, tricks := g.current(), len(g.round.History)
+	if err := g.round.Play(tiles); err != nil {
+		g.Log(err.Error())
+		return
+	}
+
+	plays := g.round.Trick
+	if len(g.round.History) > tricks {
+		plays = g.round.History[tricks].Plays
+	}
+
+	switch p := plays[len(plays)-1]; {
+	case p.Discard:
+		g.Log(fmt.Sprintf("%s discards %d", seat.name, len(p.Tiles)))
+	case len(plays) == 1:
+		lead, _ := engine.ClassifyTien(p.Tiles)
+		g.Log(fmt.Sprintf("%s leads %s %v", seat.name, lead, p.Tiles))
+	default:
+		g.Log(fmt.Sprintf("%s beats it with %v", seat.name, p.Tiles))
+	}
+
+	if len(g.round.History) > tricks {
+		g.Log(fmt.Sprintf("%s takes the trick", g.seats[g.round.History[tricks].Winner].name))
+	}
+	g.startTurn()
+}
+
+func (g *TienGowGame) end() {
+	result := g.round.Result()
+	g.match.Add(result)
+	g.finish = true
+	g.refresh()
+	g.Log(fmt.Sprintf("[::b]HAND FINISHED: %s", result.Reason))
+	for i, s := range g.seats {
+		g.Log(fmt.Sprintf("%s: %d tiles, %+d, total %d", s.name, g.round.Won[i], result.Points[i], g.match.Scores[i]))
+	}
+
+	g.recordResult(result)
+	if !g.match.Over() {
+		g.Log("Press n to deal the next hand")
+		return
+	}
+
+	for _, w := range g.match.Winners() {
+		g.Log(fmt.Sprintf("[::b]MATCH WON by %s with %d points", g.seats[w].name, g.match.Scores[w]))
+	}
+
+	if g.OnEnd != nil {
+		g.Log("Press Enter to continue")
+		g.OnEnd(g.match)
+	} else {
+		g.Log("Press n to start a new match")
+	}
+}
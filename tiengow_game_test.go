@@ -0,0 +1,36 @@
+package domino
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+func TestTienGowGame(t *testing.T) {
+	rules, _ := engine.LookupRuleset("tien-gow")
+	game := NewTienGowGame(rules)
+	for i := 0; i < rules.Players; i++ {
+		game.Join(fmt.Sprintf("p%d", i+1), false)
+	}
+
+	if !game.Started() || game.viewer() != game.round.Turn {
+		t.Fatal("Expecting a dealt hand showing the tiles of the leader")
+	}
+
+	for turns := 0; !game.finish; turns++ {
+		if turns > 32 {
+			t.Fatal("Expecting the hand over once every tile is played")
+		}
+		game.play(game.round.Options()[0])
+	}
+
+	won := 0
+	for _, w := range game.round.Won {
+		won += w
+	}
+
+	if len(game.match.Hands) != 1 || won != 32 {
+		t.Fatalf("Expecting every tile taken in one hand but got %d", won)
+	}
+}
@@ -0,0 +1,449 @@
+// Package tournament schedules games among a roster of humans and CPU
+// strategies, keeps the standings and saves its state after every game so an
+// interrupted tournament can be resumed.
+package tournament
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gusti-andika/domino/engine"
+)
+
+// Format is the way games are scheduled.
+type Format string
+
+const (
+	RoundRobin        Format = "roundrobin"
+	Swiss             Format = "swiss"
+	SingleElimination Format = "elimination"
+)
+
+const cpuPrefix = "cpu:"
+
+// Entrant is a tournament participant. CPU entrants have a Strategy.
+type Entrant struct {
+	Name     string `json:"name"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// ParseEntrant reads "cpu:<strategy>" and "external:<command>" as CPU
+// entrants and anything else as a human name.
+func ParseEntrant(s string) Entrant {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, cpuPrefix) {
+		return Entrant{Name: s, Strategy: strings.TrimPrefix(s, cpuPrefix)}
+	}
+
+	if strings.HasPrefix(s, engine.ExternalPrefix) {
+		return Entrant{Name: s, Strategy: s}
+	}
+
+	return Entrant{Name: s}
+}
+
+func (e Entrant) IsCpu() bool {
+	return e.Strategy != ""
+}
+
+// Result is the outcome of one table. Winners lists every winner of a
+// shared win; Winner is the first of them or -1 when nobody won.
+type Result struct {
+	Winner  int   `json:"winner"`
+	Winners []int `json:"winners,omitempty"`
+	Pips    []int `json:"pips"`
+	Blocked bool  `json:"blocked"`
+}
+
+// Single reports whether the table went to one winner alone.
+func (r *Result) Single() bool {
+	return r.Winner >= 0 && len(r.Winners) <= 1
+}
+
+func (r *Result) isWinner(seat int) bool {
+	if len(r.Winners) == 0 {
+		return seat == r.Winner
+	}
+
+	for _, w := range r.Winners {
+		if w == seat {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Game is a scheduled table. Seats holds entrant indexes and a game with a
+// single seat is a bye.
+type Game struct {
+	Round  int     `json:"round"`
+	Seats  []int   `json:"seats"`
+	Result *Result `json:"result,omitempty"`
+}
+
+func (g *Game) Done() bool {
+	return g.Result != nil || g.Bye()
+}
+
+func (g *Game) Bye() bool {
+	return len(g.Seats) == 1
+}
+
+// Tournament is the whole schedule and its results.
+type Tournament struct {
+	Format    Format    `json:"format"`
+	TableSize int       `json:"tableSize"`
+	Rounds    int       `json:"rounds"`
+	Entrants  []Entrant `json:"entrants"`
+	Games     []*Game   `json:"games"`
+	Round     int       `json:"round"`
+	path      string
+}
+
+// New creates a tournament. Rounds is only used by the Swiss format and
+// defaults to enough rounds to separate the field.
+func New(format Format, tableSize, rounds int, entrants []Entrant) (*Tournament, error) {
+	switch format {
+	case RoundRobin, Swiss, SingleElimination:
+	default:
+		return nil, fmt.Errorf("unknown tournament format %q", format)
+	}
+
+	if tableSize < 2 {
+		return nil, fmt.Errorf("tables need at least 2 players")
+	}
+
+	if len(entrants) < tableSize {
+		return nil, fmt.Errorf("%d entrants can not fill a table of %d", len(entrants), tableSize)
+	}
+
+	if format == Swiss && rounds <= 0 {
+		for n := 1; n < len(entrants); n *= tableSize {
+			rounds++
+		}
+	}
+
+	return &Tournament{Format: format, TableSize: tableSize, Rounds: rounds, Entrants: entrants}, nil
+}
+
+// Load resumes a tournament saved at path.
+func Load(path string) (*Tournament, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tournament{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+
+	t.path = path
+	return t, nil
+}
+
+// SaveTo sets the file the tournament is saved to and writes it.
+func (t *Tournament) SaveTo(path string) error {
+	t.path = path
+	return t.Save()
+}
+
+// Save writes the tournament to its file, if it has one.
+func (t *Tournament) Save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := t.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, t.path)
+}
+
+// Pending returns the games of the current round still to be played,
+// scheduling the next round when the current one is complete. It returns
+// nil when the tournament is over.
+func (t *Tournament) Pending() []*Game {
+	for {
+		pending := []*Game{}
+		for _, g := range t.Games {
+			if g.Round == t.Round && !g.Done() {
+				pending = append(pending, g)
+			}
+		}
+
+		if len(pending) > 0 {
+			return pending
+		}
+
+		if !t.schedule() {
+			return nil
+		}
+	}
+}
+
+// Record stores the result of a game and saves the tournament. A table of
+// an elimination tournament needs a single winner to go through, a shared
+// win or none has to be replayed.
+func (t *Tournament) Record(g *Game, r Result) error {
+	if t.Format == SingleElimination && !r.Single() {
+		return fmt.Errorf("round %d: an elimination table needs a single winner, replay it", g.Round)
+	}
+
+	g.Result = &r
+	return t.Save()
+}
+
+// schedule adds the next round of games and reports false when there is no
+// next round.
+func (t *Tournament) schedule() bool {
+	var tables [][]int
+	switch t.Format {
+	case RoundRobin:
+		tables = t.roundRobinTables()
+	case Swiss:
+		if t.Round < t.Rounds {
+			tables = t.swissTables()
+		}
+	case SingleElimination:
+		tables = t.eliminationTables()
+	}
+
+	if len(tables) == 0 {
+		return false
+	}
+
+	t.Round++
+	for _, seats := range tables {
+		t.Games = append(t.Games, &Game{Round: t.Round, Seats: seats})
+	}
+
+	return true
+}
+
+// roundRobinTables returns the next round of a schedule in which every
+// combination of TableSize entrants meets once. Combinations not played yet
+// are packed greedily into a round where nobody sits twice.
+func (t *Tournament) roundRobinTables() [][]int {
+	played := map[string]bool{}
+	for _, g := range t.Games {
+		played[fmt.Sprint(g.Seats)] = true
+	}
+
+	busy := map[int]bool{}
+	tables := [][]int{}
+	combinations(len(t.Entrants), t.TableSize, func(seats []int) {
+		if played[fmt.Sprint(seats)] {
+			return
+		}
+
+		for _, s := range seats {
+			if busy[s] {
+				return
+			}
+		}
+
+		for _, s := range seats {
+			busy[s] = true
+		}
+		tables = append(tables, append([]int(nil), seats...))
+	})
+
+	return tables
+}
+
+func combinations(n, k int, f func([]int)) {
+	seats := make([]int, k)
+	var rec func(start, depth int)
+	rec = func(start, depth int) {
+		if depth == k {
+			f(seats)
+			return
+		}
+
+		for i := start; i <= n-(k-depth); i++ {
+			seats[depth] = i
+			rec(i+1, depth+1)
+		}
+	}
+	rec(0, 0)
+}
+
+// swissTables seats entrants with similar scores together. The lowest
+// ranked entrants that do not fill a table get a bye, preferring those who
+// have not had one yet.
+func (t *Tournament) swissTables() [][]int {
+	order := []int{}
+	for _, s := range t.Standings() {
+		order = append(order, s.Entrant)
+	}
+
+	byes := len(order) % t.TableSize
+	tables := [][]int{}
+	for b := 0; b < byes; b++ {
+		pick := len(order) - 1
+		for i := len(order) - 1; i >= 0; i-- {
+			if !t.hadBye(order[i]) {
+				pick = i
+				break
+			}
+		}
+
+		tables = append(tables, []int{order[pick]})
+		order = append(order[:pick:pick], order[pick+1:]...)
+	}
+
+	for i := 0; i+t.TableSize <= len(order); i += t.TableSize {
+		tables = append(tables, append([]int(nil), order[i:i+t.TableSize]...))
+	}
+
+	return tables
+}
+
+func (t *Tournament) hadBye(entrant int) bool {
+	for _, g := range t.Games {
+		if g.Bye() && g.Seats[0] == entrant {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eliminationTables seats the entrants still in the tournament, the single
+// winner of each table going through. Entrants that do not fill a table get
+// a bye to the next round.
+func (t *Tournament) eliminationTables() [][]int {
+	alive := []int{}
+	if t.Round == 0 {
+		for i := range t.Entrants {
+			alive = append(alive, i)
+		}
+	} else {
+		for _, g := range t.Games {
+			if g.Round != t.Round {
+				continue
+			}
+
+			if g.Bye() {
+				alive = append(alive, g.Seats[0])
+			} else {
+				alive = append(alive, g.Seats[g.Result.Winner])
+			}
+		}
+	}
+
+	if len(alive) < 2 {
+		return nil
+	}
+
+	tables := [][]int{}
+	size := t.TableSize
+	if len(alive) < size {
+		size = len(alive)
+	}
+
+	for len(alive) >= size {
+		tables = append(tables, alive[:size])
+		alive = alive[size:]
+	}
+
+	for _, e := range alive {
+		tables = append(tables, []int{e})
+	}
+
+	return tables
+}
+
+// Standing is an entrant's line in the standings.
+type Standing struct {
+	Entrant int
+	Name    string
+	Played  int
+	Wins    int
+	Byes    int
+	Points  int
+	Pips    int
+	// Out is the round an entrant was knocked out of an elimination
+	// tournament, zero while still in
+	Out int
+}
+
+// Standings ranks the entrants by points, then by fewer pips left, then by
+// name. A win and a bye are both worth one point.
+func (t *Tournament) Standings() []Standing {
+	list := make([]Standing, len(t.Entrants))
+	for i, e := range t.Entrants {
+		list[i] = Standing{Entrant: i, Name: e.Name}
+	}
+
+	for _, g := range t.Games {
+		if g.Bye() {
+			list[g.Seats[0]].Byes++
+			list[g.Seats[0]].Points++
+			continue
+		}
+
+		if g.Result == nil {
+			continue
+		}
+
+		for seat, e := range g.Seats {
+			list[e].Played++
+			list[e].Pips += g.Result.Pips[seat]
+			if g.Result.isWinner(seat) {
+				list[e].Wins++
+				list[e].Points++
+			} else if t.Format == SingleElimination {
+				list[e].Out = g.Round
+			}
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if t.Format == SingleElimination && (a.Out == 0) != (b.Out == 0) {
+			return a.Out == 0
+		}
+
+		if t.Format == SingleElimination && a.Out != b.Out {
+			return a.Out > b.Out
+		}
+
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+
+		if a.Pips != b.Pips {
+			return a.Pips < b.Pips
+		}
+
+		return a.Name < b.Name
+	})
+
+	return list
+}
+
+// Report renders the standings as a plain text table.
+func (t *Tournament) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s tournament, round %d\n", t.Format, t.Round)
+	fmt.Fprintf(&b, "%-4s %-20s %6s %5s %5s %6s %6s\n", "#", "Entrant", "Played", "Wins", "Byes", "Points", "Pips")
+	for i, s := range t.Standings() {
+		fmt.Fprintf(&b, "%-4d %-20s %6d %5d %5d %6d %6d\n", i+1, s.Name, s.Played, s.Wins, s.Byes, s.Points, s.Pips)
+	}
+
+	return b.String()
+}
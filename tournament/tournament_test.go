@@ -0,0 +1,142 @@
+package tournament
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func entrants(names ...string) []Entrant {
+	list := []Entrant{}
+	for _, n := range names {
+		list = append(list, ParseEntrant(n))
+	}
+	return list
+}
+
+// playAll finishes every pending game, the lowest seated entrant winning.
+func playAll(t *testing.T, tm *Tournament) {
+	for games := tm.Pending(); games != nil; games = tm.Pending() {
+		for _, g := range games {
+			winner := 0
+			for i, e := range g.Seats {
+				if e < g.Seats[winner] {
+					winner = i
+				}
+			}
+
+			if err := tm.Record(g, Result{Winner: winner, Pips: make([]int, len(g.Seats))}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	tm, err := New(RoundRobin, 3, 0, entrants("a", "b", "c", "d", "cpu:heavy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	playAll(t, tm)
+
+	// every combination of 3 out of 5 meets once
+	if len(tm.Games) != 10 {
+		t.Fatalf("Expecting 10 games but got %d", len(tm.Games))
+	}
+
+	seated := map[int]map[int]bool{}
+	for _, g := range tm.Games {
+		if seated[g.Round] == nil {
+			seated[g.Round] = map[int]bool{}
+		}
+
+		for _, e := range g.Seats {
+			if seated[g.Round][e] {
+				t.Fatalf("Entrant %d seated twice in round %d", e, g.Round)
+			}
+			seated[g.Round][e] = true
+		}
+	}
+
+	standings := tm.Standings()
+	if standings[0].Name != "a" || standings[0].Wins != 6 {
+		t.Fatalf("Expecting a to win all 6 games but got %+v", standings[0])
+	}
+
+	if !tm.Entrants[4].IsCpu() || tm.Entrants[4].Strategy != "heavy" {
+		t.Fatalf("Expecting cpu:heavy parsed as CPU entrant but got %+v", tm.Entrants[4])
+	}
+}
+
+func TestSwissByes(t *testing.T) {
+	tm, err := New(Swiss, 2, 3, entrants("a", "b", "c", "d", "e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	playAll(t, tm)
+	if tm.Round != 3 {
+		t.Fatalf("Expecting 3 Swiss rounds but got %d", tm.Round)
+	}
+
+	byes := map[int]int{}
+	for _, g := range tm.Games {
+		if g.Bye() {
+			byes[g.Seats[0]]++
+		}
+	}
+
+	if len(byes) != 3 {
+		t.Fatalf("Expecting 3 different entrants to get a bye but got %v", byes)
+	}
+}
+
+func TestEliminationResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tournament")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "t.json")
+	tm, err := New(SingleElimination, 2, 0, entrants("a", "b", "c", "d", "e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tm.SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// a shared win sends nobody through, the table is replayed
+	first := tm.Pending()[0]
+	if err := tm.Record(first, Result{Winner: 0, Winners: []int{0, 1}, Pips: []int{4, 4}}); err == nil || first.Done() {
+		t.Fatalf("Expecting a shared win refused by an elimination table")
+	}
+
+	// play one game then resume from the file
+	tm.Record(first, Result{Winner: 0, Pips: []int{0, 9}})
+
+	tm, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tm.Games[0].Done() {
+		t.Fatalf("Expecting the recorded game to survive a resume")
+	}
+
+	playAll(t, tm)
+	standings := tm.Standings()
+	if standings[0].Name != "a" || standings[0].Out != 0 {
+		t.Fatalf("Expecting a to win the elimination but got %+v", standings)
+	}
+
+	for _, s := range standings[1:] {
+		if s.Out == 0 {
+			t.Fatalf("Expecting %s to be knocked out", s.Name)
+		}
+	}
+}
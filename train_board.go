@@ -0,0 +1,159 @@
+package domino
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gusti-andika/domino/engine"
+	"github.com/rivo/tview"
+)
+
+// trainTiles is how many tiles of a train the board shows, the older ones
+// are summarised by their count.
+const trainTiles = 10
+
+// TrainBoard shows the station double and every train leaving it, one row
+// per train.
+type TrainBoard struct {
+	*tview.Table
+}
+
+func NewTrainBoard() *TrainBoard {
+	board := &TrainBoard{Table: tview.NewTable()}
+	board.SetBorder(true).SetTitle("Trains")
+	return board
+}
+
+// Refresh redraws the board. names holds the seat names, selected is the
+// train the player on turn aims at and is highlighted, -1 for none.
+func (b *TrainBoard) Refresh(r *engine.TrainRound, names []string, selected int) {
+	b.Clear()
+	b.SetTitle(fmt.Sprintf("Trains [station %s, boneyard %d]", tview.Escape(r.Station.String()), len(r.Boneyard)))
+	for i, train := range r.Trains {
+		name := "Mexican train"
+		if !train.Mexican() {
+			name = fmt.Sprintf("%s (%d)", names[train.Owner], len(r.Hands[train.Owner]))
+		}
+
+		marker := ""
+		if train.Open && !train.Mexican() {
+			marker = "open"
+		}
+
+		color := tcell.ColorWhite
+		switch {
+		case i == r.Double:
+			color = tcell.ColorRed
+			marker = "double!"
+		case !train.Mexican() && train.Owner == r.Turn:
+			color = tcell.ColorBlue
+		}
+
+		tiles := []string{}
+		shown := train.Tiles
+		if len(shown) > trainTiles {
+			tiles = append(tiles, fmt.Sprintf("+%d", len(shown)-trainTiles))
+			shown = shown[len(shown)-trainTiles:]
+		}
+
+		for _, t := range shown {
+			tiles = append(tiles, fmt.Sprintf("%d|%d", t.A, t.B))
+		}
+
+		cells := []*tview.TableCell{
+			tview.NewTableCell(name).SetTextColor(color),
+			tview.NewTableCell(marker).SetTextColor(tcell.ColorYellow),
+			tview.NewTableCell(tview.Escape(fmt.Sprintf("[%d] %s", r.Station.A, strings.Join(tiles, " ")))).SetTextColor(color).SetExpansion(1),
+		}
+
+		for col, cell := range cells {
+			if i == selected {
+				cell.SetAttributes(tcell.AttrReverse)
+			}
+			b.SetCell(i, col, cell)
+		}
+	}
+}
+
+// trainLayout plays a TrainRound in a BranchGame, the targets are the
+// trains.
+type trainLayout struct {
+	*engine.TrainRound
+	view  *TrainBoard
+	names []string
+}
+
+func newTrainLayout(r *engine.TrainRound, names []string) *trainLayout {
+	return &trainLayout{TrainRound: r, view: NewTrainBoard(), names: names}
+}
+
+func (l *trainLayout) turn() int { return l.Turn }
+
+func (l *trainLayout) hand(player int) []engine.Tile { return l.Hands[player] }
+
+func (l *trainLayout) legal(player int) []placement {
+	moves := []placement{}
+	for _, m := range l.LegalMoves(player) {
+		moves = append(moves, placement{tile: m.Tile, target: m.Train})
+	}
+
+	return moves
+}
+
+func (l *trainLayout) play(p placement) error {
+	return l.Play(engine.TrainMove{Tile: p.tile, Train: p.target})
+}
+
+func (l *trainLayout) targets() []int {
+	targets := make([]int, len(l.Trains))
+	for i := range targets {
+		targets[i] = i
+	}
+
+	return targets
+}
+
+func (l *trainLayout) targetName(train int) string {
+	t := l.Trains[train]
+	if t.Mexican() {
+		return "the Mexican train"
+	}
+
+	return l.names[t.Owner] + "'s train"
+}
+
+func (l *trainLayout) after(p placement) string {
+	if l.Double == p.target {
+		return fmt.Sprintf("The double %s must be satisfied", tview.Escape(p.tile.String()))
+	}
+
+	return ""
+}
+
+func (l *trainLayout) hint() (placement, string, bool) {
+	scored := engine.EvaluateTrains(l.Position(l.Turn))
+	if len(scored) == 0 {
+		return placement{}, "", false
+	}
+
+	return placement{tile: scored[0].Tile, target: scored[0].Train}, scored[0].Reason, true
+}
+
+func (l *trainLayout) chooser(s engine.Strategy) func() (placement, bool) {
+	pos := l.Position(l.Turn)
+	return func() (placement, bool) {
+		m, ok := engine.ChooseTrain(s, pos)
+		return placement{tile: m.Tile, target: m.Train}, ok
+	}
+}
+
+func (l *trainLayout) board() tview.Primitive { return l.view }
+
+func (l *trainLayout) refresh(selected int) {
+	l.view.Refresh(l.TrainRound, l.names, selected)
+}
+
+func (l *trainLayout) describe() string {
+	return fmt.Sprintf("from station %s", tview.Escape(l.Station.String()))
+}